@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// summarize describes an entry of collection fetched upstream at storedAt.
+// removedAt may be zero, in which case it is derived from the collection's retention.
+func summarize(collection, key string, storedAt, removedAt time.Time) utils.CacheEntrySummary {
+	age := time.Since(storedAt)
+	if removedAt.IsZero() {
+		removedAt = storedAt.Add(collectionRetention(collection))
+	}
+	return utils.CacheEntrySummary{
+		Key:        key,
+		StoredAt:   storedAt,
+		AgeSeconds: int64(age.Seconds()),
+		TTLSeconds: int64((collectionTTL(collection) - age).Seconds()),
+		RemovedAt:  removedAt,
+		Stale:      age > collectionTTL(collection),
+	}
+}
+
+// ListEntries lists the entries of a collection whose keys start with prefix, ordered by key.
+// Without a shared tier, the entries held in process are listed instead.
+func ListEntries(ctx context.Context, collection, prefix string) ([]utils.CacheEntrySummary, error) {
+	summaries := []utils.CacheEntrySummary{}
+	shared := sharedTier()
+	if shared == nil {
+		for _, entry := range localTier(collection).snapshot(prefix) {
+			summaries = append(summaries, summarize(collection, entry.key, entry.storedAt, entry.expiresAt))
+		}
+		sort.Slice(summaries, func(i, j int) bool { return summaries[i].Key < summaries[j].Key })
+		return summaries, nil
+	}
+
+	infos, err := shared.Scan(ctx, collection, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		summaries = append(summaries, summarize(collection, info.Key, info.Timestamp, info.ExpiresAt))
+	}
+	return summaries, nil
+}
+
+// InspectEntry returns one entry with its data, read from the shared tier if it is enabled.
+// Returns db.ErrNotFound if there is no such entry.
+func InspectEntry(ctx context.Context, collection, key string) (*utils.CacheEntryDetail, error) {
+	shared := sharedTier()
+	if shared == nil {
+		for _, entry := range localTier(collection).snapshot(key) {
+			if entry.key == key {
+				return &utils.CacheEntryDetail{
+					CacheEntrySummary: summarize(collection, key, entry.storedAt, entry.expiresAt),
+					Data:              entry.value,
+				}, nil
+			}
+		}
+		return nil, db.ErrNotFound
+	}
+
+	var entry cacheEntry[interface{}]
+	if err := shared.Get(ctx, collection, key, &entry); err != nil {
+		return nil, err
+	}
+	return &utils.CacheEntryDetail{
+		CacheEntrySummary: summarize(collection, key, entry.Timestamp, time.Time{}),
+		Data:              entry.Data,
+	}, nil
+}
+
+// Invalidate removes one entry from both tiers. Other instances keep their in-process copy until it expires.
+func Invalidate(ctx context.Context, collection, key string) error {
+	localTier(collection).remove(key)
+	if shared := sharedTier(); shared != nil {
+		return shared.Delete(ctx, collection, key)
+	}
+	return nil
+}
+
+// InvalidatePrefix removes every entry whose key starts with prefix from both tiers; an empty prefix
+// clears the collection. Returns how many entries were removed from the shared tier, or from the
+// in-process tier if there is no shared tier.
+func InvalidatePrefix(ctx context.Context, collection, prefix string) (int, error) {
+	removedLocally := localTier(collection).removePrefix(prefix)
+	shared := sharedTier()
+	if shared == nil {
+		return removedLocally, nil
+	}
+
+	infos, err := shared.Scan(ctx, collection, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, info := range infos {
+		if err := shared.Delete(ctx, collection, info.Key); err != nil {
+			return i, err
+		}
+	}
+	return len(infos), nil
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheAdmin_ListInspectInvalidate(t *testing.T) {
+	ctx := context.Background()
+	ResetLocalCache()
+	t.Cleanup(ResetLocalCache)
+	_, _ = InvalidatePrefix(ctx, utils.CountryCacheCollection, "")
+
+	for _, iso := range []string{"NO", "NL", "SE"} {
+		require.NoError(t, SaveCountryInfoToCache(ctx, iso, utils.CountryInfoResponse{Capital: []string{iso + "-city"}}))
+	}
+
+	entries, err := ListEntries(ctx, utils.CountryCacheCollection, "N")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "NL", entries[0].Key)
+	assert.Equal(t, "NO", entries[1].Key)
+	assert.False(t, entries[0].Stale)
+	assert.Greater(t, entries[0].TTLSeconds, int64(0))
+
+	detail, err := InspectEntry(ctx, utils.CountryCacheCollection, "SE")
+	require.NoError(t, err)
+	assert.Equal(t, "SE", detail.Key)
+	assert.NotNil(t, detail.Data)
+
+	require.NoError(t, Invalidate(ctx, utils.CountryCacheCollection, "SE"))
+	_, err = InspectEntry(ctx, utils.CountryCacheCollection, "SE")
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	removed, err := InvalidatePrefix(ctx, utils.CountryCacheCollection, "N")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	_, err = GetCachedCountryInfo(ctx, "NO", time.Hour)
+	assert.Error(t, err, "invalidated entries are gone from the in-process tier too")
+}
+
+func TestStats_CountsLookupOutcomes(t *testing.T) {
+	ctx := context.Background()
+	ResetLocalCache()
+	t.Cleanup(ResetLocalCache)
+	before := statsFor(utils.WeatherCacheCollection)
+
+	key := WeatherCacheKey(12.5, 34.5)
+	_ = Invalidate(ctx, utils.WeatherCacheCollection, key)
+	_, err := GetCachedWeather(ctx, key, time.Hour)
+	require.Error(t, err)
+	require.NoError(t, SaveWeatherToCache(ctx, key, utils.WeatherData{Temperature: 4}))
+	_, err = GetCachedWeather(ctx, key, time.Hour)
+	require.NoError(t, err)
+	_, freshness, err := LookupWeather(ctx, key, -time.Second)
+	require.NoError(t, err)
+	require.True(t, freshness.Stale)
+
+	after := statsFor(utils.WeatherCacheCollection)
+	assert.Equal(t, int64(1), after.Misses-before.Misses)
+	assert.Equal(t, int64(1), after.Hits-before.Hits)
+	assert.Equal(t, int64(1), after.Stale-before.Stale)
+}
+
+// statsFor returns the current counters of one collection.
+func statsFor(collection string) utils.CacheCollectionStats {
+	for _, stats := range Stats() {
+		if stats.Collection == collection {
+			return stats
+		}
+	}
+	return utils.CacheCollectionStats{}
+}
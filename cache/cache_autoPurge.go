@@ -11,37 +11,51 @@ import (
 // It includes the name of the cache collection, the purge function to run, and an error message format.
 type purgeTask struct {
 	Name string
-	Func func(context.Context) error
+	Func func(context.Context) (utils.CachePurgeResult, error)
 	Err  string
 }
 
+// purgeTasks lists the purge job of every cache collection, using constants from the utils package.
+var purgeTasks = []purgeTask{
+	{Name: utils.CountryCacheCollection, Func: PurgeOldCountryCache, Err: utils.ErrPurgeCountryCache},
+	{Name: utils.WeatherCacheCollection, Func: PurgeOldWeatherCache, Err: utils.ErrPurgeWeatherCache},
+	{Name: utils.CurrencyCacheCollection, Func: PurgeOldCurrencyCache, Err: utils.ErrPurgeCurrencyCache},
+}
+
 // StartCachePurgeLoop launches a background loop that regularly purges expired cache entries.
-// It runs every interval defined by utils.CachePurgeInterval.
-func StartCachePurgeLoop() {
+// It runs every interval defined by utils.CachePurgeInterval until ctx is cancelled,
+// which also stops a purge cycle that is under way.
+func StartCachePurgeLoop(ctx context.Context) {
 	ticker := time.NewTicker(utils.CachePurgeInterval) // Schedule regular purging using a ticker set to every hour
-	defer ticker.Stop()                                // Stop the ticker if the loop ever exits (good practice)
+	defer ticker.Stop()                                // Stop the ticker when the loop exits
 
-	// Define the purge tasks for each cache type, using constants from the utils package
-	tasks := []purgeTask{
-		{Name: utils.CountryCacheCollection, Func: PurgeOldCountryCache, Err: utils.ErrPurgeCountryCache},
-		{Name: utils.WeatherCacheCollection, Func: PurgeOldWeatherCache, Err: utils.ErrPurgeWeatherCache},
-		{Name: utils.CurrencyCacheCollection, Func: PurgeOldCurrencyCache, Err: utils.ErrPurgeCurrencyCache},
-	}
-
-	// Infinite loop that performs cache purging at the specified interval
+	// Loop that performs cache purging at the specified interval until shutdown
 	for {
-		ctx := context.Background()           // Create a new background context for this purge cycle
-		log.Println(utils.MsgCachePurgeStart) // Log the start of the cache purge
-
-		// Execute each purge task in order
-		for _, task := range tasks {
-			if err := task.Func(ctx); err != nil {
-				// Log the task-specific error message if the purge function fails
-				log.Printf(task.Err, err)
-			}
+		RunPurgeCycle(ctx)
+		select {
+		case <-ctx.Done():
+			log.Println(utils.MsgCachePurgeStop)
+			return
+		case <-ticker.C: // Wait until the next tick to repeat
 		}
+	}
+}
+
+// RunPurgeCycle runs every purge task once, in order, and reports the outcome of each.
+func RunPurgeCycle(ctx context.Context) []utils.CachePurgeResult {
+	log.Println(utils.MsgCachePurgeStart) // Log the start of the cache purge
 
-		log.Println(utils.MsgCachePurgeDone) // Log the end of the purge cycle
-		<-ticker.C                           // Wait until the next tick to repeat
+	results := make([]utils.CachePurgeResult, 0, len(purgeTasks))
+	for _, task := range purgeTasks {
+		result, err := task.Func(ctx)
+		if err != nil {
+			// Log the task-specific error message if the purge function fails
+			log.Printf(task.Err, err)
+			result.Error = err.Error()
+		}
+		results = append(results, result)
 	}
+
+	log.Println(utils.MsgCachePurgeDone) // Log the end of the purge cycle
+	return results
 }
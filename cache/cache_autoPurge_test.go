@@ -3,8 +3,11 @@ package cache
 
 import (
 	"context"
-	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 // Dummy implementations for testing
@@ -14,23 +17,23 @@ func TestSinglePurgeCycle(t *testing.T) {
 	tasks := []purgeTask{
 		{
 			Name: "TestCountry",
-			Func: func(ctx context.Context) error {
+			Func: func(ctx context.Context) (utils.CachePurgeResult, error) {
 				calls["Country"] = true
-				return nil
+				return utils.CachePurgeResult{}, nil
 			},
 		},
 		{
 			Name: "TestWeather",
-			Func: func(ctx context.Context) error {
+			Func: func(ctx context.Context) (utils.CachePurgeResult, error) {
 				calls["Weather"] = true
-				return nil
+				return utils.CachePurgeResult{}, nil
 			},
 		},
 		{
 			Name: "TestCurrency",
-			Func: func(ctx context.Context) error {
+			Func: func(ctx context.Context) (utils.CachePurgeResult, error) {
 				calls["Currency"] = true
-				return nil
+				return utils.CachePurgeResult{}, nil
 			},
 		},
 	}
@@ -38,7 +41,7 @@ func TestSinglePurgeCycle(t *testing.T) {
 	// simulate one loop manually
 	ctx := context.Background()
 	for _, task := range tasks {
-		err := task.Func(ctx)
+		_, err := task.Func(ctx)
 		assert.NoError(t, err)
 	}
 
@@ -47,4 +50,18 @@ func TestSinglePurgeCycle(t *testing.T) {
 	assert.True(t, calls["Currency"])
 }
 
-// Note: We don't test StartCachePurgeLoop itself because it runs forever (infinite loop).
+func TestStartCachePurgeLoop_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		StartCachePurgeLoop(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("purge loop did not stop after its context was cancelled")
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// Backend is the shared (L2) cache tier behind the in-process cache. Entries are grouped into
+// collections and read back into a struct exposing matching Data and Timestamp fields.
+type Backend interface {
+	// Get decodes the entry stored under key into dest. Returns db.ErrNotFound if there is none.
+	Get(ctx context.Context, collection, key string, dest interface{}) error
+	// Set stores data with the current timestamp. Backends that support it drop the entry after ttl.
+	Set(ctx context.Context, collection, key string, data interface{}, ttl time.Duration) error
+	// Delete removes an entry. Deleting a missing key is not an error.
+	Delete(ctx context.Context, collection, key string) error
+	// Scan lists the entries of a collection whose keys start with prefix, ordered by key.
+	Scan(ctx context.Context, collection, prefix string) ([]EntryInfo, error)
+}
+
+// EntryInfo describes a cache entry without its data.
+type EntryInfo struct {
+	Key       string    `json:"key"`
+	Timestamp time.Time `json:"timestamp"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// StoreBackend keeps cache entries in the active db.Store. It has no native expiry;
+// entries are removed by the purge loop instead.
+type StoreBackend struct{}
+
+// Get decodes the entry stored under key into dest.
+func (StoreBackend) Get(ctx context.Context, collection, key string, dest interface{}) error {
+	return db.GetCacheEntry(ctx, collection, key, dest)
+}
+
+// Set stores data with the current timestamp. The ttl is enforced by the purge loop.
+func (StoreBackend) Set(ctx context.Context, collection, key string, data interface{}, _ time.Duration) error {
+	return db.SetCacheEntry(ctx, collection, key, data)
+}
+
+// Delete removes an entry.
+func (StoreBackend) Delete(ctx context.Context, collection, key string) error {
+	return db.DeleteCacheEntry(ctx, collection, key)
+}
+
+// Scan lists the entries whose keys start with prefix. Expiry is derived from the collection's retention.
+func (StoreBackend) Scan(ctx context.Context, collection, prefix string) ([]EntryInfo, error) {
+	infos := []EntryInfo{}
+	err := db.ForEachCacheEntry(ctx, collection, func(entry utils.CacheRecord) error {
+		if strings.HasPrefix(entry.Key, prefix) {
+			infos = append(infos, EntryInfo{
+				Key:       entry.Key,
+				Timestamp: entry.Timestamp,
+				ExpiresAt: entry.Timestamp.Add(collectionRetention(collection)),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// activeBackend is the shared tier used by the cache helpers; the store by default.
+var (
+	backendMu     sync.RWMutex
+	activeBackend Backend = StoreBackend{}
+)
+
+// UseBackend installs the shared cache tier.
+func UseBackend(backend Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	activeBackend = backend
+}
+
+// sharedTier returns the shared cache tier, or nil if utils.CacheL2Enabled is off.
+func sharedTier() Backend {
+	if !utils.CacheL2Enabled {
+		return nil
+	}
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return activeBackend
+}
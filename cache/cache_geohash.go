@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// geohashCell is the latitude/longitude box a geohash stands for.
+type geohashCell struct {
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// center returns the midpoint of the cell.
+func (c geohashCell) center() (lat, lon float64) {
+	return (c.minLat + c.maxLat) / 2, (c.minLon + c.maxLon) / 2
+}
+
+// encodeGeohash returns the geohash of a point, precision characters long (clamped to 1-12).
+// Points on a cell edge belong to the cell north and east of it; longitude 180 is longitude -180.
+func encodeGeohash(lat, lon float64, precision int) string {
+	precision = min(max(precision, 1), utils.MaxWeatherGeohashPrecision)
+	lon = wrapLongitude(lon)
+	cell := geohashCell{minLat: -90, maxLat: 90, minLon: -180, maxLon: 180}
+	hash := make([]byte, 0, precision)
+
+	// Bits alternate between longitude and latitude, starting with longitude; five make a character
+	index, bits, evenBit := 0, 0, true
+	for len(hash) < precision {
+		if evenBit {
+			mid := (cell.minLon + cell.maxLon) / 2
+			if lon >= mid {
+				index = index<<1 | 1
+				cell.minLon = mid
+			} else {
+				index <<= 1
+				cell.maxLon = mid
+			}
+		} else {
+			mid := (cell.minLat + cell.maxLat) / 2
+			if lat >= mid {
+				index = index<<1 | 1
+				cell.minLat = mid
+			} else {
+				index <<= 1
+				cell.maxLat = mid
+			}
+		}
+		evenBit = !evenBit
+
+		if bits++; bits == 5 {
+			hash = append(hash, utils.GeohashAlphabet[index])
+			index, bits = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// decodeGeohash returns the cell of a geohash, or false if hash is not one (such as a key written
+// before weather keys were geohashes).
+func decodeGeohash(hash string) (geohashCell, bool) {
+	if hash == "" || len(hash) > utils.MaxWeatherGeohashPrecision {
+		return geohashCell{}, false
+	}
+	cell := geohashCell{minLat: -90, maxLat: 90, minLon: -180, maxLon: 180}
+	evenBit := true
+	for _, char := range hash {
+		index := strings.IndexRune(utils.GeohashAlphabet, char)
+		if index < 0 {
+			return geohashCell{}, false
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			set := index&mask != 0
+			if evenBit {
+				mid := (cell.minLon + cell.maxLon) / 2
+				if set {
+					cell.minLon = mid
+				} else {
+					cell.maxLon = mid
+				}
+			} else {
+				mid := (cell.minLat + cell.maxLat) / 2
+				if set {
+					cell.minLat = mid
+				} else {
+					cell.maxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+	return cell, true
+}
+
+// geohashCellSize returns the height and width in degrees of the cells of geohashes precision characters long.
+func geohashCellSize(precision int) (latDeg, lonDeg float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2 // Longitude takes the first, odd bit
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
+
+// coveringPrecision returns the longest geohash length, up to limit, whose cells around latitude lat
+// are at least radiusKm tall and wide, so that a cell and its neighbours cover the radius.
+func coveringPrecision(lat, radiusKm float64, limit int) int {
+	kmPerDegree := utils.EarthRadiusKm * math.Pi / 180
+	for precision := limit; precision > 1; precision-- {
+		latDeg, lonDeg := geohashCellSize(precision)
+		if latDeg*kmPerDegree >= radiusKm && lonDeg*kmPerDegree*math.Cos(lat*math.Pi/180) >= radiusKm {
+			return precision
+		}
+	}
+	return 1
+}
+
+// neighbouringGeohashes returns the geohash of a point's cell and of the up to eight cells around it.
+// Longitudes wrap around the antimeridian; there are no cells beyond the poles.
+func neighbouringGeohashes(lat, lon float64, precision int) []string {
+	latDeg, lonDeg := geohashCellSize(precision)
+	seen := map[string]bool{}
+	var hashes []string
+	for _, dLat := range []float64{0, -1, 1} {
+		cellLat := lat + dLat*latDeg
+		if cellLat < -90 || cellLat > 90 {
+			continue
+		}
+		for _, dLon := range []float64{0, -1, 1} {
+			hash := encodeGeohash(cellLat, lon+dLon*lonDeg, precision)
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes
+}
+
+// wrapLongitude maps a longitude into [-180, 180).
+func wrapLongitude(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// distanceKm returns the great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * utils.EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// scanKeys lists the keys of a collection that start with prefix, from the shared tier if it is
+// enabled and from the in-process tier otherwise.
+func scanKeys(ctx context.Context, collection, prefix string) ([]string, error) {
+	var keys []string
+	shared := sharedTier()
+	if shared == nil {
+		for _, entry := range localTier(collection).snapshot(prefix) {
+			keys = append(keys, entry.key)
+		}
+		return keys, nil
+	}
+
+	infos, err := shared.Scan(ctx, collection, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		keys = append(keys, info.Key)
+	}
+	return keys, nil
+}
+
+// nearbyWeatherKeys returns the weather cache keys whose cells are centred within radiusKm of a point,
+// nearest first. Only the cells around the point are scanned, one prefix scan per cell, since
+// neighbouring cells across the equator or a meridian may share no prefix at all.
+func nearbyWeatherKeys(ctx context.Context, lat, lon, radiusKm float64) ([]string, error) {
+	cells := neighbouringGeohashes(lat, lon, coveringPrecision(lat, radiusKm, utils.WeatherGeohashPrecision))
+
+	distances := map[string]float64{}
+	var nearby []string
+	for _, prefix := range cells {
+		keys, err := scanKeys(ctx, utils.WeatherCacheCollection, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			cell, ok := decodeGeohash(key)
+			if !ok {
+				continue
+			}
+			cellLat, cellLon := cell.center()
+			if distance := distanceKm(lat, lon, cellLat, cellLon); distance <= radiusKm {
+				distances[key] = distance
+				nearby = append(nearby, key)
+			}
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool { return distances[nearby[i]] < distances[nearby[j]] })
+	return nearby, nil
+}
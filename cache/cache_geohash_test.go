@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	cases := []struct {
+		name      string
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{"northern and eastern", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{"negative coordinates", -25.382708, -49.265506, 12, "6gkzwgjzn820"},
+		{"origin belongs to the north-east", 0, 0, 5, "s0000"},
+		{"just south-west of the origin", -0.00001, -0.00001, 5, "7zzzz"},
+		{"just west of the prime meridian", 0, -0.00001, 5, "ebpbp"},
+		{"just south of the equator", -0.00001, 0, 5, "kpbpb"},
+		{"antimeridian wraps to the west", 0, 180, 3, encodeGeohash(0, -180, 3)},
+		{"precision is clamped", 59.91, 10.75, 40, encodeGeohash(59.91, 10.75, utils.MaxWeatherGeohashPrecision)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, encodeGeohash(tc.lat, tc.lon, tc.precision))
+		})
+	}
+}
+
+func TestDecodeGeohash(t *testing.T) {
+	cell, ok := decodeGeohash(encodeGeohash(-33.92, 18.42, 7))
+	require.True(t, ok)
+	assert.True(t, cell.minLat <= -33.92 && -33.92 < cell.maxLat)
+	assert.True(t, cell.minLon <= 18.42 && 18.42 < cell.maxLon)
+
+	for _, invalid := range []string{"", "59.9_10.8", "u4pa", "0123456789bcd"} {
+		_, ok := decodeGeohash(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestNeighbouringGeohashes(t *testing.T) {
+	around := neighbouringGeohashes(0.01, 179.99, 5)
+	assert.Len(t, around, 9)
+	assert.Contains(t, around, encodeGeohash(0.01, -179.99, 5), "cells across the antimeridian are neighbours")
+	assert.Contains(t, around, encodeGeohash(-0.01, -179.99, 5), "and so are the diagonal ones")
+
+	assert.Len(t, neighbouringGeohashes(89.99, 10, 5), 6, "there are no cells north of the pole")
+}
+
+func TestLookupNearestWeather(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name                 string
+		cachedLat, cachedLon float64
+		queryLat, queryLon   float64
+	}{
+		{"across the equator and prime meridian", 0.001, 0.001, -0.001, -0.001},
+		{"across the antimeridian", 0.01, 179.99, 0.01, -179.99},
+		{"southern hemisphere", -33.92, 18.42, -33.93, 18.45},
+		{"western hemisphere", -22.90, -43.20, -22.92, -43.19},
+	}
+
+	for _, l2 := range []bool{true, false} {
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				withWeatherStore(t, l2)
+				require.NotEqual(t, WeatherCacheKey(tc.cachedLat, tc.cachedLon), WeatherCacheKey(tc.queryLat, tc.queryLon))
+				require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(tc.cachedLat, tc.cachedLon), utils.WeatherData{Temperature: 12}))
+
+				weather, _, err := LookupNearestWeather(ctx, tc.queryLat, tc.queryLon, time.Hour)
+				require.NoError(t, err)
+				assert.Equal(t, 12.0, weather.Temperature)
+			})
+		}
+	}
+}
+
+func TestLookupNearestWeather_PrefersOwnAndNearestCells(t *testing.T) {
+	ctx := context.Background()
+	withWeatherStore(t, true)
+
+	require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(59.92, 10.70), utils.WeatherData{Temperature: 1}))
+	require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(59.96, 10.75), utils.WeatherData{Temperature: 2}))
+
+	weather, _, err := LookupNearestWeather(ctx, 59.92, 10.73, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, weather.Temperature, "the nearer cell answers")
+
+	require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(59.92, 10.73), utils.WeatherData{Temperature: 3}))
+	weather, _, err = LookupNearestWeather(ctx, 59.92, 10.73, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, weather.Temperature, "the point's own cell wins")
+}
+
+func TestLookupNearestWeather_OutsideRadius(t *testing.T) {
+	ctx := context.Background()
+	withWeatherStore(t, true)
+	require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(59.91, 10.75), utils.WeatherData{Temperature: 1}))
+
+	_, _, err := LookupNearestWeather(ctx, 60.2, 10.75, time.Hour)
+	assert.Error(t, err, "cells further away than the radius are not reused")
+
+	_, _, err = LookupNearestWeather(ctx, 59.96, 10.75, time.Hour)
+	require.NoError(t, err, "the cell is within the default radius")
+
+	utils.WeatherReuseRadiusKm = 0
+	_, _, err = LookupNearestWeather(ctx, 59.96, 10.75, time.Hour)
+	assert.Error(t, err, "a zero radius disables reuse")
+}
+
+// scanRecorder is the store backend recording the prefixes it is asked to scan.
+type scanRecorder struct {
+	StoreBackend
+	prefixes []string
+}
+
+func (r *scanRecorder) Scan(ctx context.Context, collection, prefix string) ([]EntryInfo, error) {
+	r.prefixes = append(r.prefixes, prefix)
+	return r.StoreBackend.Scan(ctx, collection, prefix)
+}
+
+func TestNearbyWeatherKeys_ScansEachNeighbouringCell(t *testing.T) {
+	ctx := context.Background()
+	withWeatherStore(t, true)
+	recorder := &scanRecorder{}
+	UseBackend(recorder)
+	t.Cleanup(func() { UseBackend(StoreBackend{}) })
+
+	// Just west of the prime meridian, where the neighbouring cells start with both g and u
+	require.NoError(t, SaveWeatherToCache(ctx, WeatherCacheKey(51.5, 0.01), utils.WeatherData{Temperature: 8}))
+	weather, _, err := LookupNearestWeather(ctx, 51.5, -0.01, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 8.0, weather.Temperature)
+
+	require.NotEmpty(t, recorder.prefixes)
+	assert.LessOrEqual(t, len(recorder.prefixes), 9)
+	for _, prefix := range recorder.prefixes {
+		assert.NotEmpty(t, prefix, "the whole weather collection is never scanned")
+	}
+}
+
+// withWeatherStore empties the weather cache and restores the weather and tier settings after the test.
+func withWeatherStore(t *testing.T, l2 bool) {
+	t.Helper()
+	previousL2, previousRadius, previousPrecision := utils.CacheL2Enabled, utils.WeatherReuseRadiusKm, utils.WeatherGeohashPrecision
+	t.Cleanup(func() {
+		utils.CacheL2Enabled, utils.WeatherReuseRadiusKm, utils.WeatherGeohashPrecision = previousL2, previousRadius, previousPrecision
+		ResetLocalCache()
+	})
+
+	utils.CacheL2Enabled = true
+	_, _ = InvalidatePrefix(context.Background(), utils.WeatherCacheCollection, "")
+	ResetLocalCache()
+	utils.CacheL2Enabled = l2
+	utils.WeatherReuseRadiusKm = utils.DefaultWeatherReuseRadiusKm
+	utils.WeatherGeohashPrecision = utils.DefaultWeatherGeohashPrecision
+}
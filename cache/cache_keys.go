@@ -1,24 +1,20 @@
 package cache
 
 import (
-	"fmt"
 	"github.com/amundfpl/Assignment-2/utils"
-	"sort"
 	"strings"
 )
 
-// WeatherCacheKey generates a unique cache key for a weather lookup based on latitude and longitude.
-// It uses a formatted string defined in utils.WeatherCacheKeyFormat.
+// WeatherCacheKey generates the cache key of the weather for a point: the geohash of its cell,
+// utils.WeatherGeohashPrecision characters long. Points in the same cell share one entry.
 func WeatherCacheKey(lat, lon float64) string {
-	return fmt.Sprintf(utils.WeatherCacheKeyFormat, lat, lon)
+	return encodeGeohash(lat, lon, utils.WeatherGeohashPrecision)
 }
 
-// CurrencyCacheKey generates a deterministic cache key for currency conversion based on
-// a base currency and a list of target currencies.
-// The target currencies are sorted to ensure the key is consistent regardless of input order.
-func CurrencyCacheKey(base string, targets []string) string {
-	sort.Strings(targets) // ensure deterministic key
-	return base + utils.CacheKeySeparator + strings.Join(targets, utils.CacheKeySeparator)
+// CurrencyCacheKey generates the cache key of the full rate table quoted from a base currency.
+// Every set of target currencies is derived from the same table, so the targets are not part of the key.
+func CurrencyCacheKey(base string) string {
+	return strings.ToUpper(strings.TrimSpace(base))
 }
 
 // CountryCacheKey normalizes an ISO country code by trimming whitespace and converting to uppercase.
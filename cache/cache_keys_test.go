@@ -2,7 +2,6 @@
 package cache
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,18 +9,13 @@ import (
 
 func TestWeatherCacheKey(t *testing.T) {
 	key := WeatherCacheKey(59.91, 10.75)
-	assert.Equal(t, "59.9_10.8", key) // Matches fmt.Sprintf("%.1f_%.1f", ...)
+	assert.Equal(t, "u4xsu", key) // Geohash of the point at utils.DefaultWeatherGeohashPrecision
+	assert.Equal(t, key, WeatherCacheKey(59.92, 10.76), "nearby points share a cell")
 }
 
-func TestCurrencyCacheKey_Deterministic(t *testing.T) {
-	targets := []string{"USD", "EUR", "SEK"}
-	key1 := CurrencyCacheKey("NOK", targets)
-
-	scrambled := []string{"SEK", "USD", "EUR"}
-	key2 := CurrencyCacheKey("NOK", scrambled)
-
-	assert.Equal(t, key1, key2)
-	assert.True(t, strings.HasPrefix(key1, "NOK"))
+func TestCurrencyCacheKey_OnePerBase(t *testing.T) {
+	assert.Equal(t, "NOK", CurrencyCacheKey(" nok"))
+	assert.Equal(t, CurrencyCacheKey("NOK"), CurrencyCacheKey("nok"))
 }
 
 func TestCountryCacheKey(t *testing.T) {
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// localEntry is one value held by the in-process cache tier.
+type localEntry struct {
+	key       string
+	value     interface{}
+	storedAt  time.Time // When the data was fetched upstream, carried over from the store tier
+	expiresAt time.Time
+}
+
+// localCache is a bounded, concurrency-safe LRU cache with per-entry expiry.
+// A capacity of 0 or less disables it.
+type localCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used entry at the front
+	entries  map[string]*list.Element
+}
+
+// newLocalCache returns an empty cache holding at most capacity entries.
+func newLocalCache(capacity int) *localCache {
+	return &localCache{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the value stored under key with the time it was fetched upstream, and marks it as recently
+// used. Entries past their expiry are dropped; entries older than maxAge are kept for less demanding callers.
+func (c *localCache) get(key string, maxAge time.Duration) (interface{}, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	entry := element.Value.(*localEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, time.Time{}, false
+	}
+	if isCacheExpired(entry.storedAt, maxAge) {
+		return nil, time.Time{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, entry.storedAt, true
+}
+
+// put stores value under key until storedAt+ttl, evicting least recently used entries beyond capacity.
+func (c *localCache) put(key string, value interface{}, storedAt time.Time, ttl time.Duration) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &localEntry{key: key, value: value, storedAt: storedAt, expiresAt: storedAt.Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*localEntry).key)
+	}
+}
+
+// remove drops the entry stored under key, if any.
+func (c *localCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// removePrefix drops every entry whose key starts with prefix and returns how many there were.
+func (c *localCache) removePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// snapshot lists the entries whose keys start with prefix, without marking them as used.
+func (c *localCache) snapshot(prefix string) []*localEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entries []*localEntry
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, element.Value.(*localEntry))
+		}
+	}
+	return entries
+}
+
+// len returns the number of entries held, including expired ones not yet dropped.
+func (c *localCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// localTiers holds one in-process cache per collection, created on first use so that
+// utils.CacheL1MaxEntries can still be set from flags at startup.
+var (
+	localTiersMu sync.Mutex
+	localTiers   = map[string]*localCache{}
+)
+
+// localTier returns the in-process cache for a collection.
+func localTier(collection string) *localCache {
+	localTiersMu.Lock()
+	defer localTiersMu.Unlock()
+
+	tier, ok := localTiers[collection]
+	if !ok {
+		tier = newLocalCache(utils.CacheL1MaxEntries)
+		localTiers[collection] = tier
+	}
+	return tier
+}
+
+// ResetLocalCache empties the in-process tier of every collection and applies the current
+// utils.CacheL1MaxEntries. Used by tests and after the store tier was changed behind the cache's back.
+func ResetLocalCache() {
+	localTiersMu.Lock()
+	defer localTiersMu.Unlock()
+	localTiers = map[string]*localCache{}
+}
+
+// collectionTTL is how long entries of a cache collection are fresh.
+func collectionTTL(collection string) time.Duration {
+	switch collection {
+	case utils.WeatherCacheCollection:
+		return utils.WeatherCacheTTL
+	case utils.CurrencyCacheCollection:
+		return utils.CurrencyCacheTTL
+	}
+	return utils.CountryCacheTTL
+}
+
+// collectionRetention is how long entries of a cache collection are kept: their TTL plus the
+// grace window in which they may still be served as stale.
+func collectionRetention(collection string) time.Duration {
+	return collectionTTL(collection) + utils.CacheStaleGrace
+}
+
+// lookupLocal returns the in-process copy of a cache entry and its timestamp if it is no older than maxAge.
+// The value is shared between callers and must not be modified.
+func lookupLocal[T any](collection, key string, maxAge time.Duration) (*T, time.Time, bool) {
+	value, storedAt, ok := localTier(collection).get(key, maxAge)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	typed, ok := value.(*T)
+	return typed, storedAt, ok
+}
+
+// storeLocal puts data into the in-process tier, keeping the time it was originally cached.
+func storeLocal[T any](collection, key string, data T, storedAt time.Time) {
+	localTier(collection).put(key, &data, storedAt, collectionRetention(collection))
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	local := newLocalCache(2)
+	now := time.Now()
+	local.put("a", 1, now, time.Hour)
+	local.put("b", 2, now, time.Hour)
+
+	_, _, ok := local.get("a", time.Hour) // "a" is now the most recently used
+	require.True(t, ok)
+	local.put("c", 3, now, time.Hour)
+
+	_, _, ok = local.get("b", time.Hour)
+	assert.False(t, ok, "least recently used entry is evicted")
+	value, _, ok := local.get("a", time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, local.len())
+}
+
+func TestLocalCache_ExpiryAndMaxAge(t *testing.T) {
+	local := newLocalCache(10)
+	local.put("old", "v", time.Now().Add(-2*time.Hour), time.Hour)
+	local.put("recent", "v", time.Now().Add(-30*time.Minute), time.Hour)
+
+	_, _, ok := local.get("old", 24*time.Hour)
+	assert.False(t, ok, "entries past their TTL are not served")
+	assert.Equal(t, 1, local.len(), "expired entries are dropped on read")
+
+	_, _, ok = local.get("recent", 10*time.Minute)
+	assert.False(t, ok, "entries older than the caller's max age are not served")
+	_, _, ok = local.get("recent", time.Hour)
+	assert.True(t, ok, "but are kept for less demanding callers")
+}
+
+func TestLocalCache_ZeroCapacityDisables(t *testing.T) {
+	local := newLocalCache(0)
+	local.put("a", 1, time.Now(), time.Hour)
+	_, _, ok := local.get("a", time.Hour)
+	assert.False(t, ok)
+}
+
+func TestGetCache_ReadThroughPopulatesLocalTier(t *testing.T) {
+	ctx := context.Background()
+	ResetLocalCache()
+	t.Cleanup(ResetLocalCache)
+
+	key := "TEST_READ_THROUGH"
+	require.NoError(t, db.SetCacheEntry(ctx, utils.WeatherCacheCollection, key, utils.WeatherData{Temperature: 3}))
+
+	cached, err := GetCachedWeather(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, cached.Temperature)
+
+	// Served from the in-process tier once the store entry is gone
+	_, err = db.PurgeCacheEntries(ctx, utils.WeatherCacheCollection, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	cached, err = GetCachedWeather(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, cached.Temperature)
+}
+
+func TestSetCache_WithoutStoreTier(t *testing.T) {
+	ctx := context.Background()
+	ResetLocalCache()
+	utils.CacheL2Enabled = false
+	t.Cleanup(func() {
+		utils.CacheL2Enabled = true
+		ResetLocalCache()
+	})
+
+	key := CurrencyCacheKey("NOK")
+	require.NoError(t, SaveCurrencyRatesToCache(ctx, key, map[string]float64{"SEK": 1.02}))
+	rates, err := GetCachedCurrencyRates(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1.02, rates["SEK"])
+
+	var entry struct{ Data map[string]float64 }
+	assert.ErrorIs(t, db.GetCacheEntry(ctx, utils.CurrencyCacheCollection, key, &entry), db.ErrNotFound,
+		"nothing is written to the store tier")
+
+	_, err = GetCachedCurrencyRates(ctx, CurrencyCacheKey("DKK"), time.Hour)
+	assert.ErrorIs(t, err, errLocalMiss)
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
@@ -9,45 +10,44 @@ import (
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
-// purgeCacheCollection deletes all documents in a given Firestore collection that are older than the specified duration.
-// - collection: the Firestore collection name
-// - olderThan: documents with timestamps before (now - olderThan) will be purged
-func purgeCacheCollection(ctx context.Context, collection string, olderThan time.Duration) error {
-	// Calculate time threshold for stale documents
+// purgeCacheCollection deletes all entries in a given cache collection that are older than the specified duration.
+// - collection: the cache collection name
+// - olderThan: entries with timestamps before (now - olderThan) will be purged
+// The result lists every entry that could not be deleted; the error is set if any failed or the purge stopped early.
+func purgeCacheCollection(ctx context.Context, collection string, olderThan time.Duration) (utils.CachePurgeResult, error) {
+	// Calculate time threshold for stale entries
 	threshold := time.Now().Add(-olderThan)
 
-	// Build a Firestore query to find documents older than the threshold
-	query := db.FirestoreClient().
-		Collection(collection).
-		Where(utils.TimestampField, utils.OperatorLessThan, threshold)
-
-	// Execute the query
-	docs, err := query.Documents(ctx).GetAll()
-	if err != nil {
-		return err // Return any query error
+	// Let the active store find and delete everything older than the threshold, a batch at a time
+	result := utils.CachePurgeResult{Collection: collection}
+	purged, err := db.PurgeCacheEntries(ctx, collection, threshold)
+	result.Purged = purged
+
+	// Report each entry that could not be deleted; the store carried on past them
+	var purgeErr *db.PurgeError
+	if errors.As(err, &purgeErr) {
+		result.Failures = purgeErr.Failures
+		for _, failure := range purgeErr.Failures {
+			log.Printf(utils.ErrPurgeEntry, failure.Key, collection, failure.Error)
+		}
 	}
 
-	// Log how many documents will be purged
-	log.Printf(utils.MsgPurgeSuccess, len(docs), collection)
-
-	// Delete each matching document
-	for _, doc := range docs {
-		_, _ = doc.Ref.Delete(ctx) // Ignoring delete errors (optional: handle if needed)
-	}
-	return nil
+	// Log how many entries were purged
+	log.Printf(utils.MsgPurgeSuccess, purged, collection)
+	return result, err
 }
 
-// PurgeOldCountryCache purges outdated entries from the country cache based on its TTL setting.
-func PurgeOldCountryCache(ctx context.Context) error {
-	return purgeCacheCollection(ctx, utils.CountryCacheCollection, utils.CountryCacheTTL) //Purge old country cache every 24 hours
+// PurgeOldCountryCache purges country cache entries past their TTL and stale grace window.
+func PurgeOldCountryCache(ctx context.Context) (utils.CachePurgeResult, error) {
+	return purgeCacheCollection(ctx, utils.CountryCacheCollection, collectionRetention(utils.CountryCacheCollection))
 }
 
-// PurgeOldWeatherCache purges outdated entries from the weather cache based on its TTL setting.
-func PurgeOldWeatherCache(ctx context.Context) error {
-	return purgeCacheCollection(ctx, utils.WeatherCacheCollection, utils.WeatherCacheTTL) // Purge old weather cache every 2 hour
+// PurgeOldWeatherCache purges weather cache entries past their TTL and stale grace window.
+func PurgeOldWeatherCache(ctx context.Context) (utils.CachePurgeResult, error) {
+	return purgeCacheCollection(ctx, utils.WeatherCacheCollection, collectionRetention(utils.WeatherCacheCollection))
 }
 
-// PurgeOldCurrencyCache purges outdated entries from the currency cache based on its TTL setting.
-func PurgeOldCurrencyCache(ctx context.Context) error {
-	return purgeCacheCollection(ctx, utils.CurrencyCacheCollection, utils.CurrencyCacheTTL) // Purge old currency cache every 12 hour
+// PurgeOldCurrencyCache purges currency cache entries past their TTL and stale grace window.
+func PurgeOldCurrencyCache(ctx context.Context) (utils.CachePurgeResult, error) {
+	return purgeCacheCollection(ctx, utils.CurrencyCacheCollection, collectionRetention(utils.CurrencyCacheCollection))
 }
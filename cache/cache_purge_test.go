@@ -11,29 +11,30 @@ import (
 	"github.com/amundfpl/Assignment-2/testsetup"
 	"github.com/amundfpl/Assignment-2/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain(m *testing.M) {
 	// Koble til testdatabasen én gang før alle tester
-	testsetup.InitTestFirebase()
+	testsetup.InitTestStore()
 	os.Exit(m.Run())
 }
 
 func TestPurgeOldCountryCache_NoError(t *testing.T) {
 	ctx := context.Background()
-	err := PurgeOldCountryCache(ctx)
+	_, err := PurgeOldCountryCache(ctx)
 	assert.NoError(t, err)
 }
 
 func TestPurgeOldWeatherCache_NoError(t *testing.T) {
 	ctx := context.Background()
-	err := PurgeOldWeatherCache(ctx)
+	_, err := PurgeOldWeatherCache(ctx)
 	assert.NoError(t, err)
 }
 
 func TestPurgeOldCurrencyCache_NoError(t *testing.T) {
 	ctx := context.Background()
-	err := PurgeOldCurrencyCache(ctx)
+	_, err := PurgeOldCurrencyCache(ctx)
 	assert.NoError(t, err)
 }
 
@@ -41,23 +42,61 @@ func TestPurgeEmptyCollection(t *testing.T) {
 	ctx := context.Background()
 	collection := "test_cache"
 
-	// Legg til dokument med gammel timestamp
-	_, _, err := db.FirestoreClient().Collection(collection).Add(ctx, map[string]interface{}{
-		utils.TimestampField: time.Now().Add(-2 * time.Hour),
-	})
+	// Legg til et dokument
+	err := db.SetCacheEntry(ctx, collection, "stale", map[string]interface{}{"value": 1})
 	assert.NoError(t, err)
+	time.Sleep(time.Millisecond)
 
-	// Slett dokumenter eldre enn 1 time
-	err = purgeCacheCollection(ctx, collection, 1*time.Hour)
+	// Slett alle dokumenter eldre enn nå
+	result, err := purgeCacheCollection(ctx, collection, 0)
 	assert.NoError(t, err)
 
-	// Verifiser at alle dokumenter er slettet
-	docs, err := db.FirestoreClient().Collection(collection).Documents(ctx).GetAll()
-	assert.NoError(t, err)
-	assert.Len(t, docs, 0)
+	assert.Equal(t, 1, result.Purged)
+
+	// Verifiser at dokumentet er slettet
+	var entry cacheEntry[map[string]interface{}]
+	err = db.GetCacheEntry(ctx, collection, "stale", &entry)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
 
-	// Rydd opp i tilfelle noe gjenstår
-	for _, doc := range docs {
-		_, _ = doc.Ref.Delete(ctx)
+// failingPurgeStore reports one entry as undeletable on every purge.
+type failingPurgeStore struct {
+	db.Store
+}
+
+func (s failingPurgeStore) PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time, batchSize int) (int, error) {
+	purged, _ := s.Store.PurgeCacheEntries(ctx, collection, olderThan, batchSize)
+	return purged, &db.PurgeError{
+		Collection: collection,
+		Failures:   []utils.CachePurgeFailure{{Key: "stuck", Error: "permission denied"}},
 	}
 }
+
+func TestPurgeCacheCollection_ReportsFailures(t *testing.T) {
+	previous := db.CurrentStore()
+	db.UseStore(failingPurgeStore{Store: db.NewMemoryStore()})
+	t.Cleanup(func() { db.UseStore(previous) })
+	ctx := context.Background()
+	require.NoError(t, db.SetCacheEntry(ctx, utils.CountryCacheCollection, "NO", "data"))
+	time.Sleep(time.Millisecond)
+
+	result, err := purgeCacheCollection(ctx, utils.CountryCacheCollection, 0)
+	var purgeErr *db.PurgeError
+	require.ErrorAs(t, err, &purgeErr)
+	assert.Equal(t, 1, result.Purged)
+	assert.Equal(t, []utils.CachePurgeFailure{{Key: "stuck", Error: "permission denied"}}, result.Failures)
+
+	results := RunPurgeCycle(ctx)
+	require.Len(t, results, 3)
+	assert.Equal(t, utils.CountryCacheCollection, results[0].Collection)
+	assert.Len(t, results[0].Failures, 1)
+	assert.Equal(t, "could not delete 1 entries from country_cache", results[0].Error)
+}
+
+func TestPurgeCacheCollection_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := purgeCacheCollection(ctx, "test_cache", 0)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,308 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// respError is an error reply sent by the server.
+type respError string
+
+func (e respError) Error() string {
+	return fmt.Sprintf(utils.ErrRESPReply, string(e))
+}
+
+// respConn is one connection to the server with its buffered reader.
+type respConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// respEntry is the JSON value stored for each cache entry.
+type respEntry struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// RESPBackend is a Backend on any server speaking the Redis protocol (Redis, Valkey, KeyDB, ...),
+// so that horizontally scaled instances share one cache. Entries are stored as JSON under
+// "<utils.RedisKeyPrefix><collection>:<key>" and expire natively after their TTL.
+type RESPBackend struct {
+	addr     string
+	password string
+	idle     chan *respConn
+}
+
+// NewRESPBackend connects to the server at addr, authenticating with password if it is set.
+// Returns an error if the server cannot be reached.
+func NewRESPBackend(ctx context.Context, addr, password string) (*RESPBackend, error) {
+	backend := &RESPBackend{addr: addr, password: password, idle: make(chan *respConn, utils.RedisMaxIdleConns)}
+	if _, err := backend.do(ctx, "PING"); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// Close closes every idle connection.
+func (b *RESPBackend) Close() error {
+	for {
+		select {
+		case conn := <-b.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisKey namespaces a cache key by collection.
+func redisKey(collection, key string) string {
+	return utils.RedisKeyPrefix + collection + utils.RedisKeySeparator + key
+}
+
+// Get decodes the entry stored under key into dest.
+func (b *RESPBackend) Get(ctx context.Context, collection, key string, dest interface{}) error {
+	reply, err := b.do(ctx, "GET", redisKey(collection, key))
+	if err != nil {
+		return err
+	}
+	raw, ok := reply.([]byte)
+	if !ok {
+		return db.ErrNotFound
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+// Set stores data with the current timestamp, expiring it after ttl if ttl is positive.
+func (b *RESPBackend) Set(ctx context.Context, collection, key string, data interface{}, ttl time.Duration) error {
+	raw, err := json.Marshal(respEntry{Timestamp: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+	args := []string{"SET", redisKey(collection, key), string(raw)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err = b.do(ctx, args...)
+	return err
+}
+
+// Delete removes an entry.
+func (b *RESPBackend) Delete(ctx context.Context, collection, key string) error {
+	_, err := b.do(ctx, "DEL", redisKey(collection, key))
+	return err
+}
+
+// Scan lists the entries whose keys start with prefix, using SCAN so the server is never blocked.
+func (b *RESPBackend) Scan(ctx context.Context, collection, prefix string) ([]EntryInfo, error) {
+	namespace := redisKey(collection, "")
+	pattern := escapeGlob(namespace+prefix) + "*"
+
+	seen := map[string]bool{} // SCAN may return a key more than once
+	cursor := "0"
+	for {
+		reply, err := b.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(utils.RedisScanCount))
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return nil, fmt.Errorf(utils.ErrRESPProtocol, reply)
+		}
+		next, _ := page[0].([]byte)
+		keys, _ := page[1].([]interface{})
+		for _, key := range keys {
+			if raw, ok := key.([]byte); ok {
+				seen[string(raw)] = true
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	infos := []EntryInfo{}
+	for fullKey := range seen {
+		info, found, err := b.entryInfo(ctx, fullKey)
+		if err != nil {
+			return nil, err
+		}
+		if found { // Entries may expire between SCAN and GET
+			info.Key = strings.TrimPrefix(fullKey, namespace)
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// entryInfo reads the timestamp and remaining lifetime of a stored entry.
+func (b *RESPBackend) entryInfo(ctx context.Context, fullKey string) (EntryInfo, bool, error) {
+	reply, err := b.do(ctx, "GET", fullKey)
+	if err != nil {
+		return EntryInfo{}, false, err
+	}
+	raw, ok := reply.([]byte)
+	if !ok {
+		return EntryInfo{}, false, nil
+	}
+	var stored struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return EntryInfo{}, false, err
+	}
+	info := EntryInfo{Timestamp: stored.Timestamp}
+
+	reply, err = b.do(ctx, "PTTL", fullKey)
+	if err != nil {
+		return EntryInfo{}, false, err
+	}
+	if remaining, ok := reply.(int64); ok && remaining > 0 {
+		info.ExpiresAt = time.Now().Add(time.Duration(remaining) * time.Millisecond)
+	}
+	return info, true, nil
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards.
+func escapeGlob(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// do sends one command and returns its reply: a string for status replies, []byte or nil for bulk
+// strings, int64 for integers and []interface{} for arrays. Error replies are returned as errors.
+func (b *RESPBackend) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := b.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(utils.RedisDefaultTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	reply, err := roundTrip(conn, args)
+	if _, isReply := err.(respError); err != nil && !isReply {
+		_ = conn.Close() // The connection state is unknown after an I/O or protocol error
+		return nil, err
+	}
+	b.release(conn)
+	return reply, err
+}
+
+// conn takes an idle connection or dials a new, authenticated one.
+func (b *RESPBackend) conn(ctx context.Context) (*respConn, error) {
+	select {
+	case conn := <-b.idle:
+		return conn, nil
+	default:
+	}
+
+	var dialer net.Dialer
+	raw, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &respConn{Conn: raw, reader: bufio.NewReader(raw)}
+	if b.password != "" {
+		if err := conn.SetDeadline(time.Now().Add(utils.RedisDefaultTimeout)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		if _, err := roundTrip(conn, []string{"AUTH", b.password}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release returns a healthy connection to the idle pool, closing it if the pool is full.
+func (b *RESPBackend) release(conn *respConn) {
+	select {
+	case b.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+}
+
+// roundTrip writes a command as an array of bulk strings and reads the reply.
+func roundTrip(conn *respConn, args []string) (interface{}, error) {
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(command.String())); err != nil {
+		return nil, err
+	}
+	return readReply(conn.reader)
+}
+
+// readReply parses one RESP2 reply.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf(utils.ErrRESPProtocol, line)
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, respError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err // Null bulk string
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err // Null array
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf(utils.ErrRESPProtocol, line)
+}
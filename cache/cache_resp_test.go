@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRESPServer implements the handful of Redis commands RESPBackend uses.
+type fakeRESPServer struct {
+	password string
+	mu       sync.Mutex
+	values   map[string]string
+	expiries map[string]time.Time
+}
+
+// startFakeRESPServer listens on a random local port until the test ends and returns its address.
+func startFakeRESPServer(t *testing.T, password string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeRESPServer{password: password, values: map[string]string{}, expiries: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// serve answers the commands of one connection until it is closed.
+func (s *fakeRESPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			raw, _ := item.([]byte)
+			args[i] = string(raw)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		command := strings.ToUpper(args[0])
+		var response string
+		switch {
+		case command == "AUTH":
+			authenticated = args[1] == s.password
+			response = "+OK\r\n"
+			if !authenticated {
+				response = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			response = "-NOAUTH Authentication required.\r\n"
+		default:
+			response = s.execute(command, args[1:])
+		}
+		if _, err := conn.Write([]byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs one authenticated command and returns the encoded reply.
+func (s *fakeRESPServer) execute(command string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, expiry := range s.expiries {
+		if time.Now().After(expiry) {
+			delete(s.values, key)
+			delete(s.expiries, key)
+		}
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := s.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		s.values[args[0]] = args[1]
+		delete(s.expiries, args[0])
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			s.expiries[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		_, ok := s.values[args[0]]
+		delete(s.values, args[0])
+		delete(s.expiries, args[0])
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "PTTL":
+		if _, ok := s.values[args[0]]; !ok {
+			return ":-2\r\n"
+		}
+		expiry, ok := s.expiries[args[0]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(expiry).Milliseconds())
+	case "SCAN":
+		// Every key in one page; the pattern is args[2] after "MATCH"
+		var keys strings.Builder
+		count := 0
+		for key := range s.values {
+			if matched, _ := path.Match(args[2], key); matched {
+				fmt.Fprintf(&keys, "$%d\r\n%s\r\n", len(key), key)
+				count++
+			}
+		}
+		return fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n%s", count, keys.String())
+	}
+	return "-ERR unknown command '" + command + "'\r\n"
+}
+
+// testBackendContract checks the behaviour every Backend must share.
+func testBackendContract(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	collection := utils.WeatherCacheCollection
+	prefix := fmt.Sprintf("contract-%d-", time.Now().UnixNano())
+	t.Cleanup(func() {
+		for _, key := range []string{"a", "ab", "b"} {
+			_ = backend.Delete(ctx, collection, prefix+key)
+		}
+	})
+
+	for _, key := range []string{"a", "ab", "b"} {
+		require.NoError(t, backend.Set(ctx, collection, prefix+key, utils.WeatherData{Temperature: 4}, time.Hour))
+	}
+
+	var entry cacheEntry[utils.WeatherData]
+	require.NoError(t, backend.Get(ctx, collection, prefix+"a", &entry))
+	assert.Equal(t, 4.0, entry.Data.Temperature)
+	assert.WithinDuration(t, time.Now(), entry.Timestamp, time.Minute)
+	assert.ErrorIs(t, backend.Get(ctx, collection, prefix+"missing", &entry), db.ErrNotFound)
+
+	infos, err := backend.Scan(ctx, collection, prefix+"a")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, prefix+"a", infos[0].Key)
+	assert.Equal(t, prefix+"ab", infos[1].Key)
+	assert.True(t, infos[0].ExpiresAt.After(time.Now()))
+
+	infos, err = backend.Scan(ctx, collection, prefix+"*")
+	require.NoError(t, err)
+	assert.Empty(t, infos, "wildcards in the prefix match literally")
+
+	require.NoError(t, backend.Delete(ctx, collection, prefix+"a"))
+	assert.ErrorIs(t, backend.Get(ctx, collection, prefix+"a", &entry), db.ErrNotFound)
+	assert.NoError(t, backend.Delete(ctx, collection, prefix+"a"), "deleting a missing key is not an error")
+}
+
+func TestBackendContract_Store(t *testing.T) {
+	testBackendContract(t, StoreBackend{})
+}
+
+func TestBackendContract_RESP(t *testing.T) {
+	backend, err := NewRESPBackend(context.Background(), startFakeRESPServer(t, "secret"), "secret")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+	testBackendContract(t, backend)
+}
+
+func TestBackendContract_Redis(t *testing.T) {
+	addr := os.Getenv(utils.EnvRedisAddr)
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	backend, err := NewRESPBackend(context.Background(), addr, os.Getenv(utils.EnvRedisPassword))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+	testBackendContract(t, backend)
+}
+
+func TestNewRESPBackend_Failures(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewRESPBackend(ctx, startFakeRESPServer(t, "secret"), "wrong")
+	var reply respError
+	assert.ErrorAs(t, err, &reply)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	_, err = NewRESPBackend(ctx, addr, "")
+	assert.Error(t, err, "an unreachable server fails at startup")
+}
+
+func TestRESPBackend_ExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewRESPBackend(ctx, startFakeRESPServer(t, ""), "")
+	require.NoError(t, err)
+
+	require.NoError(t, backend.Set(ctx, utils.CountryCacheCollection, "no", "data", 20*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	var entry cacheEntry[string]
+	assert.ErrorIs(t, backend.Get(ctx, utils.CountryCacheCollection, "no", &entry), db.ErrNotFound)
+}
+
+func TestGetCache_ThroughRESPBackend(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewRESPBackend(ctx, startFakeRESPServer(t, ""), "")
+	require.NoError(t, err)
+	UseBackend(backend)
+	ResetLocalCache()
+	t.Cleanup(func() {
+		UseBackend(StoreBackend{})
+		ResetLocalCache()
+	})
+
+	key := CurrencyCacheKey("NOK")
+	require.NoError(t, SaveCurrencyRatesToCache(ctx, key, map[string]float64{"EUR": 0.085}))
+	ResetLocalCache() // Force a read from the shared tier, as another instance would
+
+	rates, err := GetCachedCurrencyRates(ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0.085, rates["EUR"])
+
+	var entry struct{ Data map[string]float64 }
+	assert.ErrorIs(t, db.GetCacheEntry(ctx, utils.CurrencyCacheCollection, key, &entry), db.ErrNotFound,
+		"nothing is written to the storage backend")
+}
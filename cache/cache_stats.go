@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// lookupOutcome is how a cache lookup ended, for the per-collection counters.
+type lookupOutcome int
+
+const (
+	outcomeHit lookupOutcome = iota
+	outcomeStale
+	outcomeMiss
+	outcomeExpired
+)
+
+// collectionCounters counts the lookups of one collection since startup.
+type collectionCounters struct {
+	outcomes [outcomeExpired + 1]atomic.Int64
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = map[string]*collectionCounters{}
+)
+
+// uncountedContextKey marks contexts whose lookups are left out of the counters.
+type uncountedContextKey struct{}
+
+// WithoutStats returns a copy of ctx whose cache lookups are not counted, so that background
+// work such as cache warming does not show up as client hits and misses.
+func WithoutStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncountedContextKey{}, true)
+}
+
+// countLookup records the outcome of one lookup in collection, unless ctx comes from WithoutStats.
+func countLookup(ctx context.Context, collection string, outcome lookupOutcome) {
+	if uncounted, _ := ctx.Value(uncountedContextKey{}).(bool); uncounted {
+		return
+	}
+	countersMu.Lock()
+	c, ok := counters[collection]
+	if !ok {
+		c = &collectionCounters{}
+		counters[collection] = c
+	}
+	countersMu.Unlock()
+	c.outcomes[outcome].Add(1)
+}
+
+// Stats returns the lookup counters of every cache collection, in the order of utils.CacheCollections.
+func Stats() []utils.CacheCollectionStats {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	stats := make([]utils.CacheCollectionStats, 0, len(utils.CacheCollections))
+	for _, collection := range utils.CacheCollections {
+		entry := utils.CacheCollectionStats{Collection: collection}
+		if c, ok := counters[collection]; ok {
+			entry.Hits = c.outcomes[outcomeHit].Load()
+			entry.Stale = c.outcomes[outcomeStale].Load()
+			entry.Misses = c.outcomes[outcomeMiss].Load()
+			entry.Expired = c.outcomes[outcomeExpired].Load()
+		}
+		stats = append(stats, entry)
+	}
+	return stats
+}
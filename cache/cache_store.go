@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/amundfpl/Assignment-2/db"
@@ -22,32 +23,109 @@ func isCacheExpired(timestamp time.Time, maxAge time.Duration) bool {
 	return time.Since(timestamp) > maxAge
 }
 
-// setCache stores a generic value into Firestore with a timestamp in the specified collection under the given document ID.
+// errLocalMiss is wrapped into cache misses when only the in-process tier is enabled.
+var errLocalMiss = errors.New(utils.ErrCacheL1Miss)
+
+// Freshness tells how old cached data is compared to the age the caller asked for.
+type Freshness struct {
+	StoredAt time.Time // When the data was fetched upstream
+	Stale    bool      // Older than the caller's max age, but still within utils.CacheStaleGrace
+}
+
+// cacheMessages holds the error messages a collection reports its failures with.
+type cacheMessages struct {
+	miss, decode, expired string
+}
+
+var (
+	defaultCacheMessages  = cacheMessages{utils.ErrCacheMiss, utils.ErrCacheDecode, utils.ErrCacheExpired}
+	currencyCacheMessages = cacheMessages{utils.ErrCacheMissCurrency, utils.ErrCacheDecodeCurrency, utils.ErrCacheExpiredCurrency}
+)
+
+// setCache stores a generic value with a timestamp in the in-process tier and, if enabled,
+// in the shared tier's collection under the given document ID.
 func setCache[T any](ctx context.Context, collection, docID string, data T) error {
-	_, err := db.FirestoreClient().Collection(collection).Doc(docID).Set(ctx, map[string]interface{}{
-		utils.FieldData:      data,
-		utils.TimestampField: time.Now(),
-	})
-	return err
+	storeLocal(collection, docID, data, time.Now())
+	shared := sharedTier()
+	if shared == nil {
+		return nil
+	}
+	return shared.Set(ctx, collection, docID, data, collectionRetention(collection))
 }
 
-// getCache retrieves a value from Firestore, checks if it's expired, and returns the typed data.
-// It returns an error if the document is missing, decoding fails, or the data is too old.
-func getCache[T any](ctx context.Context, collection, docID string, maxAge time.Duration) (*T, error) {
-	doc, err := db.FirestoreClient().Collection(collection).Doc(docID).Get(ctx)
-	if err != nil {
-		return nil, fmt.Errorf(utils.ErrCacheMiss, docID, err)
+// lookupCache retrieves a value from the in-process tier, falling back to the shared tier. Values no
+// older than maxAge are fresh; values up to utils.CacheStaleGrace older are returned marked as stale.
+// Values read from the shared tier populate the in-process tier.
+// It returns an error if the document is missing, decoding fails, or the data is older than the grace window.
+func lookupCache[T any](ctx context.Context, collection, docID string, maxAge time.Duration, msgs cacheMessages) (*T, Freshness, error) {
+	if data, storedAt, ok := lookupLocal[T](collection, docID, maxAge+utils.CacheStaleGrace); ok {
+		return data, countFreshness(ctx, collection, storedAt, maxAge), nil
+	}
+	shared := sharedTier()
+	if shared == nil {
+		countLookup(ctx, collection, outcomeMiss)
+		return nil, Freshness{}, fmt.Errorf(msgs.miss, docID, errLocalMiss)
 	}
 
 	var entry cacheEntry[T]
-	if err := doc.DataTo(&entry); err != nil {
-		return nil, fmt.Errorf(utils.ErrCacheDecode, docID, err)
+	if err := shared.Get(ctx, collection, docID, &entry); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			countLookup(ctx, collection, outcomeMiss)
+			return nil, Freshness{}, fmt.Errorf(msgs.miss, docID, err)
+		}
+		return nil, Freshness{}, fmt.Errorf(msgs.decode, docID, err)
+	}
+
+	if isCacheExpired(entry.Timestamp, maxAge+utils.CacheStaleGrace) {
+		countLookup(ctx, collection, outcomeExpired)
+		return nil, Freshness{}, errors.New(msgs.expired)
 	}
 
-	if isCacheExpired(entry.Timestamp, maxAge) {
-		return nil, errors.New(utils.ErrCacheExpired)
+	storeLocal(collection, docID, entry.Data, entry.Timestamp)
+	return &entry.Data, countFreshness(ctx, collection, entry.Timestamp, maxAge), nil
+}
+
+// countFreshness records a served lookup as a hit or a stale hit and returns its Freshness.
+func countFreshness(ctx context.Context, collection string, storedAt time.Time, maxAge time.Duration) Freshness {
+	freshness := Freshness{StoredAt: storedAt, Stale: isCacheExpired(storedAt, maxAge)}
+	if freshness.Stale {
+		countLookup(ctx, collection, outcomeStale)
+	} else {
+		countLookup(ctx, collection, outcomeHit)
+	}
+	return freshness
+}
+
+// getCache is lookupCache without stale data: values older than maxAge are reported as expired.
+func getCache[T any](ctx context.Context, collection, docID string, maxAge time.Duration, msgs cacheMessages) (*T, error) {
+	data, freshness, err := lookupCache[T](ctx, collection, docID, maxAge, msgs)
+	if err != nil {
+		return nil, err
+	}
+	if freshness.Stale {
+		return nil, errors.New(msgs.expired)
+	}
+	return data, nil
+}
+
+// peekCache returns a cached value however old it is, without counting the lookup. The shared tier is
+// read even past the collection's retention, since entries linger there until they are purged.
+func peekCache[T any](ctx context.Context, collection, docID string, msgs cacheMessages) (*T, error) {
+	if data, _, ok := lookupLocal[T](collection, docID, collectionRetention(collection)); ok {
+		return data, nil
+	}
+	shared := sharedTier()
+	if shared == nil {
+		return nil, fmt.Errorf(msgs.miss, docID, errLocalMiss)
 	}
 
+	var entry cacheEntry[T]
+	if err := shared.Get(ctx, collection, docID, &entry); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, fmt.Errorf(msgs.miss, docID, err)
+		}
+		return nil, fmt.Errorf(msgs.decode, docID, err)
+	}
 	return &entry.Data, nil
 }
 
@@ -56,7 +134,21 @@ func getCache[T any](ctx context.Context, collection, docID string, maxAge time.
 // GetCachedCountryInfo retrieves cached country data for a given ISO code if it is not expired.
 func GetCachedCountryInfo(ctx context.Context, iso string, maxAge time.Duration) (*utils.CountryInfoResponse, error) {
 	key := CountryCacheKey(iso)
-	return getCache[utils.CountryInfoResponse](ctx, utils.CountryCacheCollection, key, maxAge)
+	return getCache[utils.CountryInfoResponse](ctx, utils.CountryCacheCollection, key, maxAge, defaultCacheMessages)
+}
+
+// LookupCountryInfo is GetCachedCountryInfo that also returns data up to utils.CacheStaleGrace past maxAge,
+// marked as stale.
+func LookupCountryInfo(ctx context.Context, iso string, maxAge time.Duration) (*utils.CountryInfoResponse, Freshness, error) {
+	key := CountryCacheKey(iso)
+	return lookupCache[utils.CountryInfoResponse](ctx, utils.CountryCacheCollection, key, maxAge, defaultCacheMessages)
+}
+
+// PeekCountryInfo returns cached country data however old it is, so that an expired copy can be
+// revalidated upstream instead of downloaded again. The value is shared and must not be modified.
+func PeekCountryInfo(ctx context.Context, iso string) (*utils.CountryInfoResponse, error) {
+	key := CountryCacheKey(iso)
+	return peekCache[utils.CountryInfoResponse](ctx, utils.CountryCacheCollection, key, defaultCacheMessages)
 }
 
 // SaveCountryInfoToCache stores country data in the cache for the given ISO code.
@@ -69,7 +161,38 @@ func SaveCountryInfoToCache(ctx context.Context, iso string, data utils.CountryI
 
 // GetCachedWeather retrieves cached weather data by key if it is not expired.
 func GetCachedWeather(ctx context.Context, key string, maxAge time.Duration) (*utils.WeatherData, error) {
-	return getCache[utils.WeatherData](ctx, utils.WeatherCacheCollection, key, maxAge)
+	return getCache[utils.WeatherData](ctx, utils.WeatherCacheCollection, key, maxAge, defaultCacheMessages)
+}
+
+// LookupWeather is GetCachedWeather that also returns data up to utils.CacheStaleGrace past maxAge,
+// marked as stale.
+func LookupWeather(ctx context.Context, key string, maxAge time.Duration) (*utils.WeatherData, Freshness, error) {
+	return lookupCache[utils.WeatherData](ctx, utils.WeatherCacheCollection, key, maxAge, defaultCacheMessages)
+}
+
+// LookupNearestWeather is LookupWeather for the cell of a point. If that cell is not cached, the
+// nearest cached cell centred within utils.WeatherReuseRadiusKm answers instead.
+func LookupNearestWeather(ctx context.Context, lat, lon float64, maxAge time.Duration) (*utils.WeatherData, Freshness, error) {
+	key := WeatherCacheKey(lat, lon)
+	weather, freshness, err := LookupWeather(ctx, key, maxAge)
+	if err == nil || utils.WeatherReuseRadiusKm <= 0 {
+		return weather, freshness, err
+	}
+
+	nearby, scanErr := nearbyWeatherKeys(ctx, lat, lon, utils.WeatherReuseRadiusKm)
+	if scanErr != nil {
+		log.Printf(utils.ErrNearbyWeatherScan, lat, lon, scanErr)
+		return nil, freshness, err
+	}
+	for _, nearbyKey := range nearby {
+		if nearbyKey == key {
+			continue
+		}
+		if nearbyWeather, nearbyFreshness, nearbyErr := LookupWeather(ctx, nearbyKey, maxAge); nearbyErr == nil {
+			return nearbyWeather, nearbyFreshness, nil
+		}
+	}
+	return nil, freshness, err
 }
 
 // SaveWeatherToCache stores weather data in the cache under the given key.
@@ -79,31 +202,26 @@ func SaveWeatherToCache(ctx context.Context, key string, data utils.WeatherData)
 
 // --- Currency Cache ---
 
-// GetCachedCurrencyRates retrieves cached currency exchange rates if available and not expired.
-// Unlike other types, this uses a manual struct instead of the generic cacheEntry due to map typing.
+// GetCachedCurrencyRates retrieves a cached rate table, keyed by CurrencyCacheKey, if available and not expired.
 func GetCachedCurrencyRates(ctx context.Context, key string, maxAge time.Duration) (map[string]float64, error) {
-	doc, err := db.FirestoreClient().Collection(utils.CurrencyCacheCollection).Doc(key).Get(ctx)
+	rates, err := getCache[map[string]float64](ctx, utils.CurrencyCacheCollection, key, maxAge, currencyCacheMessages)
 	if err != nil {
-		return nil, fmt.Errorf(utils.ErrCacheMissCurrency, key, err)
-	}
-
-	// Manually define struct since map[string]float64 doesn't work with generics directly
-	var entry struct {
-		Timestamp time.Time
-		Data      map[string]float64
-	}
-	if err := doc.DataTo(&entry); err != nil {
-		return nil, fmt.Errorf(utils.ErrCacheDecodeCurrency, key, err)
+		return nil, err
 	}
+	return *rates, nil
+}
 
-	if isCacheExpired(entry.Timestamp, maxAge) {
-		return nil, errors.New(utils.ErrCacheExpiredCurrency)
+// LookupCurrencyRates is GetCachedCurrencyRates that also returns rates up to utils.CacheStaleGrace past maxAge,
+// marked as stale.
+func LookupCurrencyRates(ctx context.Context, key string, maxAge time.Duration) (map[string]float64, Freshness, error) {
+	rates, freshness, err := lookupCache[map[string]float64](ctx, utils.CurrencyCacheCollection, key, maxAge, currencyCacheMessages)
+	if err != nil {
+		return nil, freshness, err
 	}
-
-	return entry.Data, nil
+	return *rates, freshness, nil
 }
 
-// SaveCurrencyRatesToCache stores currency exchange rates under the given key in the cache.
+// SaveCurrencyRatesToCache stores a rate table under the given key in the cache.
 func SaveCurrencyRatesToCache(ctx context.Context, key string, rates map[string]float64) error {
 	return setCache(ctx, utils.CurrencyCacheCollection, key, rates)
 }
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/utils"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -52,7 +54,7 @@ func TestSetAndGetWeatherCache(t *testing.T) {
 
 func TestSetAndGetCurrencyCache(t *testing.T) {
 	ctx := context.Background()
-	key := CurrencyCacheKey("NOK", []string{"USD", "EUR"})
+	key := CurrencyCacheKey("NOK")
 	rates := map[string]float64{
 		"USD": 0.10,
 		"EUR": 0.09,
@@ -66,3 +68,28 @@ func TestSetAndGetCurrencyCache(t *testing.T) {
 	assert.Equal(t, rates["USD"], cached["USD"])
 	assert.Equal(t, rates["EUR"], cached["EUR"])
 }
+
+func TestLookupWeather_StaleWithinGrace(t *testing.T) {
+	ctx := context.Background()
+	ResetLocalCache()
+	t.Cleanup(ResetLocalCache)
+
+	put := func(key string, age time.Duration) {
+		err := db.PutCacheEntry(ctx, utils.WeatherCacheCollection, utils.CacheRecord{
+			Key: key, Timestamp: time.Now().Add(-age), Data: json.RawMessage(`{"Temperature": 7}`),
+		})
+		assert.NoError(t, err)
+	}
+	put("TEST_STALE", 3*time.Hour)
+	put("TEST_TOO_OLD", 2*time.Hour+utils.CacheStaleGrace+time.Minute)
+
+	weather, freshness, err := LookupWeather(ctx, "TEST_STALE", 2*time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, freshness.Stale)
+	assert.Equal(t, 7.0, weather.Temperature)
+	_, err = GetCachedWeather(ctx, "TEST_STALE", 2*time.Hour)
+	assert.EqualError(t, err, utils.ErrCacheExpired, "callers that do not accept stale data still see it as expired")
+
+	_, _, err = LookupWeather(ctx, "TEST_TOO_OLD", 2*time.Hour)
+	assert.EqualError(t, err, utils.ErrCacheExpired, "data past the grace window is never served")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/server"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// runCommand runs the maintenance command named by args[0] against the selected store
+// instead of starting the server.
+func runCommand(storeOpts server.StoreOptions, args []string) error {
+	switch args[0] {
+	case utils.CommandMigrate:
+		return withStore(storeOpts, func() error { return runMigrate(args[1:]) })
+	case utils.CommandBackup:
+		return withStore(storeOpts, func() error { return runBackup(args[1:]) })
+	case utils.CommandRestore:
+		return withStore(storeOpts, func() error { return runRestore(args[1:]) })
+	default:
+		return fmt.Errorf(utils.ErrUnknownCommand, args[0])
+	}
+}
+
+// withStore initializes the storage backend, runs fn and closes the backend again.
+func withStore(storeOpts server.StoreOptions, fn func() error) error {
+	if dbInitErr := server.DatabaseInitialization(storeOpts); dbInitErr != nil {
+		log.Fatalf(utils.ErrMsgInitDB, dbInitErr)
+	}
+	defer func() {
+		if closeErr := db.CloseStore(); closeErr != nil {
+			log.Printf(utils.ErrMsgCloseStore, closeErr)
+		}
+	}()
+	return fn()
+}
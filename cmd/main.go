@@ -1,11 +1,223 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/amundfpl/Assignment-2/server"
+	"github.com/amundfpl/Assignment-2/utils"
 )
 
 // main is the entry point of the application.
-// It logs startup and delegates to the server package to launch the HTTP server.
+// It parses command-line flags and delegates to the server package to launch the HTTP server,
+// or runs a maintenance command (migrate, backup or restore) when one follows the flags.
+// The storage backend defaults to the STORE_BACKEND environment variable, then Firestore,
+// and the SQL data source name defaults to DATABASE_URL. The trash retention defaults to
+// TRASH_RETENTION, then utils.DefaultTrashRetention, and the admin API key to ADMIN_API_KEY.
+// The in-process cache size and store tier default to CACHE_SIZE and CACHE_L2, the shared
+// cache backend to CACHE_BACKEND and REDIS_ADDR, and the warm-up budget to CACHE_WARM_BUDGET.
+// The cache TTLs default to COUNTRY_CACHE_TTL, WEATHER_CACHE_TTL and CURRENCY_CACHE_TTL, and the
+// weather cache cells to WEATHER_GEOHASH_PRECISION and WEATHER_REUSE_RADIUS. Upstream GET attempts
+// default to HTTP_MAX_ATTEMPTS.
+// The Redis password is only read from REDIS_PASSWORD.
 func main() {
-	server.StartServer()
+	defaultStore := os.Getenv(utils.EnvStore)
+	if defaultStore == "" {
+		defaultStore = utils.StoreFirestore
+	}
+
+	store := flag.String(utils.FlagStore, defaultStore, utils.FlagStoreUsage)
+	dsn := flag.String(utils.FlagDSN, os.Getenv(utils.EnvDSN), utils.FlagDSNUsage)
+	flag.StringVar(&utils.AdminAPIKey, utils.FlagAdminKey, os.Getenv(utils.EnvAdminKey), utils.FlagAdminKeyUsage)
+	flag.DurationVar(&utils.TrashRetention, utils.FlagTrashRetention, defaultTrashRetention(), utils.FlagTrashRetentionUsage)
+	flag.IntVar(&utils.CacheL1MaxEntries, utils.FlagCacheSize, defaultCacheSize(), utils.FlagCacheSizeUsage)
+	flag.BoolVar(&utils.CacheL2Enabled, utils.FlagCacheL2, defaultCacheL2(), utils.FlagCacheL2Usage)
+	flag.IntVar(&utils.CacheWarmBudget, utils.FlagCacheWarmBudget, defaultCacheWarmBudget(), utils.FlagCacheWarmBudgetUsage)
+	flag.DurationVar(&utils.CountryCacheTTL, utils.FlagCountryCacheTTL,
+		defaultCacheTTL(utils.EnvCountryCacheTTL, utils.DefaultCountryCacheTTL), utils.FlagCountryCacheTTLUsage)
+	flag.DurationVar(&utils.WeatherCacheTTL, utils.FlagWeatherCacheTTL,
+		defaultCacheTTL(utils.EnvWeatherCacheTTL, utils.DefaultWeatherCacheTTL), utils.FlagWeatherCacheTTLUsage)
+	flag.DurationVar(&utils.CurrencyCacheTTL, utils.FlagCurrencyCacheTTL,
+		defaultCacheTTL(utils.EnvCurrencyCacheTTL, utils.DefaultCurrencyCacheTTL), utils.FlagCurrencyCacheTTLUsage)
+	flag.IntVar(&utils.WeatherGeohashPrecision, utils.FlagWeatherPrecision, defaultWeatherPrecision(), utils.FlagWeatherPrecisionUsage)
+	flag.Float64Var(&utils.WeatherReuseRadiusKm, utils.FlagWeatherReuseRadius, defaultWeatherReuseRadius(), utils.FlagWeatherReuseRadiusUsage)
+	flag.IntVar(&utils.HTTPMaxAttempts, utils.FlagHTTPMaxAttempts, defaultHTTPMaxAttempts(), utils.FlagHTTPMaxAttemptsUsage)
+	flag.Float64Var(&utils.HTTPRateLimit, utils.FlagHTTPRateLimit, defaultHTTPRateLimit(), utils.FlagHTTPRateLimitUsage)
+	flag.IntVar(&utils.HTTPRateBurst, utils.FlagHTTPRateBurst, defaultHTTPRateBurst(), utils.FlagHTTPRateBurstUsage)
+	cacheBackend := flag.String(utils.FlagCacheBackend, envOr(utils.EnvCacheBackend, utils.CacheBackendStore), utils.FlagCacheBackendUsage)
+	redisAddr := flag.String(utils.FlagRedisAddr, envOr(utils.EnvRedisAddr, utils.DefaultRedisAddr), utils.FlagRedisAddrUsage)
+	flag.Parse()
+
+	storeOpts := server.StoreOptions{Backend: *store, DSN: *dsn}
+	if flag.NArg() > 0 {
+		if commandErr := runCommand(storeOpts, flag.Args()); commandErr != nil {
+			log.Fatal(commandErr)
+		}
+		return
+	}
+	server.StartServer(storeOpts, server.CacheOptions{
+		Backend:       *cacheBackend,
+		RedisAddr:     *redisAddr,
+		RedisPassword: os.Getenv(utils.EnvRedisPassword),
+	})
+}
+
+// envOr reads the environment variable key, falling back to fallback if it is unset.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// defaultTrashRetention reads TRASH_RETENTION, falling back to utils.DefaultTrashRetention
+// if it is unset or not a valid duration.
+func defaultTrashRetention() time.Duration {
+	raw := os.Getenv(utils.EnvTrashRetention)
+	if raw == "" {
+		return utils.DefaultTrashRetention
+	}
+	retention, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf(utils.ErrInvalidTrashRetention, utils.EnvTrashRetention, raw, utils.DefaultTrashRetention)
+		return utils.DefaultTrashRetention
+	}
+	return retention
+}
+
+// defaultCacheSize reads CACHE_SIZE, falling back to utils.DefaultCacheL1MaxEntries
+// if it is unset or not a valid integer.
+func defaultCacheSize() int {
+	raw := os.Getenv(utils.EnvCacheSize)
+	if raw == "" {
+		return utils.DefaultCacheL1MaxEntries
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvCacheSize, raw, utils.DefaultCacheL1MaxEntries)
+		return utils.DefaultCacheL1MaxEntries
+	}
+	return size
+}
+
+// defaultCacheWarmBudget reads CACHE_WARM_BUDGET, falling back to utils.DefaultCacheWarmBudget
+// if it is unset or not a valid integer.
+func defaultCacheWarmBudget() int {
+	raw := os.Getenv(utils.EnvCacheWarmBudget)
+	if raw == "" {
+		return utils.DefaultCacheWarmBudget
+	}
+	budget, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvCacheWarmBudget, raw, utils.DefaultCacheWarmBudget)
+		return utils.DefaultCacheWarmBudget
+	}
+	return budget
+}
+
+// defaultCacheTTL reads the cache TTL in the environment variable key, falling back to fallback
+// if it is unset or not a positive duration.
+func defaultCacheTTL(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 {
+		log.Printf(utils.ErrInvalidCacheSetting, key, raw, fallback)
+		return fallback
+	}
+	return ttl
+}
+
+// defaultWeatherPrecision reads WEATHER_GEOHASH_PRECISION, falling back to utils.DefaultWeatherGeohashPrecision
+// if it is unset or not an integer from 1 to utils.MaxWeatherGeohashPrecision.
+func defaultWeatherPrecision() int {
+	raw := os.Getenv(utils.EnvWeatherPrecision)
+	if raw == "" {
+		return utils.DefaultWeatherGeohashPrecision
+	}
+	precision, err := strconv.Atoi(raw)
+	if err != nil || precision < 1 || precision > utils.MaxWeatherGeohashPrecision {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvWeatherPrecision, raw, utils.DefaultWeatherGeohashPrecision)
+		return utils.DefaultWeatherGeohashPrecision
+	}
+	return precision
+}
+
+// defaultWeatherReuseRadius reads WEATHER_REUSE_RADIUS, falling back to utils.DefaultWeatherReuseRadiusKm
+// if it is unset or not a non-negative number.
+func defaultWeatherReuseRadius() float64 {
+	raw := os.Getenv(utils.EnvWeatherReuseRadius)
+	if raw == "" {
+		return utils.DefaultWeatherReuseRadiusKm
+	}
+	radius, err := strconv.ParseFloat(raw, 64)
+	if err != nil || radius < 0 {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvWeatherReuseRadius, raw, utils.DefaultWeatherReuseRadiusKm)
+		return utils.DefaultWeatherReuseRadiusKm
+	}
+	return radius
+}
+
+// defaultHTTPMaxAttempts reads HTTP_MAX_ATTEMPTS, falling back to utils.DefaultHTTPMaxAttempts
+// if it is unset or not a positive integer.
+func defaultHTTPMaxAttempts() int {
+	raw := os.Getenv(utils.EnvHTTPMaxAttempts)
+	if raw == "" {
+		return utils.DefaultHTTPMaxAttempts
+	}
+	attempts, err := strconv.Atoi(raw)
+	if err != nil || attempts < 1 {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvHTTPMaxAttempts, raw, utils.DefaultHTTPMaxAttempts)
+		return utils.DefaultHTTPMaxAttempts
+	}
+	return attempts
+}
+
+// defaultHTTPRateLimit reads HTTP_RATE_LIMIT, falling back to utils.DefaultHTTPRateLimit
+// if it is unset or not a non-negative number.
+func defaultHTTPRateLimit() float64 {
+	raw := os.Getenv(utils.EnvHTTPRateLimit)
+	if raw == "" {
+		return utils.DefaultHTTPRateLimit
+	}
+	limit, err := strconv.ParseFloat(raw, 64)
+	if err != nil || limit < 0 {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvHTTPRateLimit, raw, utils.DefaultHTTPRateLimit)
+		return utils.DefaultHTTPRateLimit
+	}
+	return limit
+}
+
+// defaultHTTPRateBurst reads HTTP_RATE_BURST, falling back to utils.DefaultHTTPRateBurst
+// if it is unset or not a positive integer.
+func defaultHTTPRateBurst() int {
+	raw := os.Getenv(utils.EnvHTTPRateBurst)
+	if raw == "" {
+		return utils.DefaultHTTPRateBurst
+	}
+	burst, err := strconv.Atoi(raw)
+	if err != nil || burst < 1 {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvHTTPRateBurst, raw, utils.DefaultHTTPRateBurst)
+		return utils.DefaultHTTPRateBurst
+	}
+	return burst
+}
+
+// defaultCacheL2 reads CACHE_L2, falling back to enabled if it is unset or not a valid boolean.
+func defaultCacheL2() bool {
+	raw := os.Getenv(utils.EnvCacheL2)
+	if raw == "" {
+		return true
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf(utils.ErrInvalidCacheSetting, utils.EnvCacheL2, raw, true)
+		return true
+	}
+	return enabled
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// runMigrate upgrades every stored registration to the current schema version and prints a
+// JSON report. With --report nothing is written. Fails if any document could not be migrated.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet(utils.CommandMigrate, flag.ExitOnError)
+	reportOnly := flags.Bool(utils.FlagMigrateReport, false, utils.FlagMigrateReportUse)
+	_ = flags.Parse(args)
+
+	report, migrateErr := db.MigrateDashboardConfigs(context.Background(), *reportOnly)
+	if migrateErr != nil {
+		return fmt.Errorf(utils.ErrMigrateDocuments, migrateErr)
+	}
+
+	if printErr := printJSON(report); printErr != nil {
+		return printErr
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf(utils.MsgMigrationFailures, report.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// GetCacheEntry decodes the cache entry stored under key in collection into dest.
+// Returns ErrNotFound if no entry exists.
+func GetCacheEntry(ctx context.Context, collection, key string, dest interface{}) error {
+	return CurrentStore().GetCacheEntry(ctx, collection, key, dest)
+}
+
+// SetCacheEntry stores data with the current timestamp under key in collection.
+func SetCacheEntry(ctx context.Context, collection, key string, data interface{}) error {
+	return CurrentStore().SetCacheEntry(ctx, collection, key, data)
+}
+
+// PurgeCacheEntries removes all entries in collection stored before olderThan, utils.CachePurgeBatchSize
+// at a time. Returns the number of entries purged; entries that could not be deleted are listed in a *PurgeError.
+func PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time) (int, error) {
+	return CurrentStore().PurgeCacheEntries(ctx, collection, olderThan, utils.CachePurgeBatchSize)
+}
+
+// DeleteCacheEntry removes the entry stored under key in collection, if any.
+func DeleteCacheEntry(ctx context.Context, collection, key string) error {
+	return CurrentStore().DeleteCacheEntry(ctx, collection, key)
+}
+
+// GetCacheEntries returns up to limit raw entries in collection whose keys sort after the key after,
+// ordered by key.
+func GetCacheEntries(ctx context.Context, collection, after string, limit int) ([]utils.CacheRecord, error) {
+	return CurrentStore().GetCacheEntries(ctx, collection, after, limit)
+}
+
+// ForEachCacheEntry visits every raw entry in collection in key order, utils.MaxPageLimit at a time.
+func ForEachCacheEntry(ctx context.Context, collection string, fn func(utils.CacheRecord) error) error {
+	after := ""
+	for {
+		entries, err := GetCacheEntries(ctx, collection, after, utils.MaxPageLimit)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < utils.MaxPageLimit {
+			return nil
+		}
+		after = entries[len(entries)-1].Key
+	}
+}
+
+// PutCacheEntry stores a raw entry in collection, keeping its original timestamp.
+func PutCacheEntry(ctx context.Context, collection string, entry utils.CacheRecord) error {
+	return CurrentStore().PutCacheEntry(ctx, collection, entry)
+}
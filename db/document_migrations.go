@@ -0,0 +1,198 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrSchemaTooNew is returned when a document was written by a newer schema than this build knows.
+var ErrSchemaTooNew = errors.New(utils.ErrSchemaTooNew)
+
+// DocumentMigration upgrades a stored document by one schema version.
+// Apply works on the raw document keyed by JSON field names (see utils.DashboardConfig), so it can
+// still read fields whose Go type has since changed. It must be deterministic and idempotent.
+type DocumentMigration struct {
+	Version     int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// dashboardMigrations lists every dashboard document migration in the order it must be applied.
+// Append new migrations to the end; never edit one that has already shipped.
+var dashboardMigrations = []DocumentMigration{
+	{
+		Version:     1,
+		Description: "baseline: default missing features and target currencies",
+		Apply: func(doc map[string]interface{}) error {
+			features, ok := doc[utils.KeyFeatures].(map[string]interface{})
+			if !ok || features == nil {
+				features = map[string]interface{}{}
+				doc[utils.KeyFeatures] = features
+			}
+			if features[utils.KeyTargetCurrencies] == nil {
+				features[utils.KeyTargetCurrencies] = []interface{}{}
+			}
+			return nil
+		},
+	},
+}
+
+// DashboardSchemaVersion is the schema version written by this build: that of the newest migration.
+func DashboardSchemaVersion() int {
+	return dashboardMigrations[len(dashboardMigrations)-1].Version
+}
+
+// dashboardDocumentKeys are the JSON field names of a dashboard document, used to canonicalize
+// documents stored under Go field names (as Firestore does) or in another letter case.
+var dashboardDocumentKeys = []string{
+	utils.KeyID, utils.KeyCountry, utils.KeyISOCode, utils.KeyFeatures, utils.KeyLastChange,
+	utils.KeyVersion, utils.KeyDeletedAt, utils.KeyTenantID, utils.KeySchemaVersion,
+}
+
+// featureDocumentKeys are the JSON field names inside a document's features.
+var featureDocumentKeys = []string{
+	utils.KeyTemperature, utils.KeyPrecipitation, utils.KeyCapital, utils.KeyCoordinates,
+	utils.KeyPopulation, utils.KeyArea, utils.KeyTargetCurrencies,
+}
+
+// canonicalizeKeys renames every key of doc that matches one of keys case-insensitively to that key.
+func canonicalizeKeys(doc map[string]interface{}, keys []string) {
+	for stored, value := range doc {
+		for _, key := range keys {
+			if stored != key && strings.EqualFold(stored, key) {
+				delete(doc, stored)
+				doc[key] = value
+				break
+			}
+		}
+	}
+}
+
+// documentSchemaVersion reads a document's schema version; documents that predate versioning are 0.
+func documentSchemaVersion(doc map[string]interface{}) int {
+	switch v := doc[utils.KeySchemaVersion].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// upgradeDashboardDocument applies every migration newer than the document's schema version in place.
+// Reports whether anything was applied.
+func upgradeDashboardDocument(doc map[string]interface{}) (bool, error) {
+	canonicalizeKeys(doc, dashboardDocumentKeys)
+	if features, ok := doc[utils.KeyFeatures].(map[string]interface{}); ok {
+		canonicalizeKeys(features, featureDocumentKeys)
+	}
+
+	from := documentSchemaVersion(doc)
+	if from > DashboardSchemaVersion() {
+		return false, fmt.Errorf(utils.ErrDocumentSchema, from, ErrSchemaTooNew)
+	}
+
+	upgraded := false
+	for _, migration := range dashboardMigrations {
+		if migration.Version <= from {
+			continue
+		}
+		if err := migration.Apply(doc); err != nil {
+			return false, fmt.Errorf(utils.ErrDocumentMigration, migration.Version, err)
+		}
+		doc[utils.KeySchemaVersion] = migration.Version
+		upgraded = true
+	}
+	return upgraded, nil
+}
+
+// decodeDashboardDocument upgrades a raw dashboard document to the current schema and decodes it.
+// Reports whether the stored document is outdated and should be written back.
+func decodeDashboardDocument(doc map[string]interface{}) (*utils.DashboardConfig, bool, error) {
+	upgraded, err := upgradeDashboardDocument(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	var config utils.DashboardConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, false, fmt.Errorf(utils.ErrDecodeDocument, err)
+	}
+	return &config, upgraded, nil
+}
+
+// DecodeDashboardJSON upgrades a dashboard document serialized as JSON, such as a backup record,
+// to the current schema and decodes it.
+func DecodeDashboardJSON(raw []byte) (*utils.DashboardConfig, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf(utils.ErrDecodeDocument, err)
+	}
+	config, _, err := decodeDashboardDocument(doc)
+	return config, err
+}
+
+// upgradeDashboardConfig runs a decoded config through the migrations, for stores that keep
+// decoded values. Configs already at the current schema are returned unchanged.
+func upgradeDashboardConfig(config utils.DashboardConfig) (utils.DashboardConfig, bool, error) {
+	if config.SchemaVersion == DashboardSchemaVersion() {
+		return config, false, nil
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return config, false, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return config, false, err
+	}
+	migrated, _, decodeErr := decodeDashboardDocument(doc)
+	if decodeErr != nil {
+		return config, false, decodeErr
+	}
+	return *migrated, true, nil
+}
+
+// logUndecodableDashboard reports a document skipped by a listing because it failed to decode.
+// The migrate command's report mode lists every such document.
+func logUndecodableDashboard(id string, err error) {
+	log.Printf(utils.ErrSkipUndecodable, id, err)
+}
+
+// migrationRun accumulates the outcome of a bulk document migration.
+type migrationRun struct {
+	report utils.MigrationReport
+}
+
+// newMigrationRun starts a report for a bulk migration.
+func newMigrationRun(dryRun bool) *migrationRun {
+	return &migrationRun{report: utils.MigrationReport{
+		SchemaVersion: DashboardSchemaVersion(),
+		DryRun:        dryRun,
+		Failures:      []utils.DocumentFailure{},
+	}}
+}
+
+// record counts one document: failed if err is set, upgraded if it needed migrating, current otherwise.
+func (m *migrationRun) record(id string, upgraded bool, err error) {
+	m.report.Total++
+	switch {
+	case err != nil:
+		m.report.Failed++
+		m.report.Failures = append(m.report.Failures, utils.DocumentFailure{ID: id, Error: err.Error()})
+	case upgraded:
+		m.report.Upgraded++
+	default:
+		m.report.Current++
+	}
+}
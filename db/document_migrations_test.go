@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedLegacyDashboard stores a config as written before schema versioning, bypassing the store's
+// own stamping. A non-empty features string is stored verbatim by the SQL store.
+func seedLegacyDashboard(t *testing.T, store Store, config utils.DashboardConfig, features string) {
+	switch s := store.(type) {
+	case *MemoryStore:
+		s.dashboards[config.ID] = config
+	case *SQLStore:
+		if features == "" {
+			features = "null"
+		}
+		_, err := s.exec(context.Background(), `INSERT INTO dashboard_configs
+			(id, country, iso_code, features, last_change, version, deleted_at, tenant_id, schema_version)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			config.ID, config.Country, config.ISOCode, features, config.LastChange, config.Version,
+			config.DeletedAt, config.TenantID, config.SchemaVersion)
+		require.NoError(t, err)
+	default:
+		t.Fatalf("unsupported store %T", store)
+	}
+}
+
+// withDashboardMigrations temporarily replaces the migration registry.
+func withDashboardMigrations(t *testing.T, migrations ...DocumentMigration) {
+	previous := dashboardMigrations
+	dashboardMigrations = append(append([]DocumentMigration{}, previous...), migrations...)
+	t.Cleanup(func() { dashboardMigrations = previous })
+}
+
+func TestDecodeDashboardDocument_UpgradesLegacyDocument(t *testing.T) {
+	// Firestore stores untagged fields under their Go names
+	doc := map[string]interface{}{
+		"ID":         "a",
+		"Country":    "Norway",
+		"ISOCode":    "NO",
+		"LastChange": "20250101 10:00",
+		"Features":   map[string]interface{}{"Capital": true},
+	}
+
+	config, upgraded, err := decodeDashboardDocument(doc)
+	require.NoError(t, err)
+	assert.True(t, upgraded)
+	assert.Equal(t, "a", config.ID)
+	assert.Equal(t, "NO", config.ISOCode)
+	assert.True(t, config.Features.Capital)
+	assert.NotNil(t, config.Features.TargetCurrencies)
+	assert.Equal(t, DashboardSchemaVersion(), config.SchemaVersion)
+}
+
+func TestDecodeDashboardDocument_CurrentAndTooNew(t *testing.T) {
+	current := map[string]interface{}{utils.KeyID: "a", utils.KeySchemaVersion: int64(DashboardSchemaVersion())}
+	_, upgraded, err := decodeDashboardDocument(current)
+	require.NoError(t, err)
+	assert.False(t, upgraded)
+
+	tooNew := map[string]interface{}{utils.KeyID: "a", utils.KeySchemaVersion: DashboardSchemaVersion() + 1}
+	_, _, err = decodeDashboardDocument(tooNew)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+func TestDecodeDashboardDocument_AppliesMigrationsInOrder(t *testing.T) {
+	next := DashboardSchemaVersion() + 1
+	withDashboardMigrations(t, DocumentMigration{
+		Version:     next,
+		Description: "test: mark ISO codes",
+		Apply: func(doc map[string]interface{}) error {
+			code, _ := doc[utils.KeyISOCode].(string)
+			doc[utils.KeyISOCode] = code + "!"
+			return nil
+		},
+	})
+
+	config, upgraded, err := decodeDashboardDocument(map[string]interface{}{utils.KeyISOCode: "no"})
+	require.NoError(t, err)
+	assert.True(t, upgraded)
+	assert.Equal(t, "no!", config.ISOCode)
+	assert.Equal(t, next, config.SchemaVersion)
+}
+
+func TestStore_LazyUpgradeOnRead(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seedLegacyDashboard(t, store, utils.DashboardConfig{ID: "a", Country: "Norway", ISOCode: "NO", Version: 3}, "")
+
+			config, err := store.GetDashboardConfigByID(ctx, "a")
+			require.NoError(t, err)
+			assert.Equal(t, DashboardSchemaVersion(), config.SchemaVersion)
+			assert.Equal(t, int64(3), config.Version, "upgrades must not bump the version")
+			assert.NotNil(t, config.Features.TargetCurrencies)
+
+			// The upgrade was written back, so a bulk migration finds nothing left to do
+			report, err := store.MigrateDashboardConfigs(ctx, true)
+			require.NoError(t, err)
+			assert.Equal(t, 1, report.Current)
+			assert.Equal(t, 0, report.Upgraded)
+		})
+	}
+}
+
+func TestStore_MigrateDashboardConfigs(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.UpdateDashboardConfig(ctx, utils.DashboardConfig{ID: "current", ISOCode: "NO"}))
+			seedLegacyDashboard(t, store, utils.DashboardConfig{ID: "legacy", ISOCode: "SE"}, "")
+			seedLegacyDashboard(t, store, utils.DashboardConfig{ID: "newer", ISOCode: "DK", SchemaVersion: DashboardSchemaVersion() + 1}, "")
+
+			report, err := store.MigrateDashboardConfigs(ctx, true)
+			require.NoError(t, err)
+			assert.True(t, report.DryRun)
+			assert.Equal(t, 3, report.Total)
+			assert.Equal(t, 1, report.Current)
+			assert.Equal(t, 1, report.Upgraded)
+			require.Len(t, report.Failures, 1)
+			assert.Equal(t, "newer", report.Failures[0].ID)
+
+			// Listings skip the undecodable document instead of failing
+			configs, err := store.GetAllDashboardConfigs(ctx)
+			require.NoError(t, err)
+			assert.Len(t, configs, 2)
+
+			report, err = store.MigrateDashboardConfigs(ctx, false)
+			require.NoError(t, err)
+			assert.Equal(t, 1, report.Upgraded)
+
+			report, err = store.MigrateDashboardConfigs(ctx, true)
+			require.NoError(t, err)
+			assert.Equal(t, 2, report.Current)
+			assert.Equal(t, 0, report.Upgraded)
+		})
+	}
+}
+
+func TestSQLStore_MigrateReportsUndecodableRows(t *testing.T) {
+	store, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+	seedLegacyDashboard(t, store, utils.DashboardConfig{ID: "broken"}, `{"capital": "yes"}`)
+
+	report, err := store.MigrateDashboardConfigs(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, "broken", report.Failures[0].ID)
+
+	_, err = store.GetDashboardConfigByID(ctx, "broken")
+	assert.Error(t, err)
+}
@@ -14,9 +14,11 @@ var (
 	firestoreClient *firestore.Client
 )
 
-// SetClient sets the global Firestore client (typically used in tests).
+// SetClient sets the global Firestore client and installs it as the active store
+// (typically used in tests).
 func SetClient(client *firestore.Client) {
 	firestoreClient = client
+	UseStore(NewFirestoreStore(client))
 }
 
 // GetClient returns the global Firestore client.
@@ -44,7 +46,7 @@ func InitFirebase(ctx context.Context, credentialsFile string) error {
 	}
 
 	FirebaseApp = app
-	firestoreClient = client
+	SetClient(client)
 	return nil
 }
 
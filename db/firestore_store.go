@@ -0,0 +1,839 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/amundfpl/Assignment-2/utils"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreStore is the Store implementation backed by Google Cloud Firestore.
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore wraps an initialized Firestore client as a Store.
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// translateFirestoreErr maps Firestore's NotFound status onto ErrNotFound.
+func translateFirestoreErr(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// --- Dashboards ---
+
+// decodeDashboardSnapshot upgrades a dashboard document to the current schema and decodes it,
+// attaching its document ID. Reports whether the stored document is outdated.
+func decodeDashboardSnapshot(docSnap *firestore.DocumentSnapshot) (*utils.DashboardConfig, bool, error) {
+	config, upgraded, err := decodeDashboardDocument(docSnap.Data())
+	if err != nil {
+		return nil, false, err
+	}
+	config.ID = docSnap.Ref.ID
+	return config, upgraded, nil
+}
+
+// writeBackUpgraded stores an upgraded document, unless it was changed since docSnap was read.
+func (s *FirestoreStore) writeBackUpgraded(ctx context.Context, docSnap *firestore.DocumentSnapshot, config utils.DashboardConfig) error {
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, err := tx.Get(docSnap.Ref)
+		if err != nil {
+			return translateFirestoreErr(err)
+		}
+		if !current.UpdateTime.Equal(docSnap.UpdateTime) {
+			return ErrVersionConflict
+		}
+		return tx.Set(docSnap.Ref, config)
+	})
+}
+
+// SaveDashboardConfig stores a new dashboard configuration and returns the generated document ID.
+func (s *FirestoreStore) SaveDashboardConfig(ctx context.Context, config utils.DashboardConfig) (string, error) {
+	config.SchemaVersion = DashboardSchemaVersion()
+	docRef, _, saveErr := s.client.Collection(utils.DashboardCollection).Add(ctx, config)
+	if saveErr != nil {
+		return "", saveErr
+	}
+	log.Println(utils.MsgDashboardSaved, docRef.ID)
+	return docRef.ID, nil
+}
+
+// GetDashboardConfigByID retrieves a dashboard configuration by its document ID.
+// Outdated documents are upgraded and written back.
+func (s *FirestoreStore) GetDashboardConfigByID(ctx context.Context, id string) (*utils.DashboardConfig, error) {
+	docSnap, getErr := s.client.Collection(utils.DashboardCollection).Doc(id).Get(ctx)
+	if getErr != nil {
+		return nil, translateFirestoreErr(getErr)
+	}
+
+	config, upgraded, decodeErr := decodeDashboardSnapshot(docSnap)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if upgraded {
+		if writeErr := s.writeBackUpgraded(ctx, docSnap, *config); writeErr != nil {
+			log.Printf(utils.ErrWriteBackUpgraded, id, writeErr)
+		}
+	}
+	return config, nil
+}
+
+// GetAllDashboardConfigs retrieves all dashboard configurations, upgraded to the current schema.
+// Documents that fail to decode are logged and skipped.
+func (s *FirestoreStore) GetAllDashboardConfigs(ctx context.Context) ([]utils.DashboardConfig, error) {
+	iter := s.client.Collection(utils.DashboardCollection).Documents(ctx)
+	var configs []utils.DashboardConfig
+
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr == iterator.Done {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+
+		config, _, decodeErr := decodeDashboardSnapshot(docSnap)
+		if decodeErr != nil {
+			logUndecodableDashboard(docSnap.Ref.ID, decodeErr)
+			continue
+		}
+		configs = append(configs, *config)
+	}
+	return configs, nil
+}
+
+// MigrateDashboardConfigs upgrades every outdated document, each in its own transaction.
+func (s *FirestoreStore) MigrateDashboardConfigs(ctx context.Context, dryRun bool) (*utils.MigrationReport, error) {
+	iter := s.client.Collection(utils.DashboardCollection).OrderBy(firestore.DocumentID, firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	run := newMigrationRun(dryRun)
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr == iterator.Done {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+
+		config, upgraded, err := decodeDashboardSnapshot(docSnap)
+		if err == nil && upgraded && !dryRun {
+			err = s.writeBackUpgraded(ctx, docSnap, *config)
+		}
+		run.record(docSnap.Ref.ID, upgraded, err)
+	}
+	return &run.report, nil
+}
+
+// dashboardSortPaths maps the sortable dashboard field keys to Firestore field paths.
+var dashboardSortPaths = map[string]string{
+	utils.KeyID:         firestore.DocumentID,
+	utils.KeyCountry:    "Country",
+	utils.KeyISOCode:    "ISOCode",
+	utils.KeyLastChange: "LastChange",
+}
+
+// webhookSortPaths maps the sortable webhook field keys to Firestore field paths.
+var webhookSortPaths = map[string]string{
+	utils.KeyID:      firestore.DocumentID,
+	utils.KeyURL:     "url",
+	utils.KeyEvent:   "event",
+	utils.KeyCountry: "country",
+}
+
+// dashboardFeaturePaths maps the dashboard feature keys to Firestore field paths.
+var dashboardFeaturePaths = map[string]string{
+	utils.KeyTemperature:   "Features.Temperature",
+	utils.KeyPrecipitation: "Features.Precipitation",
+	utils.KeyCapital:       "Features.Capital",
+	utils.KeyCoordinates:   "Features.Coordinates",
+	utils.KeyPopulation:    "Features.Population",
+	utils.KeyArea:          "Features.Area",
+}
+
+// caseVariants returns value as given, upper-cased, lower-cased and capitalized word by word. Firestore only
+// matches exactly, so the case-insensitive filters match these spellings of the stored value.
+func caseVariants(value string) []string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+
+	variants := []string{}
+	for _, variant := range []string{value, strings.ToUpper(value), strings.ToLower(value), strings.Join(words, " ")} {
+		if !slices.Contains(variants, variant) {
+			variants = append(variants, variant)
+		}
+	}
+	return variants
+}
+
+// countDocuments counts the documents matching query with an aggregation, without reading them.
+func countDocuments(ctx context.Context, query firestore.Query) (int, error) {
+	result, err := query.NewAggregationQuery().WithCount(countAlias).Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, _ := result[countAlias].(*firestorepb.Value)
+	return int(count.GetIntegerValue()), nil
+}
+
+// countAlias names the count in aggregation results.
+const countAlias = "count"
+
+// snapshotString reads a string field of a document that may not decode, or "" if it is not a string.
+func snapshotString(docSnap *firestore.DocumentSnapshot, path string) string {
+	value, _ := docSnap.DataAt(path)
+	text, _ := value.(string)
+	return text
+}
+
+// queryFirestorePage counts the documents matching filtered, then fetches the page after opts.After ordered
+// by (sort field, document ID). One extra document is fetched to decide whether a next cursor is needed.
+// Documents that fail to decode are handled as queryPage handles undecodable SQL rows.
+func queryFirestorePage[T any](ctx context.Context, filtered firestore.Query, opts ListOptions, paths map[string]string,
+	decode func(*firestore.DocumentSnapshot) (T, bool), key func(T) (string, string)) (*Page[T], error) {
+
+	page := &Page[T]{Items: []T{}}
+	total, err := countDocuments(ctx, filtered)
+	if err != nil {
+		return nil, err
+	}
+	page.Total = total
+
+	field, desc := opts.sortKey()
+	direction := firestore.Asc
+	if desc {
+		direction = firestore.Desc
+	}
+	ordered := filtered.OrderBy(paths[field], direction)
+	if paths[field] != firestore.DocumentID {
+		ordered = ordered.OrderBy(firestore.DocumentID, direction)
+	}
+	if opts.After != nil {
+		if paths[field] == firestore.DocumentID {
+			ordered = ordered.StartAfter(opts.After.ID)
+		} else {
+			ordered = ordered.StartAfter(opts.After.Value, opts.After.ID)
+		}
+	}
+	if opts.Limit > 0 {
+		ordered = ordered.Limit(opts.Limit + 1)
+	}
+
+	iter := ordered.Documents(ctx)
+	defer iter.Stop()
+
+	scanned := 0
+	var lastValue, lastID string
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr == iterator.Done {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		if opts.Limit > 0 && scanned == opts.Limit {
+			page.Next = &PageCursor{Sort: opts.Sort, Value: lastValue, ID: lastID}
+			break
+		}
+		scanned++
+		item, decoded := decode(docSnap)
+		lastValue, lastID = key(item)
+		if !decoded {
+			page.Total--
+			continue
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
+
+// decodePagedDashboard decodes a dashboard document for queryFirestorePage. A document that fails to decode
+// is logged and reported as not decoded, with the fields it sorts by, so that the cursor can still pass it.
+func decodePagedDashboard(docSnap *firestore.DocumentSnapshot) (utils.DashboardConfig, bool) {
+	config, _, decodeErr := decodeDashboardSnapshot(docSnap)
+	if decodeErr != nil {
+		logUndecodableDashboard(docSnap.Ref.ID, decodeErr)
+		return utils.DashboardConfig{
+			ID:         docSnap.Ref.ID,
+			Country:    snapshotString(docSnap, "Country"),
+			ISOCode:    snapshotString(docSnap, "ISOCode"),
+			LastChange: snapshotString(docSnap, "LastChange"),
+		}, false
+	}
+	return *config, true
+}
+
+// decodePagedWebhook decodes a webhook document for queryFirestorePage, as decodePagedDashboard does.
+func decodePagedWebhook(docSnap *firestore.DocumentSnapshot) (utils.Webhook, bool) {
+	var hook utils.Webhook
+	if decodeErr := docSnap.DataTo(&hook); decodeErr != nil {
+		return utils.Webhook{
+			ID:      docSnap.Ref.ID,
+			URL:     snapshotString(docSnap, "url"),
+			Event:   snapshotString(docSnap, "event"),
+			Country: snapshotString(docSnap, "country"),
+		}, false
+	}
+	hook.ID = docSnap.Ref.ID
+	return hook, true
+}
+
+// QueryDashboardConfigs pushes filtering, cursor pagination and sorting down into Firestore. Filtering on
+// one field while sorting on another needs a composite index; Firestore's error links to creating it.
+func (s *FirestoreStore) QueryDashboardConfigs(ctx context.Context, query DashboardQuery) (*Page[utils.DashboardConfig], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	filtered := s.client.Collection(utils.DashboardCollection).Query
+	if query.Deleted {
+		filtered = filtered.Where("DeletedAt", "!=", "")
+	} else {
+		filtered = filtered.Where("DeletedAt", "==", "")
+	}
+	if !query.AllTenants {
+		filtered = filtered.Where("TenantID", "==", query.TenantID)
+	}
+	if query.ISOCode != "" {
+		filtered = filtered.Where("ISOCode", "in", caseVariants(query.ISOCode))
+	}
+	if query.Country != "" {
+		filtered = filtered.Where("Country", "in", caseVariants(query.Country))
+	}
+	for _, feature := range query.Features {
+		filtered = filtered.Where(dashboardFeaturePaths[feature], "==", true)
+	}
+	if query.LastChangeFrom != "" {
+		filtered = filtered.Where("LastChange", ">=", query.LastChangeFrom)
+	}
+	if query.LastChangeTo != "" {
+		filtered = filtered.Where("LastChange", "<=", query.LastChangeTo)
+	}
+
+	return queryFirestorePage(ctx, filtered, query.ListOptions, dashboardSortPaths, decodePagedDashboard, query.sortValue)
+}
+
+// UpdateDashboardConfig overwrites an existing dashboard configuration based on its ID.
+func (s *FirestoreStore) UpdateDashboardConfig(ctx context.Context, config utils.DashboardConfig) error {
+	config.SchemaVersion = DashboardSchemaVersion()
+	_, updateErr := s.client.Collection(utils.DashboardCollection).Doc(config.ID).Set(ctx, config)
+	return updateErr
+}
+
+// DeleteDashboardConfig removes a dashboard configuration document by its ID.
+func (s *FirestoreStore) DeleteDashboardConfig(ctx context.Context, id string) error {
+	_, deleteErr := s.client.Collection(utils.DashboardCollection).Doc(id).Delete(ctx)
+	return deleteErr
+}
+
+// UpdateDashboardConfigIfVersion writes config inside a transaction after verifying the stored version.
+func (s *FirestoreStore) UpdateDashboardConfigIfVersion(ctx context.Context, config utils.DashboardConfig, expected int64) error {
+	ref := s.client.Collection(utils.DashboardCollection).Doc(config.ID)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, err := dashboardVersionInTx(tx, ref)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if current != expected {
+			return ErrVersionConflict
+		}
+		config.Version = expected + 1
+		config.SchemaVersion = DashboardSchemaVersion()
+		return tx.Set(ref, config)
+	})
+}
+
+// DeleteDashboardConfigIfVersion deletes the document inside a transaction after verifying the stored version.
+func (s *FirestoreStore) DeleteDashboardConfigIfVersion(ctx context.Context, id string, expected int64) error {
+	ref := s.client.Collection(utils.DashboardCollection).Doc(id)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, err := dashboardVersionInTx(tx, ref)
+		if err != nil {
+			return err
+		}
+		if current != expected {
+			return ErrVersionConflict
+		}
+		return tx.Delete(ref)
+	})
+}
+
+// dashboardVersionInTx reads the version of a dashboard document within a transaction.
+// Returns ErrNotFound (and version 0) if the document does not exist.
+func dashboardVersionInTx(tx *firestore.Transaction, ref *firestore.DocumentRef) (int64, error) {
+	snap, err := tx.Get(ref)
+	if err != nil {
+		return 0, translateFirestoreErr(err)
+	}
+	existing, _, decodeErr := decodeDashboardSnapshot(snap)
+	if decodeErr != nil {
+		return 0, decodeErr
+	}
+	return existing.Version, nil
+}
+
+// --- Revisions ---
+
+// revisionCollection returns the revisions sub-collection of a dashboard document.
+// Sub-collections outlive their parent document, so history survives deletes.
+func (s *FirestoreStore) revisionCollection(dashboardID string) *firestore.CollectionRef {
+	return s.client.Collection(utils.DashboardCollection).Doc(dashboardID).Collection(utils.RevisionCollection)
+}
+
+// SaveRevision creates the revision document, keyed by its revision number.
+// Returns ErrVersionConflict if the revision number already exists.
+func (s *FirestoreStore) SaveRevision(ctx context.Context, revision utils.DashboardRevision) error {
+	ref := s.revisionCollection(revision.DashboardID).Doc(strconv.FormatInt(revision.Revision, 10))
+	_, err := ref.Create(ctx, revision)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// DeleteRevisions removes every document in a dashboard's revision sub-collection.
+func (s *FirestoreStore) DeleteRevisions(ctx context.Context, dashboardID string) error {
+	docs, err := s.revisionCollection(dashboardID).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if _, deleteErr := doc.Ref.Delete(ctx); deleteErr != nil {
+			return deleteErr
+		}
+	}
+	return nil
+}
+
+// GetRevisions returns every revision of a dashboard, oldest first.
+func (s *FirestoreStore) GetRevisions(ctx context.Context, dashboardID string) ([]utils.DashboardRevision, error) {
+	iter := s.revisionCollection(dashboardID).OrderBy(utils.FieldRevision, firestore.Asc).Documents(ctx)
+	revisions := []utils.DashboardRevision{}
+
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr == iterator.Done {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+
+		var revision utils.DashboardRevision
+		if decodeErr := docSnap.DataTo(&revision); decodeErr != nil {
+			return nil, decodeErr
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of a dashboard, or ErrNotFound.
+func (s *FirestoreStore) GetRevision(ctx context.Context, dashboardID string, revision int64) (*utils.DashboardRevision, error) {
+	docSnap, getErr := s.revisionCollection(dashboardID).Doc(strconv.FormatInt(revision, 10)).Get(ctx)
+	if getErr != nil {
+		return nil, translateFirestoreErr(getErr)
+	}
+
+	var found utils.DashboardRevision
+	if decodeErr := docSnap.DataTo(&found); decodeErr != nil {
+		return nil, decodeErr
+	}
+	return &found, nil
+}
+
+// --- Webhooks ---
+
+// SaveWebhook stores a new webhook and returns the generated document ID.
+func (s *FirestoreStore) SaveWebhook(ctx context.Context, webhook utils.Webhook) (string, error) {
+	docRef, _, saveErr := s.client.Collection(utils.WebhookCollection).Add(ctx, webhook)
+	if saveErr != nil {
+		return "", saveErr
+	}
+	return docRef.ID, nil
+}
+
+// PutWebhook stores webhook under its own document ID, replacing any existing document.
+func (s *FirestoreStore) PutWebhook(ctx context.Context, webhook utils.Webhook) error {
+	_, err := s.client.Collection(utils.WebhookCollection).Doc(webhook.ID).Set(ctx, webhook)
+	return err
+}
+
+// GetWebhookByID retrieves a single webhook using its document ID.
+func (s *FirestoreStore) GetWebhookByID(ctx context.Context, id string) (*utils.Webhook, error) {
+	docSnap, getErr := s.client.Collection(utils.WebhookCollection).Doc(id).Get(ctx)
+	if getErr != nil {
+		return nil, translateFirestoreErr(getErr)
+	}
+
+	var webhook utils.Webhook
+	decodeErr := docSnap.DataTo(&webhook)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	webhook.ID = docSnap.Ref.ID
+	return &webhook, nil
+}
+
+// GetAllWebhooks fetches all stored webhooks, skipping documents that fail to decode.
+func (s *FirestoreStore) GetAllWebhooks(ctx context.Context) ([]utils.Webhook, error) {
+	iter := s.client.Collection(utils.WebhookCollection).Documents(ctx)
+	var hooks []utils.Webhook
+
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr != nil {
+			break // No more documents
+		}
+		var hook utils.Webhook
+		decodeErr := docSnap.DataTo(&hook)
+		if decodeErr == nil {
+			hook.ID = docSnap.Ref.ID
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// GetMatchingWebhooks finds webhooks registered for a specific event,
+// optionally filtering by country. If a webhook has no country restriction (""), it is included.
+func (s *FirestoreStore) GetMatchingWebhooks(ctx context.Context, event, country string) ([]utils.Webhook, error) {
+	iter := s.client.Collection(utils.WebhookCollection).Where("event", "==", event).Documents(ctx)
+	var hooks []utils.Webhook
+
+	for {
+		doc, err := iter.Next()
+		if err != nil {
+			break // No more documents
+		}
+
+		var hook utils.Webhook
+		if err := doc.DataTo(&hook); err == nil {
+			hook.ID = doc.Ref.ID
+			// Match country exactly or allow wildcard (empty string)
+			if hook.Country == country || hook.Country == "" {
+				hooks = append(hooks, hook)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+// QueryWebhooks pushes filtering, cursor pagination and sorting down into Firestore. Events and countries
+// are stored upper-cased, so their filters match exactly.
+func (s *FirestoreStore) QueryWebhooks(ctx context.Context, query WebhookQuery) (*Page[utils.Webhook], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	filtered := s.client.Collection(utils.WebhookCollection).Query
+	if !query.AllTenants {
+		filtered = filtered.Where("tenantId", "==", query.TenantID)
+	}
+	if query.Event != "" {
+		filtered = filtered.Where("event", "==", strings.ToUpper(query.Event))
+	}
+	if query.Country != "" {
+		filtered = filtered.Where("country", "==", strings.ToUpper(query.Country))
+	}
+
+	return queryFirestorePage(ctx, filtered, query.ListOptions, webhookSortPaths, decodePagedWebhook, query.sortValue)
+}
+
+// DeleteWebhook removes a webhook using its document ID.
+func (s *FirestoreStore) DeleteWebhook(ctx context.Context, id string) error {
+	_, deleteErr := s.client.Collection(utils.WebhookCollection).Doc(id).Delete(ctx)
+	return deleteErr
+}
+
+// CountWebhooks returns the total number of webhook documents, or 0 if an error occurs.
+func (s *FirestoreStore) CountWebhooks(ctx context.Context) int {
+	count, countErr := countDocuments(ctx, s.client.Collection(utils.WebhookCollection).Query)
+	if countErr != nil {
+		return 0
+	}
+	return count
+}
+
+// --- Tenants ---
+
+// SaveTenant stores a new tenant and returns the generated document ID.
+func (s *FirestoreStore) SaveTenant(ctx context.Context, tenant utils.Tenant) (string, error) {
+	docRef, _, saveErr := s.client.Collection(utils.TenantCollection).Add(ctx, tenant)
+	if saveErr != nil {
+		return "", saveErr
+	}
+	return docRef.ID, nil
+}
+
+// PutTenant stores tenant under its own document ID, replacing any existing document.
+func (s *FirestoreStore) PutTenant(ctx context.Context, tenant utils.Tenant) error {
+	_, err := s.client.Collection(utils.TenantCollection).Doc(tenant.ID).Set(ctx, tenant)
+	return err
+}
+
+// decodeTenant converts a tenant document, attaching its document ID.
+func decodeTenant(docSnap *firestore.DocumentSnapshot) (*utils.Tenant, error) {
+	var tenant utils.Tenant
+	if decodeErr := docSnap.DataTo(&tenant); decodeErr != nil {
+		return nil, decodeErr
+	}
+	tenant.ID = docSnap.Ref.ID
+	return &tenant, nil
+}
+
+// GetTenantByID retrieves a tenant by its document ID.
+func (s *FirestoreStore) GetTenantByID(ctx context.Context, id string) (*utils.Tenant, error) {
+	docSnap, getErr := s.client.Collection(utils.TenantCollection).Doc(id).Get(ctx)
+	if getErr != nil {
+		return nil, translateFirestoreErr(getErr)
+	}
+	return decodeTenant(docSnap)
+}
+
+// GetTenantByKeyHash finds the tenant whose API key hashes to keyHash. Served by a single-field index.
+func (s *FirestoreStore) GetTenantByKeyHash(ctx context.Context, keyHash string) (*utils.Tenant, error) {
+	iter := s.client.Collection(utils.TenantCollection).Where(utils.FieldKeyHash, "==", keyHash).Limit(1).Documents(ctx)
+	defer iter.Stop()
+
+	docSnap, nextErr := iter.Next()
+	if nextErr == iterator.Done {
+		return nil, ErrNotFound
+	}
+	if nextErr != nil {
+		return nil, nextErr
+	}
+	return decodeTenant(docSnap)
+}
+
+// GetAllTenants returns every tenant ordered by document ID.
+func (s *FirestoreStore) GetAllTenants(ctx context.Context) ([]utils.Tenant, error) {
+	iter := s.client.Collection(utils.TenantCollection).OrderBy(firestore.DocumentID, firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	tenants := []utils.Tenant{}
+	for {
+		docSnap, nextErr := iter.Next()
+		if nextErr == iterator.Done {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		tenant, decodeErr := decodeTenant(docSnap)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		tenants = append(tenants, *tenant)
+	}
+	return tenants, nil
+}
+
+// UpdateTenant overwrites an existing tenant document. Returns ErrNotFound if it does not exist.
+func (s *FirestoreStore) UpdateTenant(ctx context.Context, tenant utils.Tenant) error {
+	ref := s.client.Collection(utils.TenantCollection).Doc(tenant.ID)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(ref); err != nil {
+			return translateFirestoreErr(err)
+		}
+		return tx.Set(ref, tenant)
+	})
+}
+
+// DeleteTenant removes a tenant document by its ID.
+func (s *FirestoreStore) DeleteTenant(ctx context.Context, id string) error {
+	_, deleteErr := s.client.Collection(utils.TenantCollection).Doc(id).Delete(ctx)
+	return deleteErr
+}
+
+// --- Caches ---
+
+// GetCacheEntry decodes the cache document stored under key into dest.
+func (s *FirestoreStore) GetCacheEntry(ctx context.Context, collection, key string, dest interface{}) error {
+	doc, err := s.client.Collection(collection).Doc(key).Get(ctx)
+	if err != nil {
+		return translateFirestoreErr(err)
+	}
+	return doc.DataTo(dest)
+}
+
+// SetCacheEntry stores data together with the current time under key.
+func (s *FirestoreStore) SetCacheEntry(ctx context.Context, collection, key string, data interface{}) error {
+	_, err := s.client.Collection(collection).Doc(key).Set(ctx, map[string]interface{}{
+		utils.FieldData:      data,
+		utils.TimestampField: time.Now(),
+	})
+	return err
+}
+
+// PurgeCacheEntries deletes every document in collection whose timestamp is before olderThan.
+// Documents are read in pages of batchSize, oldest first, and each page is deleted with a BulkWriter.
+// Pages continue after the last document read, so documents that fail to delete are not read again.
+// Returns the number of documents deleted.
+func (s *FirestoreStore) PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time, batchSize int) (int, error) {
+	query := s.client.
+		Collection(collection).
+		Where(utils.TimestampField, utils.OperatorLessThan, olderThan).
+		OrderBy(utils.TimestampField, firestore.Asc).
+		Limit(batchSize)
+
+	purged := 0
+	purgeErr := &PurgeError{Collection: collection}
+	var last *firestore.DocumentSnapshot
+	for {
+		page := query
+		if last != nil {
+			page = query.StartAfter(last)
+		}
+		docs, err := page.Documents(ctx).GetAll()
+		if err != nil {
+			return purged, errors.Join(err, purgeErrorOrNil(purgeErr))
+		}
+
+		deleted, failures := s.deleteCacheDocs(ctx, docs)
+		purged += deleted
+		purgeErr.Failures = append(purgeErr.Failures, failures...)
+		if len(docs) < batchSize {
+			return purged, purgeErrorOrNil(purgeErr)
+		}
+		last = docs[len(docs)-1]
+	}
+}
+
+// deleteCacheDocs deletes docs in one BulkWriter run, returning how many were deleted and which failed.
+func (s *FirestoreStore) deleteCacheDocs(ctx context.Context, docs []*firestore.DocumentSnapshot) (int, []utils.CachePurgeFailure) {
+	writer := s.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(docs))
+	var failures []utils.CachePurgeFailure
+	for i, doc := range docs {
+		job, err := writer.Delete(doc.Ref)
+		if err != nil {
+			failures = append(failures, utils.CachePurgeFailure{Key: doc.Ref.ID, Error: err.Error()})
+			continue
+		}
+		jobs[i] = job
+	}
+	writer.End()
+
+	deleted := 0
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if _, err := job.Results(); err != nil {
+			failures = append(failures, utils.CachePurgeFailure{Key: docs[i].Ref.ID, Error: err.Error()})
+			continue
+		}
+		deleted++
+	}
+	return deleted, failures
+}
+
+// DeleteCacheEntry removes a single cache document. Deleting a missing key is not an error.
+func (s *FirestoreStore) DeleteCacheEntry(ctx context.Context, collection, key string) error {
+	_, err := s.client.Collection(collection).Doc(key).Delete(ctx)
+	return err
+}
+
+// GetCacheEntries returns up to limit documents in collection whose IDs sort after the ID after, ordered
+// by document ID, with their data as JSON.
+func (s *FirestoreStore) GetCacheEntries(ctx context.Context, collection, after string, limit int) ([]utils.CacheRecord, error) {
+	query := s.client.Collection(collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(limit)
+	if after != "" {
+		query = query.StartAfter(after)
+	}
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []utils.CacheRecord{}
+	for _, doc := range docs {
+		fields := doc.Data()
+		data, marshalErr := json.Marshal(fields[utils.FieldData])
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		timestamp, _ := fields[utils.TimestampField].(time.Time)
+		entries = append(entries, utils.CacheRecord{Key: doc.Ref.ID, Timestamp: timestamp, Data: data})
+	}
+	return entries, nil
+}
+
+// PutCacheEntry stores a raw entry, keeping its original timestamp. Whole JSON numbers are stored as
+// integers so that they decode back into integer fields.
+func (s *FirestoreStore) PutCacheEntry(ctx context.Context, collection string, entry utils.CacheRecord) error {
+	decoder := json.NewDecoder(bytes.NewReader(entry.Data))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return err
+	}
+	_, err := s.client.Collection(collection).Doc(entry.Key).Set(ctx, map[string]interface{}{
+		utils.FieldData:      firestoreValue(data),
+		utils.TimestampField: entry.Timestamp,
+	})
+	return err
+}
+
+// firestoreValue converts the json.Number values in a decoded JSON value to int64 or float64.
+func firestoreValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = firestoreValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = firestoreValue(item)
+		}
+	}
+	return value
+}
+
+// --- Lifecycle ---
+
+// Ping checks Firestore connectivity by attempting to fetch one collection.
+func (s *FirestoreStore) Ping(ctx context.Context) error {
+	_, pingErr := s.client.Collections(ctx).Next()
+	if pingErr == iterator.Done {
+		return nil // Reachable, just empty
+	}
+	return pingErr
+}
+
+// Close releases the Firestore client connection.
+func (s *FirestoreStore) Close() error {
+	return s.client.Close()
+}
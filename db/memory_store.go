@@ -0,0 +1,588 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// jsonCacheEntry is a cache value kept as JSON by the non-Firestore stores, so that
+// reads decode into fresh values exactly like a round trip through Firestore.
+type jsonCacheEntry struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// decodeInto unmarshals the entry into dest, which must expose Data and Timestamp fields.
+func (e jsonCacheEntry) decodeInto(dest interface{}) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+// MemoryStore is a Store implementation that keeps everything in process memory.
+// It is safe for concurrent use and intended for local development and tests.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	dashboards map[string]utils.DashboardConfig
+	revisions  map[string][]json.RawMessage // per dashboard, ordered by revision
+	webhooks   map[string]utils.Webhook
+	tenants    map[string]utils.Tenant
+	caches     map[string]map[string]jsonCacheEntry
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		dashboards: make(map[string]utils.DashboardConfig),
+		revisions:  make(map[string][]json.RawMessage),
+		webhooks:   make(map[string]utils.Webhook),
+		tenants:    make(map[string]utils.Tenant),
+		caches:     make(map[string]map[string]jsonCacheEntry),
+	}
+}
+
+// newDocumentID generates a random alphanumeric identifier in the style of Firestore auto-IDs.
+func newDocumentID() string {
+	id := make([]byte, utils.DocumentIDLength)
+	limit := big.NewInt(int64(len(utils.DocumentIDAlphabet)))
+	for i := range id {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			panic(err) // crypto/rand only fails if the OS entropy source is broken
+		}
+		id[i] = utils.DocumentIDAlphabet[n.Int64()]
+	}
+	return string(id)
+}
+
+// --- Dashboards ---
+
+// SaveDashboardConfig stores a new dashboard configuration and returns the generated ID.
+func (s *MemoryStore) SaveDashboardConfig(_ context.Context, config utils.DashboardConfig) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config.ID = newDocumentID()
+	config.SchemaVersion = DashboardSchemaVersion()
+	s.dashboards[config.ID] = config
+	return config.ID, nil
+}
+
+// GetDashboardConfigByID retrieves a dashboard configuration by its ID.
+// Outdated configs are upgraded and written back.
+func (s *MemoryStore) GetDashboardConfigByID(_ context.Context, id string) (*utils.DashboardConfig, error) {
+	s.mu.RLock()
+	stored, ok := s.dashboards[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	config, upgraded, err := upgradeDashboardConfig(stored)
+	if err != nil {
+		return nil, err
+	}
+	if upgraded {
+		s.writeBackUpgraded(stored, config)
+	}
+	return &config, nil
+}
+
+// writeBackUpgraded replaces stored with its upgraded form, unless it was changed in the meantime.
+func (s *MemoryStore) writeBackUpgraded(stored, upgraded utils.DashboardConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.dashboards[stored.ID]
+	if ok && current.Version == stored.Version && current.SchemaVersion == stored.SchemaVersion {
+		s.dashboards[stored.ID] = upgraded
+	}
+}
+
+// GetAllDashboardConfigs retrieves all dashboard configurations ordered by ID, upgraded to the
+// current schema. Configs that fail to upgrade are logged and skipped.
+func (s *MemoryStore) GetAllDashboardConfigs(_ context.Context) ([]utils.DashboardConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var configs []utils.DashboardConfig
+	for id, stored := range s.dashboards {
+		config, _, err := upgradeDashboardConfig(stored)
+		if err != nil {
+			logUndecodableDashboard(id, err)
+			continue
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ID < configs[j].ID })
+	return configs, nil
+}
+
+// MigrateDashboardConfigs upgrades every stored configuration in place.
+func (s *MemoryStore) MigrateDashboardConfigs(_ context.Context, dryRun bool) (*utils.MigrationReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.dashboards))
+	for id := range s.dashboards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	run := newMigrationRun(dryRun)
+	for _, id := range ids {
+		config, upgraded, err := upgradeDashboardConfig(s.dashboards[id])
+		if err == nil && upgraded && !dryRun {
+			s.dashboards[id] = config
+		}
+		run.record(id, upgraded, err)
+	}
+	return &run.report, nil
+}
+
+// QueryDashboardConfigs filters, sorts and pages the stored configurations in process.
+func (s *MemoryStore) QueryDashboardConfigs(ctx context.Context, query DashboardQuery) (*Page[utils.DashboardConfig], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	configs, _ := s.GetAllDashboardConfigs(ctx)
+
+	_, desc := query.sortKey()
+	sort.SliceStable(configs, func(i, j int) bool {
+		valueA, idA := query.sortValue(configs[i])
+		valueB, idB := query.sortValue(configs[j])
+		return sortLess(valueA, idA, valueB, idB, desc)
+	})
+
+	page := newPager(query.ListOptions, query.sortValue)
+	for _, config := range configs {
+		if query.matches(config) {
+			page.offer(config)
+		}
+	}
+	return page.result(), nil
+}
+
+// UpdateDashboardConfig creates or overwrites the dashboard configuration stored under config.ID.
+func (s *MemoryStore) UpdateDashboardConfig(_ context.Context, config utils.DashboardConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config.SchemaVersion = DashboardSchemaVersion()
+	s.dashboards[config.ID] = config
+	return nil
+}
+
+// DeleteRevisions removes a dashboard's whole revision history.
+func (s *MemoryStore) DeleteRevisions(_ context.Context, dashboardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.revisions, dashboardID)
+	return nil
+}
+
+// DeleteDashboardConfig removes a dashboard configuration. Deleting a missing ID is not an error.
+func (s *MemoryStore) DeleteDashboardConfig(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.dashboards, id)
+	return nil
+}
+
+// UpdateDashboardConfigIfVersion writes config only if the stored version equals expected.
+func (s *MemoryStore) UpdateDashboardConfigIfVersion(_ context.Context, config utils.DashboardConfig, expected int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dashboards[config.ID].Version != expected { // missing documents read as version 0
+		return ErrVersionConflict
+	}
+	config.Version = expected + 1
+	config.SchemaVersion = DashboardSchemaVersion()
+	s.dashboards[config.ID] = config
+	return nil
+}
+
+// DeleteDashboardConfigIfVersion deletes the configuration only if its stored version equals expected.
+func (s *MemoryStore) DeleteDashboardConfigIfVersion(_ context.Context, id string, expected int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.dashboards[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Version != expected {
+		return ErrVersionConflict
+	}
+	delete(s.dashboards, id)
+	return nil
+}
+
+// --- Revisions ---
+
+// SaveRevision appends a revision, kept as JSON so callers cannot mutate stored history.
+// Returns ErrVersionConflict if the revision number already exists.
+func (s *MemoryStore) SaveRevision(_ context.Context, revision utils.DashboardRevision) error {
+	raw, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.revisions[revision.DashboardID]
+	for _, existing := range history {
+		var stored utils.DashboardRevision
+		if json.Unmarshal(existing, &stored) == nil && stored.Revision == revision.Revision {
+			return ErrVersionConflict
+		}
+	}
+	history = append(history, raw)
+	sort.SliceStable(history, func(i, j int) bool {
+		var a, b utils.DashboardRevision
+		_ = json.Unmarshal(history[i], &a)
+		_ = json.Unmarshal(history[j], &b)
+		return a.Revision < b.Revision
+	})
+	s.revisions[revision.DashboardID] = history
+	return nil
+}
+
+// GetRevisions returns every revision of a dashboard, oldest first.
+func (s *MemoryStore) GetRevisions(_ context.Context, dashboardID string) ([]utils.DashboardRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []utils.DashboardRevision{}
+	for _, raw := range s.revisions[dashboardID] {
+		var revision utils.DashboardRevision
+		if err := json.Unmarshal(raw, &revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of a dashboard, or ErrNotFound.
+func (s *MemoryStore) GetRevision(ctx context.Context, dashboardID string, revision int64) (*utils.DashboardRevision, error) {
+	revisions, err := s.GetRevisions(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range revisions {
+		if candidate.Revision == revision {
+			return &candidate, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// --- Webhooks ---
+
+// SaveWebhook stores a new webhook and returns the generated ID.
+func (s *MemoryStore) SaveWebhook(_ context.Context, webhook utils.Webhook) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook.ID = newDocumentID()
+	s.webhooks[webhook.ID] = webhook
+	return webhook.ID, nil
+}
+
+// PutWebhook stores webhook under its own ID, replacing any webhook with that ID.
+func (s *MemoryStore) PutWebhook(_ context.Context, webhook utils.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+// GetWebhookByID retrieves a single webhook by its ID.
+func (s *MemoryStore) GetWebhookByID(_ context.Context, id string) (*utils.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &webhook, nil
+}
+
+// GetAllWebhooks returns every stored webhook ordered by ID.
+func (s *MemoryStore) GetAllWebhooks(_ context.Context) ([]utils.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hooks []utils.Webhook
+	for _, hook := range s.webhooks {
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks, nil
+}
+
+// GetMatchingWebhooks finds webhooks registered for event whose country matches
+// exactly or is left empty as a wildcard.
+func (s *MemoryStore) GetMatchingWebhooks(ctx context.Context, event, country string) ([]utils.Webhook, error) {
+	all, _ := s.GetAllWebhooks(ctx)
+
+	var hooks []utils.Webhook
+	for _, hook := range all {
+		if hook.Event == event && (hook.Country == country || hook.Country == "") {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// QueryWebhooks filters, sorts and pages the stored webhooks in process.
+func (s *MemoryStore) QueryWebhooks(ctx context.Context, query WebhookQuery) (*Page[utils.Webhook], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	hooks, _ := s.GetAllWebhooks(ctx)
+
+	_, desc := query.sortKey()
+	sort.SliceStable(hooks, func(i, j int) bool {
+		valueA, idA := query.sortValue(hooks[i])
+		valueB, idB := query.sortValue(hooks[j])
+		return sortLess(valueA, idA, valueB, idB, desc)
+	})
+
+	page := newPager(query.ListOptions, query.sortValue)
+	for _, hook := range hooks {
+		if query.matches(hook) {
+			page.offer(hook)
+		}
+	}
+	return page.result(), nil
+}
+
+// DeleteWebhook removes a webhook. Deleting a missing ID is not an error.
+func (s *MemoryStore) DeleteWebhook(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.webhooks, id)
+	return nil
+}
+
+// CountWebhooks returns the number of stored webhooks.
+func (s *MemoryStore) CountWebhooks(_ context.Context) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.webhooks)
+}
+
+// --- Tenants ---
+
+// SaveTenant stores a new tenant and returns the generated ID.
+func (s *MemoryStore) SaveTenant(_ context.Context, tenant utils.Tenant) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant.ID = newDocumentID()
+	s.tenants[tenant.ID] = tenant
+	return tenant.ID, nil
+}
+
+// PutTenant stores tenant under its own ID, replacing any tenant with that ID.
+func (s *MemoryStore) PutTenant(_ context.Context, tenant utils.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tenants[tenant.ID] = tenant
+	return nil
+}
+
+// GetTenantByID retrieves a tenant by its ID.
+func (s *MemoryStore) GetTenantByID(_ context.Context, id string) (*utils.Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &tenant, nil
+}
+
+// GetTenantByKeyHash finds the tenant whose API key hashes to keyHash.
+func (s *MemoryStore) GetTenantByKeyHash(_ context.Context, keyHash string) (*utils.Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tenant := range s.tenants {
+		if tenant.KeyHash == keyHash {
+			return &tenant, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetAllTenants returns every tenant ordered by ID.
+func (s *MemoryStore) GetAllTenants(_ context.Context) ([]utils.Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenants := []utils.Tenant{}
+	for _, tenant := range s.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+// UpdateTenant overwrites an existing tenant. Returns ErrNotFound if it does not exist.
+func (s *MemoryStore) UpdateTenant(_ context.Context, tenant utils.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tenants[tenant.ID]; !ok {
+		return ErrNotFound
+	}
+	s.tenants[tenant.ID] = tenant
+	return nil
+}
+
+// DeleteTenant removes a tenant. Deleting a missing ID is not an error.
+func (s *MemoryStore) DeleteTenant(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tenants, id)
+	return nil
+}
+
+// --- Caches ---
+
+// GetCacheEntry decodes the entry stored under key into dest.
+func (s *MemoryStore) GetCacheEntry(_ context.Context, collection, key string, dest interface{}) error {
+	s.mu.RLock()
+	entry, ok := s.caches[collection][key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	return entry.decodeInto(dest)
+}
+
+// SetCacheEntry stores data together with the current time under key.
+func (s *MemoryStore) SetCacheEntry(_ context.Context, collection, key string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.caches[collection] == nil {
+		s.caches[collection] = make(map[string]jsonCacheEntry)
+	}
+	s.caches[collection][key] = jsonCacheEntry{Timestamp: time.Now(), Data: raw}
+	return nil
+}
+
+// PurgeCacheEntries deletes every entry in collection whose timestamp is before olderThan, releasing
+// the lock after each batchSize entries. Returns the number of entries removed.
+func (s *MemoryStore) PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time, batchSize int) (int, error) {
+	purged := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		removed := s.purgeCacheBatch(collection, olderThan, batchSize)
+		purged += removed
+		if removed < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// purgeCacheBatch deletes up to limit entries in collection stored before olderThan.
+func (s *MemoryStore) purgeCacheBatch(collection string, olderThan time.Time, limit int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, entry := range s.caches[collection] {
+		if removed == limit {
+			break
+		}
+		if entry.Timestamp.Before(olderThan) {
+			delete(s.caches[collection], key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// DeleteCacheEntry removes a single entry. Deleting a missing key is not an error.
+func (s *MemoryStore) DeleteCacheEntry(_ context.Context, collection, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.caches[collection], key)
+	return nil
+}
+
+// GetCacheEntries returns up to limit entries in collection whose keys sort after the key after, ordered by key.
+func (s *MemoryStore) GetCacheEntries(_ context.Context, collection, after string, limit int) ([]utils.CacheRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []utils.CacheRecord{}
+	for key, entry := range s.caches[collection] {
+		if key > after {
+			entries = append(entries, utils.CacheRecord{Key: key, Timestamp: entry.Timestamp, Data: entry.Data})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// PutCacheEntry stores a raw entry, keeping its original timestamp.
+func (s *MemoryStore) PutCacheEntry(_ context.Context, collection string, entry utils.CacheRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.caches[collection] == nil {
+		s.caches[collection] = make(map[string]jsonCacheEntry)
+	}
+	s.caches[collection][entry.Key] = jsonCacheEntry{Timestamp: entry.Timestamp, Data: entry.Data}
+	return nil
+}
+
+// --- Lifecycle ---
+
+// Ping always succeeds for the in-memory store.
+func (s *MemoryStore) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
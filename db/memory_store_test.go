@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_DashboardLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	id, err := store.SaveDashboardConfig(ctx, utils.DashboardConfig{Country: "Norway", ISOCode: "NO"})
+	assert.NoError(t, err)
+	assert.Len(t, id, utils.DocumentIDLength)
+
+	config, err := store.GetDashboardConfigByID(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Norway", config.Country)
+	assert.Equal(t, id, config.ID)
+
+	config.Country = "Sweden"
+	assert.NoError(t, store.UpdateDashboardConfig(ctx, *config))
+
+	all, err := store.GetAllDashboardConfigs(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, "Sweden", all[0].Country)
+
+	assert.NoError(t, store.DeleteDashboardConfig(ctx, id))
+	_, err = store.GetDashboardConfigByID(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_MatchingWebhooks(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://a", Event: utils.EventInvoke, Country: "NO"})
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://b", Event: utils.EventInvoke, Country: ""})
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://c", Event: utils.EventInvoke, Country: "SE"})
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://d", Event: utils.EventDelete, Country: "NO"})
+
+	hooks, err := store.GetMatchingWebhooks(ctx, utils.EventInvoke, "NO")
+	assert.NoError(t, err)
+	assert.Len(t, hooks, 2)
+	assert.Equal(t, 4, store.CountWebhooks(ctx))
+}
+
+func TestMemoryStore_CacheEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	err := store.SetCacheEntry(ctx, utils.WeatherCacheCollection, "59.9_10.8", utils.WeatherData{Temperature: 4.5})
+	assert.NoError(t, err)
+
+	var entry struct {
+		Timestamp time.Time
+		Data      utils.WeatherData
+	}
+	assert.NoError(t, store.GetCacheEntry(ctx, utils.WeatherCacheCollection, "59.9_10.8", &entry))
+	assert.Equal(t, 4.5, entry.Data.Temperature)
+	assert.WithinDuration(t, time.Now(), entry.Timestamp, time.Minute)
+
+	purged, err := store.PurgeCacheEntries(ctx, utils.WeatherCacheCollection, time.Now().Add(-time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = store.PurgeCacheEntries(ctx, utils.WeatherCacheCollection, time.Now().Add(time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	err = store.GetCacheEntry(ctx, utils.WeatherCacheCollection, "59.9_10.8", &entry)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_ConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	config := utils.DashboardConfig{ID: "cas", Country: "Norway"}
+	assert.NoError(t, store.UpdateDashboardConfigIfVersion(ctx, config, 0))
+	assert.ErrorIs(t, store.UpdateDashboardConfigIfVersion(ctx, config, 0), ErrVersionConflict)
+
+	stored, _ := store.GetDashboardConfigByID(ctx, "cas")
+	assert.Equal(t, int64(1), stored.Version)
+
+	assert.NoError(t, store.UpdateDashboardConfigIfVersion(ctx, config, 1))
+	assert.ErrorIs(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 1), ErrVersionConflict)
+	assert.NoError(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 2))
+	assert.ErrorIs(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 2), ErrNotFound)
+}
+
+func TestMemoryStore_Revisions(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	first := utils.DashboardRevision{DashboardID: "d1", Revision: 1, Event: utils.EventRegister,
+		Config: utils.DashboardConfig{ID: "d1", Features: utils.FeatureConfig{TargetCurrencies: []string{"EUR"}}}}
+	assert.NoError(t, store.SaveRevision(ctx, utils.DashboardRevision{DashboardID: "d1", Revision: 2, Event: utils.EventPatch}))
+	assert.NoError(t, store.SaveRevision(ctx, first))
+	assert.ErrorIs(t, store.SaveRevision(ctx, first), ErrVersionConflict)
+
+	// Mutating the caller's copy must not change stored history
+	first.Config.Features.TargetCurrencies[0] = "USD"
+
+	revisions, err := store.GetRevisions(ctx, "d1")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, int64(1), revisions[0].Revision)
+	assert.Equal(t, []string{"EUR"}, revisions[0].Config.Features.TargetCurrencies)
+
+	_, err = store.GetRevision(ctx, "d1", 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	empty, err := store.GetRevisions(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestGetCacheEntries_PagesByKey(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"c", "a", "e", "b", "d"} {
+				require.NoError(t, store.SetCacheEntry(ctx, utils.CountryCacheCollection, key, key))
+			}
+
+			var keys []string
+			after := ""
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 3, "five entries fit in three pages of two")
+				entries, err := store.GetCacheEntries(ctx, utils.CountryCacheCollection, after, 2)
+				require.NoError(t, err)
+				for _, entry := range entries {
+					keys = append(keys, entry.Key)
+				}
+				if len(entries) < 2 {
+					break
+				}
+				after = entries[len(entries)-1].Key
+			}
+			assert.Equal(t, []string{"a", "b", "c", "d", "e"}, keys)
+		})
+	}
+}
+
+func TestPurgeCacheEntries_Batches(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			old := time.Now().Add(-2 * time.Hour)
+			for _, key := range []string{"a", "b", "c", "d", "e"} {
+				require.NoError(t, store.PutCacheEntry(ctx, utils.CountryCacheCollection,
+					utils.CacheRecord{Key: key, Timestamp: old, Data: []byte(`"old"`)}))
+			}
+			require.NoError(t, store.SetCacheEntry(ctx, utils.CountryCacheCollection, "fresh", "new"))
+
+			purged, err := store.PurgeCacheEntries(ctx, utils.CountryCacheCollection, time.Now().Add(-time.Hour), 2)
+			require.NoError(t, err)
+			assert.Equal(t, 5, purged)
+
+			entries, err := store.GetCacheEntries(ctx, utils.CountryCacheCollection, "", 10)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "fresh", entries[0].Key)
+
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err = store.PurgeCacheEntries(cancelled, utils.CountryCacheCollection, time.Now().Add(time.Hour), 2)
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrInvalidQuery is returned when a listing query has an unknown sort field, feature or a bad cursor.
+var ErrInvalidQuery = errors.New(utils.ErrInvalidListQuery)
+
+// PageCursor marks where the next page starts: the sort and sort value of the last item returned,
+// plus its ID as a tie-breaker. Clients only ever see it encoded as an opaque string.
+type PageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Encode returns the opaque, URL-safe form of the cursor.
+func (c PageCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by PageCursor.Encode.
+func DecodeCursor(encoded string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidCursor, ErrInvalidQuery)
+	}
+	var cursor PageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, fmt.Errorf(utils.ErrInvalidCursor, ErrInvalidQuery)
+	}
+	return &cursor, nil
+}
+
+// Page is one page of a listing together with the total number of matches and,
+// if more items follow, the cursor to fetch them.
+type Page[T any] struct {
+	Items []T
+	Total int
+	Next  *PageCursor
+}
+
+// ListOptions holds the sorting and paging parameters shared by every listing query.
+type ListOptions struct {
+	Sort  string // Field key, prefixed with "-" for descending order; defaults to "id"
+	Limit int    // Maximum page size; 0 returns every match
+	After *PageCursor
+}
+
+// sortKey splits Sort into a field key and direction, applying the default.
+func (o ListOptions) sortKey() (string, bool) {
+	if o.Sort == "" {
+		return utils.KeyID, false
+	}
+	return strings.TrimPrefix(o.Sort, "-"), strings.HasPrefix(o.Sort, "-")
+}
+
+// validate checks the sort field against allowed and that the cursor belongs to the same sort.
+func (o ListOptions) validate(allowed map[string]string) error {
+	field, _ := o.sortKey()
+	if _, ok := allowed[field]; !ok {
+		return fmt.Errorf(utils.ErrUnknownSortField, field, ErrInvalidQuery)
+	}
+	if o.After != nil && o.After.Sort != o.Sort {
+		return fmt.Errorf(utils.ErrInvalidCursor, ErrInvalidQuery)
+	}
+	if o.Limit < 0 {
+		return fmt.Errorf(utils.ErrInvalidLimit, utils.MaxPageLimit, ErrInvalidQuery)
+	}
+	return nil
+}
+
+// DashboardQuery filters, sorts and pages dashboard configurations.
+// String filters are case-insensitive exact matches; empty fields do not filter.
+type DashboardQuery struct {
+	ListOptions
+	ISOCode        string
+	Country        string
+	Features       []string // Feature keys (e.g. "capital") that must all be enabled
+	LastChangeFrom string   // Inclusive lower bound, formatted with utils.TimestampLayout
+	LastChangeTo   string   // Inclusive upper bound, formatted with utils.TimestampLayout
+	Deleted        bool     // List soft-deleted configs instead of live ones
+	TenantID       string   // Owning tenant; "" is the default tenant used when authentication is off
+	AllTenants     bool     // Ignore TenantID, for maintenance jobs such as the trash purge
+}
+
+// WebhookQuery filters, sorts and pages webhook registrations.
+type WebhookQuery struct {
+	ListOptions
+	Event      string
+	Country    string
+	TenantID   string
+	AllTenants bool
+}
+
+// dashboardSortColumns maps the sortable dashboard field keys to their SQL columns.
+var dashboardSortColumns = map[string]string{
+	utils.KeyID:         "id",
+	utils.KeyCountry:    "country",
+	utils.KeyISOCode:    "iso_code",
+	utils.KeyLastChange: "last_change",
+}
+
+// webhookSortColumns maps the sortable webhook field keys to their SQL columns.
+var webhookSortColumns = map[string]string{
+	utils.KeyID:      "id",
+	utils.KeyURL:     "url",
+	utils.KeyEvent:   "event",
+	utils.KeyCountry: "country",
+}
+
+// featureFlags exposes each boolean dashboard feature by its JSON key.
+var featureFlags = map[string]func(utils.FeatureConfig) bool{
+	utils.KeyTemperature:   func(f utils.FeatureConfig) bool { return f.Temperature },
+	utils.KeyPrecipitation: func(f utils.FeatureConfig) bool { return f.Precipitation },
+	utils.KeyCapital:       func(f utils.FeatureConfig) bool { return f.Capital },
+	utils.KeyCoordinates:   func(f utils.FeatureConfig) bool { return f.Coordinates },
+	utils.KeyPopulation:    func(f utils.FeatureConfig) bool { return f.Population },
+	utils.KeyArea:          func(f utils.FeatureConfig) bool { return f.Area },
+}
+
+// validate checks sorting, paging and feature names.
+func (q DashboardQuery) validate() error {
+	for _, feature := range q.Features {
+		if _, ok := featureFlags[feature]; !ok {
+			return fmt.Errorf(utils.ErrUnknownFeature, feature, ErrInvalidQuery)
+		}
+	}
+	return q.ListOptions.validate(dashboardSortColumns)
+}
+
+// matches reports whether config passes every filter of the query.
+func (q DashboardQuery) matches(config utils.DashboardConfig) bool {
+	if (config.DeletedAt != "") != q.Deleted {
+		return false
+	}
+	if !q.AllTenants && config.TenantID != q.TenantID {
+		return false
+	}
+	if q.ISOCode != "" && !strings.EqualFold(config.ISOCode, q.ISOCode) {
+		return false
+	}
+	if q.Country != "" && !strings.EqualFold(config.Country, q.Country) {
+		return false
+	}
+	for _, feature := range q.Features {
+		if !featureFlags[feature](config.Features) {
+			return false
+		}
+	}
+	if q.LastChangeFrom != "" && config.LastChange < q.LastChangeFrom {
+		return false
+	}
+	if q.LastChangeTo != "" && config.LastChange > q.LastChangeTo {
+		return false
+	}
+	return true
+}
+
+// sortValue returns the value of config's sort field and its ID.
+func (q DashboardQuery) sortValue(config utils.DashboardConfig) (string, string) {
+	field, _ := q.sortKey()
+	switch field {
+	case utils.KeyCountry:
+		return config.Country, config.ID
+	case utils.KeyISOCode:
+		return config.ISOCode, config.ID
+	case utils.KeyLastChange:
+		return config.LastChange, config.ID
+	}
+	return config.ID, config.ID
+}
+
+// validate checks sorting and paging.
+func (q WebhookQuery) validate() error {
+	return q.ListOptions.validate(webhookSortColumns)
+}
+
+// matches reports whether hook passes every filter of the query.
+func (q WebhookQuery) matches(hook utils.Webhook) bool {
+	if !q.AllTenants && hook.TenantID != q.TenantID {
+		return false
+	}
+	if q.Event != "" && !strings.EqualFold(hook.Event, q.Event) {
+		return false
+	}
+	if q.Country != "" && !strings.EqualFold(hook.Country, q.Country) {
+		return false
+	}
+	return true
+}
+
+// sortValue returns the value of hook's sort field and its ID.
+func (q WebhookQuery) sortValue(hook utils.Webhook) (string, string) {
+	field, _ := q.sortKey()
+	switch field {
+	case utils.KeyURL:
+		return hook.URL, hook.ID
+	case utils.KeyEvent:
+		return hook.Event, hook.ID
+	case utils.KeyCountry:
+		return hook.Country, hook.ID
+	}
+	return hook.ID, hook.ID
+}
+
+// pager builds a Page from items offered in sort order, for stores that filter in process.
+// Every offered item counts towards the total; only those after the cursor are collected.
+type pager[T any] struct {
+	opts  ListOptions
+	key   func(T) (string, string)
+	page  Page[T]
+	more  bool
+	lastV string
+	lastI string
+}
+
+// newPager returns a pager for opts that reads sort values with key.
+func newPager[T any](opts ListOptions, key func(T) (string, string)) *pager[T] {
+	return &pager[T]{opts: opts, key: key, page: Page[T]{Items: []T{}}}
+}
+
+// offer adds a matching item. Items must be offered in the query's sort order.
+func (p *pager[T]) offer(item T) {
+	p.page.Total++
+	value, id := p.key(item)
+	_, desc := p.opts.sortKey()
+	if p.opts.After != nil && !sortsAfter(value, id, p.opts.After.Value, p.opts.After.ID, desc) {
+		return
+	}
+	if p.opts.Limit > 0 && len(p.page.Items) >= p.opts.Limit {
+		p.more = true
+		return
+	}
+	p.page.Items = append(p.page.Items, item)
+	p.lastV, p.lastI = value, id
+}
+
+// result returns the collected page, with a next cursor if items were left over.
+func (p *pager[T]) result() *Page[T] {
+	if p.more {
+		p.page.Next = &PageCursor{Sort: p.opts.Sort, Value: p.lastV, ID: p.lastI}
+	}
+	return &p.page
+}
+
+// sortsAfter reports whether (value, id) comes strictly after (afterValue, afterID) in the sort direction.
+func sortsAfter(value, id, afterValue, afterID string, desc bool) bool {
+	if value != afterValue {
+		return (value > afterValue) != desc
+	}
+	return (id > afterID) != desc
+}
+
+// sortLess orders two (value, id) pairs in the sort direction, for stores that sort in process.
+func sortLess(valueA, idA, valueB, idB string, desc bool) bool {
+	return sortsAfter(valueB, idB, valueA, idA, desc)
+}
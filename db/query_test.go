@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listingStores returns every store that can run without external services.
+func listingStores(t *testing.T) map[string]Store {
+	sqlite, _ := newTestSQLiteStore(t)
+	return map[string]Store{utils.StoreMemory: NewMemoryStore(), utils.StoreSQLite: sqlite}
+}
+
+// seedListingData stores five dashboards (one soft-deleted) and four webhooks.
+func seedListingData(t *testing.T, store Store) {
+	ctx := context.Background()
+	configs := []utils.DashboardConfig{
+		{ID: "a", Country: "Norway", ISOCode: "NO", LastChange: "20250101 10:00", Features: utils.FeatureConfig{Capital: true}},
+		{ID: "b", Country: "Sweden", ISOCode: "SE", LastChange: "20250102 10:00", Features: utils.FeatureConfig{Capital: true, Area: true}},
+		{ID: "c", Country: "Norway", ISOCode: "NO", LastChange: "20250103 10:00"},
+		{ID: "d", Country: "Denmark", ISOCode: "DK", LastChange: "20250104 10:00", Features: utils.FeatureConfig{Area: true}},
+		{ID: "e", Country: "Finland", ISOCode: "FI", LastChange: "20250105 10:00", DeletedAt: "20250106 10:00"},
+	}
+	for _, config := range configs {
+		require.NoError(t, store.UpdateDashboardConfig(ctx, config))
+	}
+	for _, hook := range []utils.Webhook{
+		{URL: "http://a", Event: utils.EventInvoke, Country: "NO"},
+		{URL: "http://b", Event: utils.EventInvoke, Country: "SE"},
+		{URL: "http://c", Event: utils.EventDelete, Country: "NO"},
+		{URL: "http://d", Event: utils.EventChange},
+	} {
+		_, err := store.SaveWebhook(ctx, hook)
+		require.NoError(t, err)
+	}
+}
+
+// configIDs extracts the IDs of a page of configs, in order.
+func configIDs(configs []utils.DashboardConfig) []string {
+	ids := []string{}
+	for _, config := range configs {
+		ids = append(ids, config.ID)
+	}
+	return ids
+}
+
+func TestQueryDashboardConfigs(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			seedListingData(t, store)
+
+			all, err := store.QueryDashboardConfigs(ctx, DashboardQuery{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "b", "c", "d"}, configIDs(all.Items))
+			assert.Equal(t, 4, all.Total)
+			assert.Nil(t, all.Next)
+
+			trashed, err := store.QueryDashboardConfigs(ctx, DashboardQuery{Deleted: true})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"e"}, configIDs(trashed.Items))
+
+			norway, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ISOCode: "no"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "c"}, configIDs(norway.Items))
+
+			capitalAndArea, err := store.QueryDashboardConfigs(ctx, DashboardQuery{
+				Features: []string{utils.KeyCapital, utils.KeyArea}})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"b"}, configIDs(capitalAndArea.Items))
+
+			ranged, err := store.QueryDashboardConfigs(ctx, DashboardQuery{
+				LastChangeFrom: "20250102 10:00", LastChangeTo: "20250103 10:00"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"b", "c"}, configIDs(ranged.Items))
+
+			byCountryDesc, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: ListOptions{Sort: "-country"}})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"b", "c", "a", "d"}, configIDs(byCountryDesc.Items))
+		})
+	}
+}
+
+func TestQueryDashboardConfigs_Paging(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			seedListingData(t, store)
+
+			opts := ListOptions{Sort: utils.KeyCountry, Limit: 3}
+			first, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: opts})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"d", "a", "c"}, configIDs(first.Items))
+			assert.Equal(t, 4, first.Total)
+			require.NotNil(t, first.Next)
+
+			// The cursor survives its opaque round trip
+			cursor, err := DecodeCursor(first.Next.Encode())
+			require.NoError(t, err)
+			opts.After = cursor
+			second, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: opts})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"b"}, configIDs(second.Items))
+			assert.Equal(t, 4, second.Total)
+			assert.Nil(t, second.Next)
+
+			// A cursor cannot be reused with a different sort
+			opts.Sort = utils.KeyLastChange
+			_, err = store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: opts})
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+
+			_, err = store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: ListOptions{Sort: "features"}})
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+			_, err = store.QueryDashboardConfigs(ctx, DashboardQuery{Features: []string{"gold"}})
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+		})
+	}
+}
+
+func TestQueryWebhooks(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			seedListingData(t, store)
+
+			invoke, err := store.QueryWebhooks(ctx, WebhookQuery{Event: "invoke"})
+			require.NoError(t, err)
+			assert.Equal(t, 2, invoke.Total)
+
+			norway, err := store.QueryWebhooks(ctx, WebhookQuery{Country: "NO", ListOptions: ListOptions{Sort: "-url"}})
+			require.NoError(t, err)
+			require.Len(t, norway.Items, 2)
+			assert.Equal(t, "http://c", norway.Items[0].URL)
+
+			var urls []string
+			opts := ListOptions{Sort: utils.KeyURL, Limit: 1}
+			for {
+				page, err := store.QueryWebhooks(ctx, WebhookQuery{ListOptions: opts})
+				require.NoError(t, err)
+				for _, hook := range page.Items {
+					urls = append(urls, hook.URL)
+				}
+				if page.Next == nil {
+					break
+				}
+				opts.After = page.Next
+			}
+			assert.Equal(t, []string{"http://a", "http://b", "http://c", "http://d"}, urls)
+		})
+	}
+}
+
+func TestCaseVariants(t *testing.T) {
+	assert.Equal(t, []string{"no", "NO", "No"}, caseVariants("no"))
+	assert.Equal(t, []string{"united states", "UNITED STATES", "United States"}, caseVariants("united states"))
+	assert.Equal(t, []string{"ÅLAND", "åland", "Åland"}, caseVariants("ÅLAND"))
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	_, err := DecodeCursor("not a cursor!")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// SaveRevision stores an immutable snapshot of a dashboard configuration.
+func SaveRevision(ctx context.Context, revision utils.DashboardRevision) error {
+	return CurrentStore().SaveRevision(ctx, revision)
+}
+
+// GetRevisions returns every revision of a dashboard, oldest first.
+// Returns an empty slice if the dashboard has no recorded history.
+func GetRevisions(ctx context.Context, dashboardID string) ([]utils.DashboardRevision, error) {
+	return CurrentStore().GetRevisions(ctx, dashboardID)
+}
+
+// GetRevision returns a single revision of a dashboard, or ErrNotFound.
+func GetRevision(ctx context.Context, dashboardID string, revision int64) (*utils.DashboardRevision, error) {
+	return CurrentStore().GetRevision(ctx, dashboardID, revision)
+}
+
+// DeleteRevisions removes the whole revision history of a dashboard.
+func DeleteRevisions(ctx context.Context, dashboardID string) error {
+	return CurrentStore().DeleteRevisions(ctx, dashboardID)
+}
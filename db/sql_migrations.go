@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// sqlMigration is one ordered, forward-only schema change for SQLStore.
+// Statements must be valid in both SQLite and PostgreSQL.
+type sqlMigration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// sqlMigrations lists every schema change in the order it must be applied.
+// Append new migrations to the end; never edit one that has already shipped.
+var sqlMigrations = []sqlMigration{
+	{
+		Version:     1,
+		Description: "create registrations, webhooks and cache tables",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS dashboard_configs (
+				id          TEXT PRIMARY KEY,
+				country     TEXT NOT NULL,
+				iso_code    TEXT NOT NULL,
+				features    TEXT NOT NULL,
+				last_change TEXT NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS webhooks (
+				id      TEXT PRIMARY KEY,
+				url     TEXT NOT NULL,
+				event   TEXT NOT NULL,
+				country TEXT NOT NULL DEFAULT ''
+			)`,
+			// Serves GetMatchingWebhooks: WHERE event = ? AND country IN (?, '')
+			`CREATE INDEX IF NOT EXISTS idx_webhooks_event_country ON webhooks (event, country)`,
+			`CREATE TABLE IF NOT EXISTS cache_entries (
+				collection TEXT NOT NULL,
+				cache_key  TEXT NOT NULL,
+				data       TEXT NOT NULL,
+				stored_at  BIGINT NOT NULL,
+				PRIMARY KEY (collection, cache_key)
+			)`,
+			// Serves the timestamp-based purge: WHERE collection = ? AND stored_at < ?
+			`CREATE INDEX IF NOT EXISTS idx_cache_entries_collection_stored_at ON cache_entries (collection, stored_at)`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "add optimistic concurrency version to registrations",
+		Statements: []string{
+			`ALTER TABLE dashboard_configs ADD COLUMN version BIGINT NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "add registration revision history",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS dashboard_revisions (
+				dashboard_id TEXT NOT NULL,
+				revision     BIGINT NOT NULL,
+				event        TEXT NOT NULL,
+				timestamp    TEXT NOT NULL,
+				config       TEXT NOT NULL,
+				diff         TEXT NOT NULL,
+				PRIMARY KEY (dashboard_id, revision)
+			)`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "add soft-delete marker to registrations",
+		Statements: []string{
+			`ALTER TABLE dashboard_configs ADD COLUMN deleted_at TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "add tenants and tenant ownership of registrations and webhooks",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS tenants (
+				id             TEXT PRIMARY KEY,
+				name           TEXT NOT NULL,
+				key_hash       TEXT NOT NULL UNIQUE,
+				created_at     TEXT NOT NULL,
+				key_rotated_at TEXT NOT NULL DEFAULT ''
+			)`,
+			// Existing rows belong to the default tenant used when authentication is off
+			`ALTER TABLE dashboard_configs ADD COLUMN tenant_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE webhooks ADD COLUMN tenant_id TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX IF NOT EXISTS idx_dashboard_configs_tenant ON dashboard_configs (tenant_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_webhooks_tenant ON webhooks (tenant_id)`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "add document schema version to registrations",
+		Statements: []string{
+			// Existing rows predate document versioning and are upgraded lazily or by the migrate command
+			`ALTER TABLE dashboard_configs ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// migrate applies every migration newer than the recorded schema version.
+// Each migration runs in its own transaction together with its bookkeeping row.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if _, err := s.exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf(utils.ErrSQLMigration, 0, err)
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf(utils.ErrSQLMigration, 0, err)
+	}
+
+	for _, migration := range sqlMigrations {
+		if migration.Version <= current {
+			continue
+		}
+		if applyErr := s.applyMigration(ctx, migration); applyErr != nil {
+			return fmt.Errorf(utils.ErrSQLMigration, migration.Version, applyErr)
+		}
+		log.Printf(utils.MsgSQLMigrationApplied, migration.Version, migration.Description)
+	}
+	return nil
+}
+
+// applyMigration runs a single migration atomically.
+func (s *SQLStore) applyMigration(ctx context.Context, migration sqlMigration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // no-op once committed
+
+	for _, statement := range migration.Statements {
+		if _, execErr := tx.ExecContext(ctx, s.rebind(statement)); execErr != nil {
+			return execErr
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`),
+		migration.Version, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 for a fresh database.
+func (s *SQLStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.queryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
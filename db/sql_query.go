@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// sqlFilter accumulates the WHERE clauses and arguments of a listing query.
+type sqlFilter struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends a clause; every clause must hold for a row to match.
+func (f *sqlFilter) add(clause string, args ...interface{}) {
+	f.clauses = append(f.clauses, clause)
+	f.args = append(f.args, args...)
+}
+
+// where renders the clauses as a WHERE suffix, or "" if there are none.
+func (f *sqlFilter) where() string {
+	if len(f.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.clauses, " AND ")
+}
+
+// featureEnabledClause tests a boolean key of the JSON features column in the store's dialect.
+func (s *SQLStore) featureEnabledClause(feature string) (string, interface{}) {
+	if s.dialect == utils.StorePostgres {
+		return `(features::jsonb ->> ?) = 'true'`, feature
+	}
+	return `json_extract(features, ?) = 1`, "$." + feature
+}
+
+// scanDashboards decodes all remaining dashboard_configs rows, skipping rows that fail to decode.
+func scanDashboards(rows *sql.Rows) ([]utils.DashboardConfig, error) {
+	defer rows.Close()
+
+	configs := []utils.DashboardConfig{}
+	for rows.Next() {
+		r, err := scanDashboardRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		config, _, decodeErr := r.decode()
+		if decodeErr != nil {
+			logUndecodableDashboard(r.id, decodeErr)
+			continue
+		}
+		configs = append(configs, *config)
+	}
+	return configs, rows.Err()
+}
+
+// scanPagedDashboard reads one dashboard_configs row for queryPage. A row that fails to decode is logged
+// and reported as not decoded, with the columns it sorts by, so that the page cursor can still pass it.
+func scanPagedDashboard(rows *sql.Rows) (utils.DashboardConfig, bool, error) {
+	r, err := scanDashboardRow(rows)
+	if err != nil {
+		return utils.DashboardConfig{}, false, err
+	}
+	config, _, decodeErr := r.decode()
+	if decodeErr != nil {
+		logUndecodableDashboard(r.id, decodeErr)
+		return utils.DashboardConfig{ID: r.id, Country: r.country, ISOCode: r.isoCode, LastChange: r.lastChange}, false, nil
+	}
+	return *config, true, nil
+}
+
+// scanPagedWebhook reads one webhooks row for queryPage. Webhook rows always decode.
+func scanPagedWebhook(rows *sql.Rows) (utils.Webhook, bool, error) {
+	hook, err := scanWebhookRow(rows)
+	return hook, err == nil, err
+}
+
+// QueryDashboardConfigs pushes filtering, keyset pagination and sorting down into SQL.
+func (s *SQLStore) QueryDashboardConfigs(ctx context.Context, query DashboardQuery) (*Page[utils.DashboardConfig], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	if query.Deleted {
+		filter.add(`deleted_at <> ''`)
+	} else {
+		filter.add(`deleted_at = ''`)
+	}
+	if !query.AllTenants {
+		filter.add(`tenant_id = ?`, query.TenantID)
+	}
+	if query.ISOCode != "" {
+		filter.add(`LOWER(iso_code) = LOWER(?)`, query.ISOCode)
+	}
+	if query.Country != "" {
+		filter.add(`LOWER(country) = LOWER(?)`, query.Country)
+	}
+	for _, feature := range query.Features {
+		filter.add(s.featureEnabledClause(feature))
+	}
+	if query.LastChangeFrom != "" {
+		filter.add(`last_change >= ?`, query.LastChangeFrom)
+	}
+	if query.LastChangeTo != "" {
+		filter.add(`last_change <= ?`, query.LastChangeTo)
+	}
+
+	return queryPage(ctx, s, "dashboard_configs",
+		dashboardColumns,
+		filter, query.ListOptions, dashboardSortColumns, scanPagedDashboard, query.sortValue)
+}
+
+// QueryWebhooks pushes filtering, keyset pagination and sorting down into SQL.
+func (s *SQLStore) QueryWebhooks(ctx context.Context, query WebhookQuery) (*Page[utils.Webhook], error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	if !query.AllTenants {
+		filter.add(`tenant_id = ?`, query.TenantID)
+	}
+	if query.Event != "" {
+		filter.add(`UPPER(event) = UPPER(?)`, query.Event)
+	}
+	if query.Country != "" {
+		filter.add(`LOWER(country) = LOWER(?)`, query.Country)
+	}
+
+	return queryPage(ctx, s, "webhooks", "id, url, event, country, tenant_id",
+		filter, query.ListOptions, webhookSortColumns, scanPagedWebhook, query.sortValue)
+}
+
+// queryPage counts the rows matching filter, then fetches the page after opts.After ordered by
+// (sort column, id). One extra row is fetched to decide whether a next cursor is needed. Rows that
+// fail to decode are left out of the page and its total, but the cursor still moves past them; the
+// total only leaves out those on this page.
+func queryPage[T any](ctx context.Context, s *SQLStore, table, columns string, filter sqlFilter,
+	opts ListOptions, sortColumns map[string]string,
+	scanRow func(*sql.Rows) (T, bool, error), key func(T) (string, string)) (*Page[T], error) {
+
+	page := &Page[T]{Items: []T{}}
+	countQuery := `SELECT COUNT(*) FROM ` + table + filter.where()
+	if err := s.queryRow(ctx, countQuery, filter.args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	field, desc := opts.sortKey()
+	column := sortColumns[field]
+	order, comparison := "ASC", ">"
+	if desc {
+		order, comparison = "DESC", "<"
+	}
+	if opts.After != nil {
+		filter.add(fmt.Sprintf(`(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))`, column, comparison),
+			opts.After.Value, opts.After.Value, opts.After.ID)
+	}
+
+	pageQuery := fmt.Sprintf(`SELECT %s FROM %s%s ORDER BY %s %s, id %s`,
+		columns, table, filter.where(), column, order, order)
+	if opts.Limit > 0 {
+		pageQuery += fmt.Sprintf(` LIMIT %d`, opts.Limit+1)
+	}
+
+	rows, err := s.query(ctx, pageQuery, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// The cursor comes from the last row scanned, decoded or not, so undecodable rows never end paging early
+	scanned := 0
+	var lastValue, lastID string
+	for rows.Next() {
+		if opts.Limit > 0 && scanned == opts.Limit {
+			page.Next = &PageCursor{Sort: opts.Sort, Value: lastValue, ID: lastID}
+			break
+		}
+		scanned++
+		item, decoded, scanErr := scanRow(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		lastValue, lastID = key(item)
+		if !decoded {
+			page.Total--
+			continue
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
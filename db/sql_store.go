@@ -0,0 +1,694 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" database/sql driver
+)
+
+// SQLStore is the Store implementation backed by a relational database.
+// SQLite and PostgreSQL are supported; queries are written with "?" placeholders
+// and rewritten for PostgreSQL by rebind.
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// NewSQLStore opens a SQL database for the given dialect (utils.StoreSQLite or utils.StorePostgres),
+// verifies the connection and applies any pending schema migrations.
+func NewSQLStore(ctx context.Context, dialect, dsn string) (*SQLStore, error) {
+	var driver string
+	switch dialect {
+	case utils.StoreSQLite:
+		driver = utils.DriverSQLite
+		if dsn == "" {
+			dsn = utils.DefaultSQLiteDSN
+		}
+	case utils.StorePostgres:
+		driver = utils.DriverPostgres
+		if dsn == "" {
+			return nil, errors.New(utils.ErrMissingDSN)
+		}
+	default:
+		return nil, fmt.Errorf(utils.ErrUnknownStore, dialect)
+	}
+
+	database, openErr := sql.Open(driver, dsn)
+	if openErr != nil {
+		return nil, fmt.Errorf(utils.ErrOpenSQLStore, openErr)
+	}
+
+	// SQLite only allows one writer at a time, so serialize access through a single connection
+	if dialect == utils.StoreSQLite {
+		database.SetMaxOpenConns(1)
+	}
+
+	store := &SQLStore{db: database, dialect: dialect}
+	if pingErr := store.Ping(ctx); pingErr != nil {
+		_ = database.Close()
+		return nil, fmt.Errorf(utils.ErrOpenSQLStore, pingErr)
+	}
+	if migrateErr := store.migrate(ctx); migrateErr != nil {
+		_ = database.Close()
+		return nil, migrateErr
+	}
+	return store, nil
+}
+
+// rebind rewrites "?" placeholders into the "$n" form expected by PostgreSQL.
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != utils.StorePostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// exec runs a statement that does not return rows.
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.rebind(query), args...)
+}
+
+// queryRow runs a query that returns at most one row.
+func (s *SQLStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.rebind(query), args...)
+}
+
+// query runs a query that returns rows.
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.rebind(query), args...)
+}
+
+// translateSQLErr maps sql.ErrNoRows onto ErrNotFound.
+func translateSQLErr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// --- Dashboards ---
+
+// dashboardColumns lists the dashboard_configs columns in the order read by scanDashboardRow.
+const dashboardColumns = "id, country, iso_code, features, last_change, version, deleted_at, tenant_id, schema_version"
+
+// dashboardRow is a dashboard_configs row as stored, before it is upgraded and decoded.
+type dashboardRow struct {
+	id, country, isoCode, features, lastChange, deletedAt, tenantID string
+	version                                                         int64
+	schemaVersion                                                   int
+}
+
+// scanDashboardRow reads one dashboard_configs row without decoding it.
+func scanDashboardRow(row interface{ Scan(...interface{}) error }) (*dashboardRow, error) {
+	var r dashboardRow
+	if err := row.Scan(&r.id, &r.country, &r.isoCode, &r.features, &r.lastChange,
+		&r.version, &r.deletedAt, &r.tenantID, &r.schemaVersion); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// decode upgrades the row to the current schema and decodes it.
+// Reports whether the stored row is outdated.
+func (r dashboardRow) decode() (*utils.DashboardConfig, bool, error) {
+	var features interface{}
+	if err := json.Unmarshal([]byte(r.features), &features); err != nil {
+		return nil, false, fmt.Errorf(utils.ErrDecodeDocument, err)
+	}
+	return decodeDashboardDocument(map[string]interface{}{
+		utils.KeyID:            r.id,
+		utils.KeyCountry:       r.country,
+		utils.KeyISOCode:       r.isoCode,
+		utils.KeyFeatures:      features,
+		utils.KeyLastChange:    r.lastChange,
+		utils.KeyVersion:       r.version,
+		utils.KeyDeletedAt:     r.deletedAt,
+		utils.KeyTenantID:      r.tenantID,
+		utils.KeySchemaVersion: r.schemaVersion,
+	})
+}
+
+// scanDashboard reads and decodes one dashboard_configs row.
+func scanDashboard(row interface{ Scan(...interface{}) error }) (*utils.DashboardConfig, bool, error) {
+	r, err := scanDashboardRow(row)
+	if err != nil {
+		return nil, false, err
+	}
+	return r.decode()
+}
+
+// writeBackUpgraded stores an upgraded config, unless it was changed or upgraded since it was read.
+func (s *SQLStore) writeBackUpgraded(ctx context.Context, config utils.DashboardConfig) error {
+	features, err := json.Marshal(config.Features)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, `UPDATE dashboard_configs
+		SET country = ?, iso_code = ?, features = ?, last_change = ?, deleted_at = ?, tenant_id = ?, schema_version = ?
+		WHERE id = ? AND version = ? AND schema_version <> ?`,
+		config.Country, config.ISOCode, string(features), config.LastChange, config.DeletedAt, config.TenantID,
+		DashboardSchemaVersion(), config.ID, config.Version, DashboardSchemaVersion())
+	return err
+}
+
+// SaveDashboardConfig stores a new dashboard configuration and returns the generated ID.
+func (s *SQLStore) SaveDashboardConfig(ctx context.Context, config utils.DashboardConfig) (string, error) {
+	config.ID = newDocumentID()
+	if err := s.UpdateDashboardConfig(ctx, config); err != nil {
+		return "", err
+	}
+	return config.ID, nil
+}
+
+// GetDashboardConfigByID retrieves a dashboard configuration by its ID.
+func (s *SQLStore) GetDashboardConfigByID(ctx context.Context, id string) (*utils.DashboardConfig, error) {
+	row := s.queryRow(ctx, `SELECT `+dashboardColumns+` FROM dashboard_configs WHERE id = ?`, id)
+	config, upgraded, err := scanDashboard(row)
+	if err != nil {
+		return nil, translateSQLErr(err)
+	}
+	if upgraded {
+		if writeErr := s.writeBackUpgraded(ctx, *config); writeErr != nil {
+			log.Printf(utils.ErrWriteBackUpgraded, id, writeErr)
+		}
+	}
+	return config, nil
+}
+
+// GetAllDashboardConfigs retrieves all dashboard configurations ordered by ID, upgraded to the
+// current schema. Rows that fail to decode are logged and skipped.
+func (s *SQLStore) GetAllDashboardConfigs(ctx context.Context) ([]utils.DashboardConfig, error) {
+	rows, err := s.query(ctx, `SELECT `+dashboardColumns+` FROM dashboard_configs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanDashboards(rows)
+}
+
+// MigrateDashboardConfigs upgrades every outdated row. All rows are read before any is written,
+// since SQLite cannot write while a result set is open on its single connection.
+func (s *SQLStore) MigrateDashboardConfigs(ctx context.Context, dryRun bool) (*utils.MigrationReport, error) {
+	rows, err := s.query(ctx, `SELECT `+dashboardColumns+` FROM dashboard_configs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	var stored []dashboardRow
+	for rows.Next() {
+		r, scanErr := scanDashboardRow(rows)
+		if scanErr != nil {
+			_ = rows.Close()
+			return nil, scanErr
+		}
+		stored = append(stored, *r)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	run := newMigrationRun(dryRun)
+	for _, r := range stored {
+		config, upgraded, decodeErr := r.decode()
+		if decodeErr == nil && upgraded && !dryRun {
+			decodeErr = s.writeBackUpgraded(ctx, *config)
+		}
+		run.record(r.id, upgraded, decodeErr)
+	}
+	return &run.report, nil
+}
+
+// UpdateDashboardConfig creates or overwrites the dashboard configuration stored under config.ID.
+func (s *SQLStore) UpdateDashboardConfig(ctx context.Context, config utils.DashboardConfig) error {
+	features, err := json.Marshal(config.Features)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, `INSERT INTO dashboard_configs (id, country, iso_code, features, last_change, version, deleted_at, tenant_id, schema_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			country = excluded.country,
+			iso_code = excluded.iso_code,
+			features = excluded.features,
+			last_change = excluded.last_change,
+			version = excluded.version,
+			deleted_at = excluded.deleted_at,
+			tenant_id = excluded.tenant_id,
+			schema_version = excluded.schema_version`,
+		config.ID, config.Country, config.ISOCode, string(features), config.LastChange, config.Version, config.DeletedAt,
+		config.TenantID, DashboardSchemaVersion())
+	return err
+}
+
+// UpdateDashboardConfigIfVersion writes config only if the stored version equals expected.
+// A compare-and-set UPDATE handles existing rows; expected == 0 may also insert a new row.
+func (s *SQLStore) UpdateDashboardConfigIfVersion(ctx context.Context, config utils.DashboardConfig, expected int64) error {
+	features, err := json.Marshal(config.Features)
+	if err != nil {
+		return err
+	}
+	config.Version = expected + 1
+
+	result, err := s.exec(ctx, `UPDATE dashboard_configs
+		SET country = ?, iso_code = ?, features = ?, last_change = ?, version = ?, deleted_at = ?, tenant_id = ?,
+			schema_version = ?
+		WHERE id = ? AND version = ?`,
+		config.Country, config.ISOCode, string(features), config.LastChange, config.Version, config.DeletedAt,
+		config.TenantID, DashboardSchemaVersion(), config.ID, expected)
+	if err != nil {
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 1 {
+		return nil
+	}
+	if expected != 0 {
+		return ErrVersionConflict
+	}
+
+	// No row matched version 0: insert unless someone else created the document first
+	result, err = s.exec(ctx, `INSERT INTO dashboard_configs (id, country, iso_code, features, last_change, version, deleted_at, tenant_id, schema_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		config.ID, config.Country, config.ISOCode, string(features), config.LastChange, config.Version, config.DeletedAt,
+		config.TenantID, DashboardSchemaVersion())
+	if err != nil {
+		return err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 1 {
+		return nil
+	}
+	return ErrVersionConflict
+}
+
+// DeleteDashboardConfigIfVersion deletes the configuration only if its stored version equals expected.
+func (s *SQLStore) DeleteDashboardConfigIfVersion(ctx context.Context, id string, expected int64) error {
+	result, err := s.exec(ctx, `DELETE FROM dashboard_configs WHERE id = ? AND version = ?`, id, expected)
+	if err != nil {
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 1 {
+		return nil
+	}
+	if _, getErr := s.GetDashboardConfigByID(ctx, id); getErr != nil {
+		return getErr
+	}
+	return ErrVersionConflict
+}
+
+// DeleteDashboardConfig removes a dashboard configuration. Deleting a missing ID is not an error.
+func (s *SQLStore) DeleteDashboardConfig(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, `DELETE FROM dashboard_configs WHERE id = ?`, id)
+	return err
+}
+
+// --- Revisions ---
+
+// DeleteRevisions removes a dashboard's whole revision history.
+func (s *SQLStore) DeleteRevisions(ctx context.Context, dashboardID string) error {
+	_, err := s.exec(ctx, `DELETE FROM dashboard_revisions WHERE dashboard_id = ?`, dashboardID)
+	return err
+}
+
+// SaveRevision inserts an immutable revision row.
+// Returns ErrVersionConflict if the revision number already exists.
+func (s *SQLStore) SaveRevision(ctx context.Context, revision utils.DashboardRevision) error {
+	config, err := json.Marshal(revision.Config)
+	if err != nil {
+		return err
+	}
+	diff, err := json.Marshal(revision.Diff)
+	if err != nil {
+		return err
+	}
+	result, err := s.exec(ctx, `INSERT INTO dashboard_revisions (dashboard_id, revision, event, timestamp, config, diff)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (dashboard_id, revision) DO NOTHING`,
+		revision.DashboardID, revision.Revision, revision.Event, revision.Timestamp, string(config), string(diff))
+	if err != nil {
+		return err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// scanRevision decodes one dashboard_revisions row.
+func scanRevision(row interface{ Scan(...interface{}) error }) (*utils.DashboardRevision, error) {
+	var revision utils.DashboardRevision
+	var config, diff string
+	if err := row.Scan(&revision.DashboardID, &revision.Revision, &revision.Event, &revision.Timestamp, &config, &diff); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(config), &revision.Config); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(diff), &revision.Diff); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetRevisions returns every revision of a dashboard, oldest first.
+func (s *SQLStore) GetRevisions(ctx context.Context, dashboardID string) ([]utils.DashboardRevision, error) {
+	rows, err := s.query(ctx, `SELECT dashboard_id, revision, event, timestamp, config, diff
+		FROM dashboard_revisions WHERE dashboard_id = ? ORDER BY revision`, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []utils.DashboardRevision{}
+	for rows.Next() {
+		revision, scanErr := scanRevision(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a single revision of a dashboard, or ErrNotFound.
+func (s *SQLStore) GetRevision(ctx context.Context, dashboardID string, revision int64) (*utils.DashboardRevision, error) {
+	row := s.queryRow(ctx, `SELECT dashboard_id, revision, event, timestamp, config, diff
+		FROM dashboard_revisions WHERE dashboard_id = ? AND revision = ?`, dashboardID, revision)
+	found, err := scanRevision(row)
+	if err != nil {
+		return nil, translateSQLErr(err)
+	}
+	return found, nil
+}
+
+// --- Webhooks ---
+
+// scanWebhooks decodes all remaining webhook rows.
+func scanWebhooks(rows *sql.Rows) ([]utils.Webhook, error) {
+	defer rows.Close()
+
+	var hooks []utils.Webhook
+	for rows.Next() {
+		hook, err := scanWebhookRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// scanWebhookRow reads one webhooks row.
+func scanWebhookRow(row interface{ Scan(...interface{}) error }) (utils.Webhook, error) {
+	var hook utils.Webhook
+	err := row.Scan(&hook.ID, &hook.URL, &hook.Event, &hook.Country, &hook.TenantID)
+	return hook, err
+}
+
+// SaveWebhook stores a new webhook and returns the generated ID.
+func (s *SQLStore) SaveWebhook(ctx context.Context, webhook utils.Webhook) (string, error) {
+	webhook.ID = newDocumentID()
+	_, err := s.exec(ctx, `INSERT INTO webhooks (id, url, event, country, tenant_id) VALUES (?, ?, ?, ?, ?)`,
+		webhook.ID, webhook.URL, webhook.Event, webhook.Country, webhook.TenantID)
+	if err != nil {
+		return "", err
+	}
+	return webhook.ID, nil
+}
+
+// PutWebhook stores webhook under its own ID, replacing any webhook with that ID.
+func (s *SQLStore) PutWebhook(ctx context.Context, webhook utils.Webhook) error {
+	_, err := s.exec(ctx, `INSERT INTO webhooks (id, url, event, country, tenant_id) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			url = excluded.url,
+			event = excluded.event,
+			country = excluded.country,
+			tenant_id = excluded.tenant_id`,
+		webhook.ID, webhook.URL, webhook.Event, webhook.Country, webhook.TenantID)
+	return err
+}
+
+// GetWebhookByID retrieves a single webhook by its ID.
+func (s *SQLStore) GetWebhookByID(ctx context.Context, id string) (*utils.Webhook, error) {
+	var hook utils.Webhook
+	err := s.queryRow(ctx, `SELECT id, url, event, country, tenant_id FROM webhooks WHERE id = ?`, id).
+		Scan(&hook.ID, &hook.URL, &hook.Event, &hook.Country, &hook.TenantID)
+	if err != nil {
+		return nil, translateSQLErr(err)
+	}
+	return &hook, nil
+}
+
+// GetAllWebhooks returns every stored webhook ordered by ID.
+func (s *SQLStore) GetAllWebhooks(ctx context.Context) ([]utils.Webhook, error) {
+	rows, err := s.query(ctx, `SELECT id, url, event, country, tenant_id FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// GetMatchingWebhooks finds webhooks registered for event whose country matches
+// exactly or is left empty as a wildcard. Served by the (event, country) index.
+func (s *SQLStore) GetMatchingWebhooks(ctx context.Context, event, country string) ([]utils.Webhook, error) {
+	rows, err := s.query(ctx, `SELECT id, url, event, country, tenant_id FROM webhooks
+		WHERE event = ? AND country IN (?, '') ORDER BY id`, event, country)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// DeleteWebhook removes a webhook. Deleting a missing ID is not an error.
+func (s *SQLStore) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	return err
+}
+
+// CountWebhooks returns the number of stored webhooks, or 0 if an error occurs.
+func (s *SQLStore) CountWebhooks(ctx context.Context) int {
+	var count int
+	if err := s.queryRow(ctx, `SELECT COUNT(*) FROM webhooks`).Scan(&count); err != nil {
+		return 0
+	}
+	return count
+}
+
+// --- Tenants ---
+
+// scanTenant decodes one tenants row.
+func scanTenant(row interface{ Scan(...interface{}) error }) (*utils.Tenant, error) {
+	var tenant utils.Tenant
+	if err := row.Scan(&tenant.ID, &tenant.Name, &tenant.KeyHash, &tenant.CreatedAt, &tenant.KeyRotatedAt); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// SaveTenant stores a new tenant and returns the generated ID.
+func (s *SQLStore) SaveTenant(ctx context.Context, tenant utils.Tenant) (string, error) {
+	tenant.ID = newDocumentID()
+	_, err := s.exec(ctx, `INSERT INTO tenants (id, name, key_hash, created_at, key_rotated_at) VALUES (?, ?, ?, ?, ?)`,
+		tenant.ID, tenant.Name, tenant.KeyHash, tenant.CreatedAt, tenant.KeyRotatedAt)
+	if err != nil {
+		return "", err
+	}
+	return tenant.ID, nil
+}
+
+// PutTenant stores tenant under its own ID, replacing any tenant with that ID.
+func (s *SQLStore) PutTenant(ctx context.Context, tenant utils.Tenant) error {
+	_, err := s.exec(ctx, `INSERT INTO tenants (id, name, key_hash, created_at, key_rotated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			key_hash = excluded.key_hash,
+			created_at = excluded.created_at,
+			key_rotated_at = excluded.key_rotated_at`,
+		tenant.ID, tenant.Name, tenant.KeyHash, tenant.CreatedAt, tenant.KeyRotatedAt)
+	return err
+}
+
+// GetTenantByID retrieves a tenant by its ID.
+func (s *SQLStore) GetTenantByID(ctx context.Context, id string) (*utils.Tenant, error) {
+	row := s.queryRow(ctx, `SELECT id, name, key_hash, created_at, key_rotated_at FROM tenants WHERE id = ?`, id)
+	tenant, err := scanTenant(row)
+	if err != nil {
+		return nil, translateSQLErr(err)
+	}
+	return tenant, nil
+}
+
+// GetTenantByKeyHash finds the tenant whose API key hashes to keyHash. Served by the unique key_hash index.
+func (s *SQLStore) GetTenantByKeyHash(ctx context.Context, keyHash string) (*utils.Tenant, error) {
+	row := s.queryRow(ctx, `SELECT id, name, key_hash, created_at, key_rotated_at FROM tenants WHERE key_hash = ?`, keyHash)
+	tenant, err := scanTenant(row)
+	if err != nil {
+		return nil, translateSQLErr(err)
+	}
+	return tenant, nil
+}
+
+// GetAllTenants returns every tenant ordered by ID.
+func (s *SQLStore) GetAllTenants(ctx context.Context) ([]utils.Tenant, error) {
+	rows, err := s.query(ctx, `SELECT id, name, key_hash, created_at, key_rotated_at FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := []utils.Tenant{}
+	for rows.Next() {
+		tenant, scanErr := scanTenant(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		tenants = append(tenants, *tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// UpdateTenant overwrites an existing tenant. Returns ErrNotFound if it does not exist.
+func (s *SQLStore) UpdateTenant(ctx context.Context, tenant utils.Tenant) error {
+	result, err := s.exec(ctx, `UPDATE tenants SET name = ?, key_hash = ?, created_at = ?, key_rotated_at = ? WHERE id = ?`,
+		tenant.Name, tenant.KeyHash, tenant.CreatedAt, tenant.KeyRotatedAt, tenant.ID)
+	if err != nil {
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteTenant removes a tenant. Deleting a missing ID is not an error.
+func (s *SQLStore) DeleteTenant(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, `DELETE FROM tenants WHERE id = ?`, id)
+	return err
+}
+
+// --- Caches ---
+
+// GetCacheEntry decodes the entry stored under key into dest.
+func (s *SQLStore) GetCacheEntry(ctx context.Context, collection, key string, dest interface{}) error {
+	var data string
+	var storedAt int64
+	err := s.queryRow(ctx, `SELECT data, stored_at FROM cache_entries
+		WHERE collection = ? AND cache_key = ?`, collection, key).Scan(&data, &storedAt)
+	if err != nil {
+		return translateSQLErr(err)
+	}
+
+	entry := jsonCacheEntry{Timestamp: time.Unix(0, storedAt), Data: json.RawMessage(data)}
+	return entry.decodeInto(dest)
+}
+
+// SetCacheEntry stores data together with the current time under key.
+func (s *SQLStore) SetCacheEntry(ctx context.Context, collection, key string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, `INSERT INTO cache_entries (collection, cache_key, data, stored_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (collection, cache_key) DO UPDATE SET
+			data = excluded.data,
+			stored_at = excluded.stored_at`,
+		collection, key, string(raw), time.Now().UnixNano())
+	return err
+}
+
+// PurgeCacheEntries deletes every entry in collection stored before olderThan, batchSize rows per
+// statement so that no single delete holds its locks for long. Served by the (collection, stored_at)
+// index. Returns the number of entries removed.
+func (s *SQLStore) PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time, batchSize int) (int, error) {
+	purged := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		result, err := s.exec(ctx, `DELETE FROM cache_entries WHERE collection = ? AND cache_key IN (
+			SELECT cache_key FROM cache_entries WHERE collection = ? AND stored_at < ? LIMIT ?)`,
+			collection, collection, olderThan.UnixNano(), batchSize)
+		if err != nil {
+			return purged, err
+		}
+		removed, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		purged += int(removed)
+		if int(removed) < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// DeleteCacheEntry removes a single entry. Deleting a missing key is not an error.
+func (s *SQLStore) DeleteCacheEntry(ctx context.Context, collection, key string) error {
+	_, err := s.exec(ctx, `DELETE FROM cache_entries WHERE collection = ? AND cache_key = ?`, collection, key)
+	return err
+}
+
+// GetCacheEntries returns up to limit entries in collection whose keys sort after the key after, ordered by key.
+func (s *SQLStore) GetCacheEntries(ctx context.Context, collection, after string, limit int) ([]utils.CacheRecord, error) {
+	rows, err := s.query(ctx, `SELECT cache_key, data, stored_at FROM cache_entries
+		WHERE collection = ? AND cache_key > ? ORDER BY cache_key LIMIT ?`, collection, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []utils.CacheRecord{}
+	for rows.Next() {
+		var key, data string
+		var storedAt int64
+		if scanErr := rows.Scan(&key, &data, &storedAt); scanErr != nil {
+			return nil, scanErr
+		}
+		entries = append(entries, utils.CacheRecord{Key: key, Timestamp: time.Unix(0, storedAt), Data: json.RawMessage(data)})
+	}
+	return entries, rows.Err()
+}
+
+// PutCacheEntry stores a raw entry, keeping its original timestamp.
+func (s *SQLStore) PutCacheEntry(ctx context.Context, collection string, entry utils.CacheRecord) error {
+	_, err := s.exec(ctx, `INSERT INTO cache_entries (collection, cache_key, data, stored_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (collection, cache_key) DO UPDATE SET
+			data = excluded.data,
+			stored_at = excluded.stored_at`,
+		collection, entry.Key, string(entry.Data), entry.Timestamp.UnixNano())
+	return err
+}
+
+// --- Lifecycle ---
+
+// Ping checks that the database is reachable.
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close releases the underlying connection pool.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSQLiteStore opens a fresh SQLite database in a temporary directory.
+func newTestSQLiteStore(t *testing.T) (*SQLStore, string) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLStore(context.Background(), utils.StoreSQLite, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store, dsn
+}
+
+func TestSQLStore_MigrationsAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+	store, dsn := newTestSQLiteStore(t)
+
+	version, err := store.SchemaVersion(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, sqlMigrations[len(sqlMigrations)-1].Version, version)
+
+	// Reopening an already migrated database must not fail or re-apply anything
+	reopened, err := NewSQLStore(ctx, utils.StoreSQLite, dsn)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	again, err := reopened.SchemaVersion(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, version, again)
+}
+
+func TestSQLStore_DashboardLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestSQLiteStore(t)
+
+	id, err := store.SaveDashboardConfig(ctx, utils.DashboardConfig{
+		Country:    "Norway",
+		ISOCode:    "NO",
+		Features:   utils.FeatureConfig{Capital: true, TargetCurrencies: []string{"EUR", "USD"}},
+		LastChange: "20250407 15:30",
+	})
+	require.NoError(t, err)
+
+	config, err := store.GetDashboardConfigByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Norway", config.Country)
+	assert.True(t, config.Features.Capital)
+	assert.Equal(t, []string{"EUR", "USD"}, config.Features.TargetCurrencies)
+
+	config.Country = "Sweden"
+	config.DeletedAt = "20250408 09:00"
+	assert.NoError(t, store.UpdateDashboardConfig(ctx, *config))
+
+	all, err := store.GetAllDashboardConfigs(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, "Sweden", all[0].Country)
+	assert.Equal(t, "20250408 09:00", all[0].DeletedAt)
+
+	assert.NoError(t, store.DeleteDashboardConfig(ctx, id))
+	_, err = store.GetDashboardConfigByID(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLStore_MatchingWebhooks(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestSQLiteStore(t)
+
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://a", Event: utils.EventInvoke, Country: "NO"})
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://b", Event: utils.EventInvoke, Country: ""})
+	_, _ = store.SaveWebhook(ctx, utils.Webhook{URL: "http://c", Event: utils.EventInvoke, Country: "SE"})
+	deleteID, _ := store.SaveWebhook(ctx, utils.Webhook{URL: "http://d", Event: utils.EventDelete, Country: "NO"})
+
+	hooks, err := store.GetMatchingWebhooks(ctx, utils.EventInvoke, "NO")
+	assert.NoError(t, err)
+	assert.Len(t, hooks, 2)
+	assert.Equal(t, 4, store.CountWebhooks(ctx))
+
+	hook, err := store.GetWebhookByID(ctx, deleteID)
+	require.NoError(t, err)
+	assert.Equal(t, "http://d", hook.URL)
+
+	assert.NoError(t, store.DeleteWebhook(ctx, deleteID))
+	_, err = store.GetWebhookByID(ctx, deleteID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLStore_CacheEntries(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestSQLiteStore(t)
+
+	rates := map[string]float64{"EUR": 0.087, "USD": 0.092}
+	require.NoError(t, store.SetCacheEntry(ctx, utils.CurrencyCacheCollection, "NOK_EUR_USD", rates))
+
+	var entry struct {
+		Timestamp time.Time
+		Data      map[string]float64
+	}
+	require.NoError(t, store.GetCacheEntry(ctx, utils.CurrencyCacheCollection, "NOK_EUR_USD", &entry))
+	assert.Equal(t, rates, entry.Data)
+	assert.WithinDuration(t, time.Now(), entry.Timestamp, time.Minute)
+
+	// Same key in another collection is a separate entry
+	err := store.GetCacheEntry(ctx, utils.CountryCacheCollection, "NOK_EUR_USD", &entry)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	purged, err := store.PurgeCacheEntries(ctx, utils.CurrencyCacheCollection, time.Now().Add(-time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = store.PurgeCacheEntries(ctx, utils.CurrencyCacheCollection, time.Now().Add(time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestSQLStore_RebindPostgres(t *testing.T) {
+	store := &SQLStore{dialect: utils.StorePostgres}
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b IN ($2, '')",
+		store.rebind("SELECT * FROM t WHERE a = ? AND b IN (?, '')"))
+
+	sqlite := &SQLStore{dialect: utils.StoreSQLite}
+	assert.Equal(t, "a = ?", sqlite.rebind("a = ?"))
+}
+
+func TestSQLStore_ConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestSQLiteStore(t)
+
+	config := utils.DashboardConfig{ID: "cas", Country: "Norway"}
+	assert.NoError(t, store.UpdateDashboardConfigIfVersion(ctx, config, 0))
+	assert.ErrorIs(t, store.UpdateDashboardConfigIfVersion(ctx, config, 0), ErrVersionConflict)
+
+	stored, err := store.GetDashboardConfigByID(ctx, "cas")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.Version)
+
+	assert.NoError(t, store.UpdateDashboardConfigIfVersion(ctx, config, 1))
+	assert.ErrorIs(t, store.UpdateDashboardConfigIfVersion(ctx, config, 1), ErrVersionConflict)
+	assert.ErrorIs(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 1), ErrVersionConflict)
+	assert.NoError(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 2))
+	assert.ErrorIs(t, store.DeleteDashboardConfigIfVersion(ctx, "cas", 2), ErrNotFound)
+}
+
+func TestSQLStore_Revisions(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestSQLiteStore(t)
+
+	revision := utils.DashboardRevision{
+		DashboardID: "d1",
+		Revision:    1,
+		Event:       utils.EventRegister,
+		Timestamp:   "20250407 15:30",
+		Config:      utils.DashboardConfig{ID: "d1", Country: "Norway", Version: 1},
+		Diff:        []utils.FieldChange{{Field: utils.KeyCountry, New: "Norway"}},
+	}
+	require.NoError(t, store.SaveRevision(ctx, revision))
+	assert.ErrorIs(t, store.SaveRevision(ctx, revision), ErrVersionConflict)
+
+	found, err := store.GetRevision(ctx, "d1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, revision, *found)
+
+	_, err = store.GetRevision(ctx, "d1", 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	revisions, err := store.GetRevisions(ctx, "d1")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+}
+
+func TestSQLStore_PagingPastUndecodableRow(t *testing.T) {
+	store, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+	for _, id := range []string{"a", "c", "d"} {
+		require.NoError(t, store.UpdateDashboardConfig(ctx, utils.DashboardConfig{ID: id, ISOCode: "NO"}))
+	}
+	// The last row of the first page cannot be decoded
+	seedLegacyDashboard(t, store, utils.DashboardConfig{ID: "b"}, `{"capital": "yes"}`)
+
+	opts := ListOptions{Sort: utils.KeyID, Limit: 2}
+	first, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: opts})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, configIDs(first.Items))
+	assert.Equal(t, 3, first.Total, "the skipped row is not counted")
+	require.NotNil(t, first.Next, "paging carries on past the undecodable row")
+	assert.Equal(t, "b", first.Next.ID)
+
+	opts.After = first.Next
+	second, err := store.QueryDashboardConfigs(ctx, DashboardQuery{ListOptions: opts})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, configIDs(second.Items))
+	assert.Nil(t, second.Next)
+}
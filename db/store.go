@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrNotFound is returned by every store implementation when a document does not exist.
+var ErrNotFound = errors.New(utils.ErrDocumentNotFound)
+
+// ErrVersionConflict is returned by conditional writes when the stored version
+// no longer matches the version the caller based its change on.
+var ErrVersionConflict = errors.New(utils.ErrVersionConflict)
+
+// PurgeError reports the entries a cache purge could not delete. The rest of the purge went ahead.
+type PurgeError struct {
+	Collection string
+	Failures   []utils.CachePurgeFailure
+}
+
+// Error summarizes how many entries failed.
+func (e *PurgeError) Error() string {
+	return fmt.Sprintf(utils.ErrPurgeFailures, len(e.Failures), e.Collection)
+}
+
+// purgeErrorOrNil returns e only if some entry failed, so that a clean purge reports no error.
+func purgeErrorOrNil(e *PurgeError) error {
+	if len(e.Failures) == 0 {
+		return nil
+	}
+	return e
+}
+
+// DashboardRepository persists dashboard configurations.
+type DashboardRepository interface {
+	SaveDashboardConfig(ctx context.Context, config utils.DashboardConfig) (string, error)
+	GetDashboardConfigByID(ctx context.Context, id string) (*utils.DashboardConfig, error)
+	GetAllDashboardConfigs(ctx context.Context) ([]utils.DashboardConfig, error)
+	UpdateDashboardConfig(ctx context.Context, config utils.DashboardConfig) error
+	DeleteDashboardConfig(ctx context.Context, id string) error
+	// QueryDashboardConfigs returns one filtered, sorted page of configurations.
+	QueryDashboardConfigs(ctx context.Context, query DashboardQuery) (*Page[utils.DashboardConfig], error)
+
+	// UpdateDashboardConfigIfVersion atomically writes config with Version set to expected+1,
+	// but only if the stored version equals expected. A missing document counts as version 0.
+	UpdateDashboardConfigIfVersion(ctx context.Context, config utils.DashboardConfig, expected int64) error
+	// DeleteDashboardConfigIfVersion atomically deletes the document only if its version equals expected.
+	DeleteDashboardConfigIfVersion(ctx context.Context, id string, expected int64) error
+
+	// MigrateDashboardConfigs upgrades every stored configuration to DashboardSchemaVersion and reports
+	// documents that fail to decode or upgrade. With dryRun set nothing is written.
+	MigrateDashboardConfigs(ctx context.Context, dryRun bool) (*utils.MigrationReport, error)
+}
+
+// RevisionRepository persists the immutable revision history of dashboard configurations.
+type RevisionRepository interface {
+	SaveRevision(ctx context.Context, revision utils.DashboardRevision) error
+	GetRevisions(ctx context.Context, dashboardID string) ([]utils.DashboardRevision, error)
+	GetRevision(ctx context.Context, dashboardID string, revision int64) (*utils.DashboardRevision, error)
+	// DeleteRevisions removes a dashboard's whole history, for restores that replace all data.
+	DeleteRevisions(ctx context.Context, dashboardID string) error
+}
+
+// WebhookRepository persists webhook registrations.
+type WebhookRepository interface {
+	SaveWebhook(ctx context.Context, webhook utils.Webhook) (string, error)
+	GetWebhookByID(ctx context.Context, id string) (*utils.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]utils.Webhook, error)
+	GetMatchingWebhooks(ctx context.Context, event, country string) ([]utils.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// QueryWebhooks returns one filtered, sorted page of webhooks.
+	QueryWebhooks(ctx context.Context, query WebhookQuery) (*Page[utils.Webhook], error)
+	CountWebhooks(ctx context.Context) int
+	// PutWebhook stores webhook under its own ID, replacing any webhook with that ID. Used by restores.
+	PutWebhook(ctx context.Context, webhook utils.Webhook) error
+}
+
+// TenantRepository persists tenants and the hashes of their API keys.
+type TenantRepository interface {
+	SaveTenant(ctx context.Context, tenant utils.Tenant) (string, error)
+	GetTenantByID(ctx context.Context, id string) (*utils.Tenant, error)
+	// GetTenantByKeyHash looks up the tenant owning an API key, or returns ErrNotFound.
+	GetTenantByKeyHash(ctx context.Context, keyHash string) (*utils.Tenant, error)
+	GetAllTenants(ctx context.Context) ([]utils.Tenant, error)
+	UpdateTenant(ctx context.Context, tenant utils.Tenant) error
+	DeleteTenant(ctx context.Context, id string) error
+	// PutTenant stores tenant under its own ID, replacing any tenant with that ID. Used by restores.
+	PutTenant(ctx context.Context, tenant utils.Tenant) error
+}
+
+// CacheStore persists timestamped cache entries grouped into named collections.
+// Entries are written as a "data" + "timestamp" pair and read back into a struct
+// exposing matching Data and Timestamp fields.
+type CacheStore interface {
+	GetCacheEntry(ctx context.Context, collection, key string, dest interface{}) error
+	SetCacheEntry(ctx context.Context, collection, key string, data interface{}) error
+	// PurgeCacheEntries deletes the entries stored before olderThan, batchSize at a time, and returns how
+	// many were deleted. Entries that fail are reported in a *PurgeError once the rest are done; any
+	// other error, including a cancelled ctx, means the purge stopped early.
+	PurgeCacheEntries(ctx context.Context, collection string, olderThan time.Time, batchSize int) (int, error)
+	// DeleteCacheEntry removes a single entry. Deleting a missing key is not an error.
+	DeleteCacheEntry(ctx context.Context, collection, key string) error
+	// GetCacheEntries returns up to limit raw entries in collection whose keys sort after the key
+	// after, ordered by key. An empty after starts at the first entry. Used by backups.
+	GetCacheEntries(ctx context.Context, collection, after string, limit int) ([]utils.CacheRecord, error)
+	// PutCacheEntry stores a raw entry, keeping its original timestamp. Used by restores.
+	PutCacheEntry(ctx context.Context, collection string, entry utils.CacheRecord) error
+}
+
+// Store bundles every repository the service needs behind a single backend.
+type Store interface {
+	DashboardRepository
+	RevisionRepository
+	WebhookRepository
+	TenantRepository
+	CacheStore
+
+	// Ping checks that the backend is reachable.
+	Ping(ctx context.Context) error
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// activeStore is the backend used by the package-level helper functions.
+var activeStore Store
+
+// UseStore installs the backend used by all package-level repository functions.
+func UseStore(store Store) {
+	activeStore = store
+}
+
+// CurrentStore returns the active backend or panics if none has been configured.
+func CurrentStore() Store {
+	if activeStore == nil {
+		panic(utils.ErrStoreNotInitialized)
+	}
+	return activeStore
+}
+
+// IsStoreInitialized reports whether a backend has been configured.
+func IsStoreInitialized() bool {
+	return activeStore != nil
+}
+
+// CloseStore releases the active backend, if one is configured.
+func CloseStore() error {
+	if activeStore != nil {
+		return activeStore.Close()
+	}
+	return nil
+}
+
+// PingStore checks connectivity of the active backend.
+// Returns nil if successful, or an error otherwise.
+func PingStore(ctx context.Context) error {
+	return CurrentStore().Ping(ctx)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// SaveTenant stores a new tenant in the active store and returns the generated ID.
+func SaveTenant(ctx context.Context, tenant utils.Tenant) (string, error) {
+	return CurrentStore().SaveTenant(ctx, tenant)
+}
+
+// GetTenantByID retrieves a tenant by its ID, or returns ErrNotFound.
+func GetTenantByID(ctx context.Context, id string) (*utils.Tenant, error) {
+	return CurrentStore().GetTenantByID(ctx, id)
+}
+
+// GetTenantByKeyHash retrieves the tenant whose API key hashes to keyHash, or returns ErrNotFound.
+func GetTenantByKeyHash(ctx context.Context, keyHash string) (*utils.Tenant, error) {
+	return CurrentStore().GetTenantByKeyHash(ctx, keyHash)
+}
+
+// GetAllTenants returns every tenant ordered by ID.
+func GetAllTenants(ctx context.Context) ([]utils.Tenant, error) {
+	return CurrentStore().GetAllTenants(ctx)
+}
+
+// UpdateTenant overwrites the tenant stored under tenant.ID.
+func UpdateTenant(ctx context.Context, tenant utils.Tenant) error {
+	return CurrentStore().UpdateTenant(ctx, tenant)
+}
+
+// DeleteTenant removes a tenant. Deleting a missing ID is not an error.
+func DeleteTenant(ctx context.Context, id string) error {
+	return CurrentStore().DeleteTenant(ctx, id)
+}
+
+// PutTenant stores a tenant under its own ID, replacing any tenant with that ID.
+func PutTenant(ctx context.Context, tenant utils.Tenant) error {
+	return CurrentStore().PutTenant(ctx, tenant)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepository(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			id, err := store.SaveTenant(ctx, utils.Tenant{Name: "acme", KeyHash: "hash-1", CreatedAt: "20250101 10:00"})
+			require.NoError(t, err)
+
+			byKey, err := store.GetTenantByKeyHash(ctx, "hash-1")
+			require.NoError(t, err)
+			assert.Equal(t, id, byKey.ID)
+			assert.Equal(t, "acme", byKey.Name)
+
+			byKey.KeyHash, byKey.KeyRotatedAt = "hash-2", "20250102 10:00"
+			require.NoError(t, store.UpdateTenant(ctx, *byKey))
+			_, err = store.GetTenantByKeyHash(ctx, "hash-1")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			byID, err := store.GetTenantByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "hash-2", byID.KeyHash)
+
+			all, err := store.GetAllTenants(ctx)
+			require.NoError(t, err)
+			assert.Len(t, all, 1)
+
+			require.NoError(t, store.DeleteTenant(ctx, id))
+			_, err = store.GetTenantByID(ctx, id)
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, store.UpdateTenant(ctx, *byID), ErrNotFound)
+		})
+	}
+}
+
+func TestQuery_TenantScoping(t *testing.T) {
+	for name, store := range listingStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.UpdateDashboardConfig(ctx, utils.DashboardConfig{ID: "a", Country: "Norway", TenantID: "t1"}))
+			require.NoError(t, store.UpdateDashboardConfig(ctx, utils.DashboardConfig{ID: "b", Country: "Sweden", TenantID: "t2"}))
+			require.NoError(t, store.UpdateDashboardConfig(ctx, utils.DashboardConfig{ID: "c", Country: "Denmark"}))
+			_, err := store.SaveWebhook(ctx, utils.Webhook{URL: "http://a", Event: utils.EventInvoke, TenantID: "t1"})
+			require.NoError(t, err)
+			_, err = store.SaveWebhook(ctx, utils.Webhook{URL: "http://b", Event: utils.EventInvoke, TenantID: "t2"})
+			require.NoError(t, err)
+
+			page, err := store.QueryDashboardConfigs(ctx, DashboardQuery{TenantID: "t1"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a"}, configIDs(page.Items))
+			assert.Equal(t, "t1", page.Items[0].TenantID)
+
+			page, err = store.QueryDashboardConfigs(ctx, DashboardQuery{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"c"}, configIDs(page.Items))
+
+			page, err = store.QueryDashboardConfigs(ctx, DashboardQuery{AllTenants: true})
+			require.NoError(t, err)
+			assert.Equal(t, 3, page.Total)
+
+			hooks, err := store.QueryWebhooks(ctx, WebhookQuery{TenantID: "t2"})
+			require.NoError(t, err)
+			require.Len(t, hooks.Items, 1)
+			assert.Equal(t, "http://b", hooks.Items[0].URL)
+			assert.Equal(t, "t2", hooks.Items[0].TenantID)
+		})
+	}
+}
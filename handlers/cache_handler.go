@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/services"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// GetCacheStats handles GET requests for the lookup counters of every cache collection.
+func GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	utils.WriteSuccessResponse(w, services.GetCacheStats(), http.StatusOK)
+}
+
+// PurgeCaches handles POST requests that run a cache purge cycle immediately.
+func PurgeCaches(w http.ResponseWriter, r *http.Request) {
+	utils.WriteSuccessResponse(w, services.PurgeCaches(r.Context()), http.StatusOK)
+}
+
+// ListCacheEntries handles GET requests for the entries of a cache collection,
+// optionally only those whose keys start with the prefix query parameter.
+func ListCacheEntries(w http.ResponseWriter, r *http.Request, collection string) {
+	entries, listErr := services.ListCacheEntries(r.Context(), collection, r.URL.Query().Get(utils.QueryPrefix))
+	if listErr != nil {
+		writeCacheAdminError(w, listErr)
+		return
+	}
+	utils.WriteSuccessResponse(w, entries, http.StatusOK)
+}
+
+// GetCacheEntry handles GET requests for a single cache entry with its data.
+func GetCacheEntry(w http.ResponseWriter, r *http.Request, collection, key string) {
+	entry, fetchErr := services.GetCacheEntry(r.Context(), collection, key)
+	if fetchErr != nil {
+		writeCacheAdminError(w, fetchErr)
+		return
+	}
+	utils.WriteSuccessResponse(w, entry, http.StatusOK)
+}
+
+// InvalidateCacheEntry handles DELETE requests for a single cache entry.
+func InvalidateCacheEntry(w http.ResponseWriter, r *http.Request, collection, key string) {
+	if deleteErr := services.InvalidateCacheEntry(r.Context(), collection, key); deleteErr != nil {
+		writeCacheAdminError(w, deleteErr)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InvalidateCacheCollection handles DELETE requests that remove the entries of a cache collection
+// whose keys start with the prefix query parameter, or the whole collection without one.
+func InvalidateCacheCollection(w http.ResponseWriter, r *http.Request, collection string) {
+	result, deleteErr := services.InvalidateCachePrefix(r.Context(), collection, r.URL.Query().Get(utils.QueryPrefix))
+	if deleteErr != nil {
+		writeCacheAdminError(w, deleteErr)
+		return
+	}
+	utils.WriteSuccessResponse(w, result, http.StatusOK)
+}
+
+// writeCacheAdminError maps unknown collections and missing entries to 404 and anything else to 500.
+func writeCacheAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownCacheCollection):
+		utils.WriteErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, db.ErrNotFound):
+		utils.WriteErrorResponse(w, utils.MsgCacheEntryNotFound+err.Error(), http.StatusNotFound)
+	default:
+		utils.WriteErrorResponse(w, utils.MsgCacheAdminFail+err.Error(), http.StatusInternalServerError)
+	}
+}
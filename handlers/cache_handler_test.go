@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheAdministration(t *testing.T) {
+	ctx := context.Background()
+	cache.ResetLocalCache()
+	t.Cleanup(cache.ResetLocalCache)
+	require.NoError(t, cache.SaveCountryInfoToCache(ctx, "FI", utils.CountryInfoResponse{Capital: []string{"Helsinki"}}))
+	require.NoError(t, cache.SaveCountryInfoToCache(ctx, "FR", utils.CountryInfoResponse{Capital: []string{"Paris"}}))
+
+	// Listing by prefix
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/country_cache?prefix=F", nil)
+	rr := httptest.NewRecorder()
+	ListCacheEntries(rr, req, utils.CountryCacheCollection)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var entries []utils.CacheEntrySummary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "FI", entries[0].Key)
+
+	// Inspecting one entry
+	rr = httptest.NewRecorder()
+	GetCacheEntry(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/country_cache/FR", nil), utils.CountryCacheCollection, "FR")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Paris")
+
+	// Invalidating it
+	rr = httptest.NewRecorder()
+	InvalidateCacheEntry(rr, httptest.NewRequest(http.MethodDelete, "/dashboard/v1/cache/country_cache/FR", nil), utils.CountryCacheCollection, "FR")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	GetCacheEntry(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/country_cache/FR", nil), utils.CountryCacheCollection, "FR")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Invalidating by prefix
+	rr = httptest.NewRecorder()
+	InvalidateCacheCollection(rr, httptest.NewRequest(http.MethodDelete, "/dashboard/v1/cache/country_cache?prefix=F", nil), utils.CountryCacheCollection)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var result utils.CacheInvalidation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Removed)
+
+	// Unknown collections are rejected
+	rr = httptest.NewRecorder()
+	ListCacheEntries(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/dashboards", nil), "dashboards")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCacheStats_RequiresAdmin(t *testing.T) {
+	withAdminKey(t, "admin-secret")
+
+	rr := httptest.NewRecorder()
+	RequireAdmin(GetCacheStats)(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/cache/", nil)
+	req.Header.Set(utils.HeaderAPIKey, "admin-secret")
+	rr = httptest.NewRecorder()
+	RequireAdmin(GetCacheStats)(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var stats []utils.CacheCollectionStats
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	assert.Len(t, stats, len(utils.CacheCollections))
+}
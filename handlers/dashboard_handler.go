@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/services"
 	"github.com/amundfpl/Assignment-2/utils"
 )
@@ -25,7 +28,15 @@ func NewDashboardHandler(svc services.DashboardService) http.HandlerFunc {
 		}
 
 		// Fetch populated dashboard data from the service
-		dashboard, fetchErr := svc.GetPopulatedDashboardByID(id)
+		dashboard, fetchErr := svc.GetPopulatedDashboardByID(r.Context(), id)
+		if errors.Is(fetchErr, db.ErrNotFound) {
+			utils.WriteErrorResponse(w, utils.MsgDashboardNotFound+fetchErr.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(fetchErr, context.DeadlineExceeded) {
+			utils.WriteErrorResponse(w, utils.ErrMsgDashboardTimeout+fetchErr.Error(), http.StatusGatewayTimeout)
+			return
+		}
 		if fetchErr != nil {
 			utils.WriteErrorResponse(w, utils.ErrMsgDashboardFetchFailed+fetchErr.Error(), http.StatusInternalServerError)
 			return
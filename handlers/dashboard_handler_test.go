@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/services"
 	"github.com/amundfpl/Assignment-2/testsetup"
@@ -10,31 +11,32 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // TestMain initializes the Firebase test client once before all tests run
 func TestMain(m *testing.M) {
-	testsetup.InitTestFirebase()
+	testsetup.InitTestStore()
 	m.Run()
 }
 
 // TestHandleGetPopulatedDashboard_RealService verifies that the GET /dashboards/{id} handler returns enriched data
 func TestHandleGetPopulatedDashboard_RealService(t *testing.T) {
 	testID := "dashboard-test-123"
-	client := db.GetClient()
 	ctx := context.Background()
 
-	_, err := client.Collection("dashboard_configs").Doc(testID).Set(ctx, map[string]interface{}{
-		"country": "Mockland",
-		"isoCode": "NO",
-		"features": map[string]interface{}{
-			"capital":          true,
-			"coordinates":      true,
-			"population":       true,
-			"area":             true,
-			"temperature":      true,
-			"precipitation":    true,
-			"targetCurrencies": []string{"USD", "EUR"},
+	err := db.UpdateDashboardConfig(ctx, utils.DashboardConfig{
+		ID:      testID,
+		Country: "Mockland",
+		ISOCode: "NO",
+		Features: utils.FeatureConfig{
+			Capital:          true,
+			Coordinates:      true,
+			Population:       true,
+			Area:             true,
+			Temperature:      true,
+			Precipitation:    true,
+			TargetCurrencies: []string{"USD", "EUR"},
 		},
 	})
 	if err != nil {
@@ -119,3 +121,28 @@ func TestHandleGetPopulatedDashboard_RealService(t *testing.T) {
 		t.Errorf("Expected USD rate to be 1.23, got: %f", response.Features.TargetCurrencies["USD"])
 	}
 }
+
+// hangingDashboardService blocks until the request's context ends.
+type hangingDashboardService struct{ services.RealDashboardService }
+
+func (hangingDashboardService) GetPopulatedDashboardByID(ctx context.Context, id string) (*utils.PopulatedDashboardResponse, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("%s: %w", utils.ErrFetchConfig, ctx.Err())
+}
+
+// TestDashboardHandler_DeadlineExceeded verifies that a request running past its deadline gets 504 Gateway Timeout
+func TestDashboardHandler_DeadlineExceeded(t *testing.T) {
+	handler := WithDeadline(20*time.Millisecond, NewDashboardHandler(hangingDashboardService{}))
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/dashboards/slow", nil)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler(rec, req)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504 Gateway Timeout, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the deadline to end the request, took %v", time.Since(start))
+	}
+}
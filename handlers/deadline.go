@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithDeadline gives the request's context a deadline of timeout from now. Upstream calls and store
+// reads made for the request are cancelled when it passes, or as soon as the client disconnects.
+func WithDeadline(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
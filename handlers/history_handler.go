@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/services"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// GetRegistrationHistory handles GET /registrations/{id}/history and lists every revision, oldest first.
+func GetRegistrationHistory(w http.ResponseWriter, r *http.Request, id string) {
+	revisions, err := services.GetDashboardHistory(r.Context(), id)
+	if err != nil {
+		writeRevisionLookupError(w, utils.MsgHistoryFetchFail, err)
+		return
+	}
+	utils.WriteSuccessResponse(w, revisions, http.StatusOK)
+}
+
+// GetRegistrationRevision handles GET /registrations/{id}/history/{rev}.
+func GetRegistrationRevision(w http.ResponseWriter, r *http.Request, id, rev string) {
+	revision, ok := parseRevision(w, rev)
+	if !ok {
+		return
+	}
+
+	found, err := services.GetDashboardRevision(r.Context(), id, revision)
+	if err != nil {
+		writeRevisionLookupError(w, utils.MsgHistoryFetchFail, err)
+		return
+	}
+	utils.WriteSuccessResponse(w, found, http.StatusOK)
+}
+
+// RollbackRegistration handles POST /registrations/{id}/rollback/{rev}, restoring a past revision
+// as a new write. Honours If-Match like PUT and PATCH.
+func RollbackRegistration(w http.ResponseWriter, r *http.Request, id, rev string) {
+	if !utils.EnforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	revision, ok := parseRevision(w, rev)
+	if !ok {
+		return
+	}
+
+	result, err := services.RollbackDashboardConfig(r.Context(), id, revision, r.Header.Get(utils.HeaderIfMatch))
+	if err != nil {
+		writeRegistrationWriteError(w, utils.MsgRollbackFail, err)
+		return
+	}
+
+	utils.SetETagHeader(w, result[utils.KeyVersion])
+	utils.WriteSuccessResponse(w, result, http.StatusOK)
+}
+
+// parseRevision converts a revision path segment to a number, writing 400 if it is not a positive integer.
+func parseRevision(w http.ResponseWriter, rev string) (int64, bool) {
+	revision, err := strconv.ParseInt(rev, 10, 64)
+	if err != nil || revision < 1 {
+		utils.WriteErrorResponse(w, utils.MsgInvalidRevision+rev, http.StatusBadRequest)
+		return 0, false
+	}
+	return revision, true
+}
+
+// writeRevisionLookupError maps a missing dashboard or revision to 404 and anything else to 500.
+func writeRevisionLookupError(w http.ResponseWriter, failMsg string, err error) {
+	if errors.Is(err, db.ErrNotFound) {
+		utils.WriteErrorResponse(w, utils.MsgRevisionNotFound+err.Error(), http.StatusNotFound)
+		return
+	}
+	utils.WriteErrorResponse(w, failMsg+err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/services"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationHistoryAndRollback(t *testing.T) {
+	id := createTestDashboard(t)
+
+	patch := httptest.NewRequest(http.MethodPatch, "/dashboard/v1/registrations/"+id, strings.NewReader(`{"country": "Changed"}`))
+	rr := httptest.NewRecorder()
+	PatchDashboardRegistration(rr, patch, id)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	GetRegistrationHistory(rr, httptest.NewRequest(http.MethodGet, "/", nil), id)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var history []utils.DashboardRevision
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+	assert.Len(t, history, 2)
+
+	rr = httptest.NewRecorder()
+	GetRegistrationRevision(rr, httptest.NewRequest(http.MethodGet, "/", nil), id, "1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	GetRegistrationRevision(rr, httptest.NewRequest(http.MethodGet, "/", nil), id, "abc")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	RollbackRegistration(rr, httptest.NewRequest(http.MethodPost, "/", nil), id, "1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, utils.VersionETag(3), rr.Header().Get(utils.HeaderETag))
+
+	rr = httptest.NewRecorder()
+	RollbackRegistration(rr, httptest.NewRequest(http.MethodPost, "/", nil), id, "42")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRegistrationHistoryGoneAfterPurge(t *testing.T) {
+	ctx := context.Background()
+	id := createTestDashboard(t)
+
+	// Trash the registration long enough ago for the purge to remove it
+	config, err := db.GetDashboardConfigByID(ctx, id)
+	require.NoError(t, err)
+	config.DeletedAt = time.Now().Add(-48 * time.Hour).Format(utils.TimestampLayout)
+	config.Version++
+	require.NoError(t, db.UpdateDashboardConfig(ctx, *config))
+	_, err = services.PurgeDeletedRegistrations(ctx, 24*time.Hour)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	GetRegistrationHistory(rr, httptest.NewRequest(http.MethodGet, "/", nil), id)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	RollbackRegistration(rr, httptest.NewRequest(http.MethodPost, "/", nil), id, "1")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
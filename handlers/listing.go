@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// parseListOptions reads the limit, cursor and sort parameters shared by every listing endpoint.
+func parseListOptions(query url.Values) (db.ListOptions, error) {
+	opts := db.ListOptions{Sort: query.Get(utils.QuerySort), Limit: utils.DefaultPageLimit}
+
+	if rawLimit := query.Get(utils.QueryLimit); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > utils.MaxPageLimit {
+			return opts, fmt.Errorf(utils.ErrInvalidLimit, utils.MaxPageLimit, db.ErrInvalidQuery)
+		}
+		opts.Limit = limit
+	}
+
+	if rawCursor := query.Get(utils.QueryCursor); rawCursor != "" {
+		cursor, err := db.DecodeCursor(rawCursor)
+		if err != nil {
+			return opts, err
+		}
+		opts.After = cursor
+	}
+	return opts, nil
+}
+
+// parseDashboardQuery builds a registration listing query from the request's query string.
+func parseDashboardQuery(r *http.Request) (db.DashboardQuery, error) {
+	values := r.URL.Query()
+	opts, err := parseListOptions(values)
+	if err != nil {
+		return db.DashboardQuery{}, err
+	}
+
+	query := db.DashboardQuery{
+		ListOptions:    opts,
+		ISOCode:        values.Get(utils.QueryISOCode),
+		Country:        values.Get(utils.QueryCountry),
+		Features:       values[utils.QueryFeature],
+		LastChangeFrom: values.Get(utils.QueryLastChangeFrom),
+		LastChangeTo:   values.Get(utils.QueryLastChangeTo),
+		Deleted:        values.Get(utils.QueryDeleted) == "true",
+	}
+
+	for param, bound := range map[string]string{
+		utils.QueryLastChangeFrom: query.LastChangeFrom,
+		utils.QueryLastChangeTo:   query.LastChangeTo,
+	} {
+		if _, parseErr := time.Parse(utils.TimestampLayout, bound); bound != "" && parseErr != nil {
+			return query, fmt.Errorf(utils.ErrInvalidTimeFilter, param, utils.TimestampLayout, db.ErrInvalidQuery)
+		}
+	}
+	return query, nil
+}
+
+// parseWebhookQuery builds a webhook listing query from the request's query string.
+func parseWebhookQuery(r *http.Request) (db.WebhookQuery, error) {
+	values := r.URL.Query()
+	opts, err := parseListOptions(values)
+	if err != nil {
+		return db.WebhookQuery{}, err
+	}
+
+	return db.WebhookQuery{
+		ListOptions: opts,
+		Event:       values.Get(utils.QueryEvent),
+		Country:     values.Get(utils.QueryCountry),
+	}, nil
+}
+
+// newListResponse wraps a page in the listing envelope. The next link repeats the request's
+// path and query with the cursor replaced, so filters and sort carry over to the next page.
+func newListResponse[T any](r *http.Request, page *db.Page[T]) utils.ListResponse[T] {
+	response := utils.ListResponse[T]{Items: page.Items, Total: page.Total}
+	if page.Next != nil {
+		values := r.URL.Query()
+		values.Set(utils.QueryCursor, page.Next.Encode())
+		response.Next = r.URL.Path + "?" + values.Encode()
+	}
+	return response
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -42,8 +43,8 @@ func RegisterWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Save webhook in the database
-	id, saveErr := db.SaveWebhook(r.Context(), webhook)
+	// Save webhook in the database, owned by the calling tenant
+	id, saveErr := services.RegisterWebhook(r.Context(), webhook)
 	if saveErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgWebhookSaveFail, http.StatusInternalServerError)
 		return
@@ -64,6 +65,10 @@ func HandleDeleteWebhook(w http.ResponseWriter, r *http.Request, id string) {
 
 	// Attempt deletion
 	deleteErr := services.DeleteWebhook(r.Context(), id)
+	if errors.Is(deleteErr, db.ErrNotFound) {
+		utils.WriteErrorResponse(w, utils.MsgWebhookNotFound+deleteErr.Error(), http.StatusNotFound)
+		return
+	}
 	if deleteErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgWebhookDeleteFail+deleteErr.Error(), http.StatusInternalServerError)
 		return
@@ -73,18 +78,29 @@ func HandleDeleteWebhook(w http.ResponseWriter, r *http.Request, id string) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetAllWebhooks returns all registered webhooks in JSON format.
-// Calls the database layer to retrieve all stored webhook entries.
+// GetAllWebhooks returns registered webhooks one page at a time in JSON format.
+// Supports limit, cursor, sort and the event and country filters.
 func GetAllWebhooks(w http.ResponseWriter, r *http.Request) {
-	// Fetch all webhook entries from Firestore
-	webhooks, fetchErr := db.GetAllWebhooks(r.Context())
+	query, parseErr := parseWebhookQuery(r)
+	if parseErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgInvalidListQuery+parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one page of the tenant's webhook entries
+	query.TenantID = services.TenantFromContext(r.Context())
+	page, fetchErr := db.QueryWebhooks(r.Context(), query)
+	if errors.Is(fetchErr, db.ErrInvalidQuery) {
+		utils.WriteErrorResponse(w, utils.MsgInvalidListQuery+fetchErr.Error(), http.StatusBadRequest)
+		return
+	}
 	if fetchErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgWebhookFetchFail+fetchErr.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Respond with the full list
-	utils.WriteSuccessResponse(w, webhooks, http.StatusOK)
+	// Respond with the page wrapped with the total count and next link
+	utils.WriteSuccessResponse(w, newListResponse(r, page), http.StatusOK)
 }
 
 // GetWebhookByID fetches and returns a specific webhook by ID.
@@ -97,7 +113,7 @@ func GetWebhookByID(w http.ResponseWriter, r *http.Request, id string) {
 	}
 
 	// Fetch webhook with the given ID
-	webhook, fetchErr := db.GetWebhookByID(r.Context(), id)
+	webhook, fetchErr := services.GetWebhookByID(r.Context(), id)
 	if fetchErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgWebhookNotFound+fetchErr.Error(), http.StatusNotFound)
 		return
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/utils"
 	"net/http"
 	"net/http/httptest"
@@ -84,17 +86,32 @@ func TestRegisterWebhook_WrongMethod(t *testing.T) {
 // ---- HandleDeleteWebhook ----
 
 func TestHandleDeleteWebhook_Success(t *testing.T) {
+	id, err := db.SaveWebhook(context.Background(), utils.Webhook{URL: "http://example.com", Event: utils.EventInvoke})
+	if err != nil {
+		t.Fatalf("Failed to save test webhook: %v", err)
+	}
 
-	req := httptest.NewRequest(http.MethodDelete, "/dashboard/v1/notifications/test-id", nil)
+	req := httptest.NewRequest(http.MethodDelete, "/dashboard/v1/notifications/"+id, nil)
 	rr := httptest.NewRecorder()
 
-	HandleDeleteWebhook(rr, req, "test-id")
+	HandleDeleteWebhook(rr, req, id)
 
 	if rr.Code != http.StatusNoContent {
 		t.Errorf("Expected 204 No Content, got %d", rr.Code)
 	}
 }
 
+func TestHandleDeleteWebhook_NotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/dashboard/v1/notifications/test-id", nil)
+	rr := httptest.NewRecorder()
+
+	HandleDeleteWebhook(rr, req, "test-id")
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 Not Found, got %d", rr.Code)
+	}
+}
+
 func TestHandleDeleteWebhook_MissingID(t *testing.T) {
 	req := httptest.NewRequest(http.MethodDelete, "/dashboard/v1/notifications/", nil)
 	rr := httptest.NewRecorder()
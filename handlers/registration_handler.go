@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
@@ -25,45 +26,54 @@ func HandleRegisterDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call service to register dashboard
-	response, regErr := services.RegisterDashboardConfig(body)
+	response, regErr := services.RegisterDashboardConfig(r.Context(), body)
 	if regErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgRegisterDashboardFail+regErr.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Respond with 201 Created and dashboard ID
+	// Respond with 201 Created, dashboard ID and the initial ETag
+	utils.SetETagHeader(w, response[utils.KeyVersion])
 	utils.WriteSuccessResponse(w, response, http.StatusCreated)
 }
 
 // GetRegistrationByID handles GET requests to fetch a dashboard configuration by its ID.
 func GetRegistrationByID(w http.ResponseWriter, r *http.Request, id string) {
 	// Retrieve config by ID from Firestore
-	config, fetchErr := db.GetDashboardConfigByID(r.Context(), id)
+	config, fetchErr := services.GetActiveDashboardConfig(r.Context(), id)
 	if fetchErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgDashboardNotFound+fetchErr.Error(), http.StatusNotFound)
 		return
 	}
 
-	// Respond with the dashboard config
+	// Respond with the dashboard config, exposing its version as the ETag
+	w.Header().Set(utils.HeaderETag, utils.VersionETag(config.Version))
 	utils.WriteSuccessResponse(w, config, http.StatusOK)
 }
 
-// GetAllRegistrations handles GET requests to retrieve all dashboard configurations.
+// GetAllRegistrations handles GET requests to list dashboard configurations one page at a time.
+// Supports limit, cursor, sort and the isoCode, country, feature, lastChangeFrom/To and deleted filters.
 func GetAllRegistrations(w http.ResponseWriter, r *http.Request) {
-	// Fetch all dashboard configs from DB
-	dashboards, fetchErr := db.GetAllDashboardConfigs(r.Context())
-	if fetchErr != nil {
-		utils.WriteErrorResponse(w, utils.MsgRetrieveConfigsFail+fetchErr.Error(), http.StatusInternalServerError)
+	query, parseErr := parseDashboardQuery(r)
+	if parseErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgInvalidListQuery+parseErr.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Ensure empty array instead of null for JSON
-	if dashboards == nil {
-		dashboards = []utils.DashboardConfig{}
+	// Fetch one page of the tenant's live dashboard configs, or trashed ones with ?deleted=true
+	query.TenantID = services.TenantFromContext(r.Context())
+	page, fetchErr := db.QueryDashboardConfigs(r.Context(), query)
+	if errors.Is(fetchErr, db.ErrInvalidQuery) {
+		utils.WriteErrorResponse(w, utils.MsgInvalidListQuery+fetchErr.Error(), http.StatusBadRequest)
+		return
+	}
+	if fetchErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgRetrieveConfigsFail+fetchErr.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Return list of dashboard configs
-	utils.WriteSuccessResponse(w, dashboards, http.StatusOK)
+	// Return the page wrapped with the total count and next link
+	utils.WriteSuccessResponse(w, newListResponse(r, page), http.StatusOK)
 }
 
 // UpdateDashboardRegistration handles PUT requests to completely replace a configuration.
@@ -87,14 +97,32 @@ func UpdateDashboardRegistration(w http.ResponseWriter, r *http.Request, id stri
 		return
 	}
 
-	// Call service to update
-	result, updateErr := services.UpdateDashboardConfig(r.Context(), id, body)
+	// Call service to update, honouring any If-Match precondition
+	result, updateErr := services.UpdateDashboardConfig(r.Context(), id, body, r.Header.Get(utils.HeaderIfMatch))
 	if updateErr != nil {
-		utils.WriteErrorResponse(w, utils.MsgUpdateConfigFail+updateErr.Error(), http.StatusInternalServerError)
+		writeRegistrationWriteError(w, utils.MsgUpdateConfigFail, updateErr)
 		return
 	}
 
 	// Return updated config
+	utils.SetETagHeader(w, result[utils.KeyVersion])
+	utils.WriteSuccessResponse(w, result, http.StatusOK)
+}
+
+// RestoreDashboardRegistration handles POST /registrations/{id}/restore, moving a soft-deleted
+// registration out of the trash. Honours If-Match like PUT and PATCH.
+func RestoreDashboardRegistration(w http.ResponseWriter, r *http.Request, id string) {
+	if !utils.EnforceMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	result, restoreErr := services.RestoreRegistrationByID(r.Context(), id, r.Header.Get(utils.HeaderIfMatch))
+	if restoreErr != nil {
+		writeRegistrationWriteError(w, utils.MsgRestoreFail, restoreErr)
+		return
+	}
+
+	utils.SetETagHeader(w, result[utils.KeyVersion])
 	utils.WriteSuccessResponse(w, result, http.StatusOK)
 }
 
@@ -106,13 +134,14 @@ func HeadCheckDashboard(w http.ResponseWriter, r *http.Request, id string) {
 	}
 
 	// Check if config exists
-	_, fetchErr := db.GetDashboardConfigByID(r.Context(), id)
+	config, fetchErr := services.GetActiveDashboardConfig(r.Context(), id)
 	if fetchErr != nil {
 		utils.WriteErrorResponse(w, utils.MsgDashboardNotFound, http.StatusNotFound)
 		return
 	}
 
 	// Return 200 OK without body
+	w.Header().Set(utils.HeaderETag, utils.VersionETag(config.Version))
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -129,14 +158,15 @@ func PatchDashboardRegistration(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
-	// Call service to apply patch
-	result, patchErr := services.PatchDashboardConfig(r.Context(), id, patch)
+	// Call service to apply patch, honouring any If-Match precondition
+	result, patchErr := services.PatchDashboardConfig(r.Context(), id, patch, r.Header.Get(utils.HeaderIfMatch))
 	if patchErr != nil {
-		utils.WriteErrorResponse(w, utils.MsgPatchConfigFail+patchErr.Error(), http.StatusInternalServerError)
+		writeRegistrationWriteError(w, utils.MsgPatchConfigFail, patchErr)
 		return
 	}
 
 	// Respond with updated result
+	utils.SetETagHeader(w, result[utils.KeyVersion])
 	utils.WriteSuccessResponse(w, result, http.StatusOK)
 }
 
@@ -146,13 +176,31 @@ func DeleteDashboardRegistration(w http.ResponseWriter, r *http.Request, id stri
 		return
 	}
 
-	// Call service to delete by ID
-	deleteErr := services.DeleteRegistrationByID(r.Context(), id)
+	// Call service to delete by ID, honouring any If-Match precondition
+	deleteErr := services.DeleteRegistrationByID(r.Context(), id, r.Header.Get(utils.HeaderIfMatch))
 	if deleteErr != nil {
-		utils.WriteErrorResponse(w, utils.MsgDeleteConfigFail+deleteErr.Error(), http.StatusInternalServerError)
+		writeRegistrationWriteError(w, utils.MsgDeleteConfigFail, deleteErr)
 		return
 	}
 
 	// Return 204 No Content
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// writeRegistrationWriteError maps a failed PUT/PATCH/DELETE to a response: version conflicts
+// become 412 Precondition Failed, missing or trashed registrations 404, restoring a registration
+// that is not in the trash 409, and everything else 500.
+func writeRegistrationWriteError(w http.ResponseWriter, failMsg string, err error) {
+	switch {
+	case errors.Is(err, db.ErrVersionConflict):
+		utils.WriteErrorResponse(w, utils.MsgPreconditionFailed+err.Error(), http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, db.ErrNotFound):
+		utils.WriteErrorResponse(w, utils.MsgDashboardNotFound+err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrNotInTrash):
+		utils.WriteErrorResponse(w, failMsg+err.Error(), http.StatusConflict)
+		return
+	}
+	utils.WriteErrorResponse(w, failMsg+err.Error(), http.StatusInternalServerError)
+}
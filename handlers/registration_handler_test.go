@@ -177,3 +177,107 @@ func TestDeleteDashboardRegistration_Success(t *testing.T) {
 		t.Errorf("Expected 204 No Content, got %d", rr.Code)
 	}
 }
+
+func TestPatchDashboardRegistration_IfMatch(t *testing.T) {
+	id := createTestDashboard(t)
+
+	// GET exposes the current version as an ETag
+	getReq := httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/"+id, nil)
+	getRR := httptest.NewRecorder()
+	GetRegistrationByID(getRR, getReq, id)
+	etag := getRR.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("Expected ETag \"1\", got %q", etag)
+	}
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/dashboard/v1/registrations/"+id, strings.NewReader(`{"country": "DK"}`))
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		PatchDashboardRegistration(rr, req, id)
+		return rr
+	}
+
+	if rr := patch(etag); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected 200 with ETag \"2\", got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr := patch(etag); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale ETag, got %d", rr.Code)
+	}
+
+	delReq := httptest.NewRequest(http.MethodDelete, "/dashboard/v1/registrations/"+id, nil)
+	delReq.Header.Set("If-Match", etag)
+	delRR := httptest.NewRecorder()
+	DeleteDashboardRegistration(delRR, delReq, id)
+	if delRR.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale DELETE, got %d", delRR.Code)
+	}
+}
+
+func TestDeleteAndRestoreDashboardRegistration(t *testing.T) {
+	id := createTestDashboard(t)
+
+	rr := httptest.NewRecorder()
+	DeleteDashboardRegistration(rr, httptest.NewRequest(http.MethodDelete, "/dashboard/v1/registrations/"+id, nil), id)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 No Content, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	GetRegistrationByID(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/"+id, nil), id)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected deleted registration to be hidden, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	GetAllRegistrations(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations?deleted=true", nil))
+	if !strings.Contains(rr.Body.String(), id) {
+		t.Errorf("Expected %s in trash listing, got %s", id, rr.Body.String())
+	}
+
+	restore := func() int {
+		rr := httptest.NewRecorder()
+		RestoreDashboardRegistration(rr, httptest.NewRequest(http.MethodPost, "/dashboard/v1/registrations/"+id+"/restore", nil), id)
+		return rr.Code
+	}
+	if code := restore(); code != http.StatusOK {
+		t.Fatalf("Expected 200 OK on restore, got %d", code)
+	}
+	if code := restore(); code != http.StatusConflict {
+		t.Errorf("Expected 409 Conflict restoring a live registration, got %d", code)
+	}
+}
+
+func TestGetAllRegistrations_Paginated(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		createTestDashboard(t)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations?isoCode=TST&limit=2&sort=-lastChange", nil)
+	rr := httptest.NewRecorder()
+	GetAllRegistrations(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", rr.Code)
+	}
+
+	var page struct {
+		Items []map[string]interface{} `json:"items"`
+		Total int                      `json:"total"`
+		Next  string                   `json:"next"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &page)
+	if len(page.Items) != 2 || page.Total < 3 {
+		t.Fatalf("Expected 2 items of at least 3, got %d of %d", len(page.Items), page.Total)
+	}
+	if !strings.Contains(page.Next, "cursor=") || !strings.Contains(page.Next, "isoCode=TST") {
+		t.Errorf("Expected next link carrying cursor and filters, got %q", page.Next)
+	}
+
+	for _, query := range []string{"limit=0", "sort=bogus", "cursor=%21", "lastChangeFrom=yesterday", "feature=gold"} {
+		rr := httptest.NewRecorder()
+		GetAllRegistrations(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations?"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
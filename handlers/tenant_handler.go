@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/services"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// apiKeyFromRequest reads the API key from the X-API-Key header or an "Authorization: Bearer" header.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(utils.HeaderAPIKey); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(r.Header.Get(utils.HeaderAuthorization), utils.BearerPrefix)
+}
+
+// RequireTenant authenticates the request's API key and scopes its context to the key's tenant.
+// When no admin key is configured, authentication is disabled and requests use the default tenant.
+func RequireTenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if utils.AdminAPIKey == "" {
+			next(w, r)
+			return
+		}
+
+		tenant, authErr := services.AuthenticateTenant(r.Context(), apiKeyFromRequest(r))
+		if errors.Is(authErr, services.ErrInvalidAPIKey) {
+			utils.WriteErrorResponse(w, utils.MsgUnauthorized+authErr.Error(), http.StatusUnauthorized)
+			return
+		}
+		if authErr != nil {
+			utils.WriteErrorResponse(w, utils.MsgTenantFetchFail+authErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		next(w, r.WithContext(services.WithTenant(r.Context(), tenant.ID)))
+	}
+}
+
+// RequireAdmin only lets requests through that present the configured admin API key.
+// The tenant admin API is unavailable while no admin key is configured.
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := apiKeyFromRequest(r)
+		if utils.AdminAPIKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(utils.AdminAPIKey)) != 1 {
+			utils.WriteErrorResponse(w, utils.MsgUnauthorized+utils.ErrInvalidAPIKey, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// CreateTenant handles POST requests that register a new tenant.
+// Responds with the tenant and its API key, which is never shown again.
+func CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var request utils.Tenant
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	created, createErr := services.CreateTenant(r.Context(), request.Name)
+	if errors.Is(createErr, services.ErrMissingTenantName) {
+		utils.WriteErrorResponse(w, utils.MsgTenantSaveFail+createErr.Error(), http.StatusBadRequest)
+		return
+	}
+	if createErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgTenantSaveFail+createErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteSuccessResponse(w, created, http.StatusCreated)
+}
+
+// ListTenants handles GET requests for every tenant. Key hashes are never included.
+func ListTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, fetchErr := services.ListTenants(r.Context())
+	if fetchErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgTenantFetchFail+fetchErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteSuccessResponse(w, tenants, http.StatusOK)
+}
+
+// GetTenantByID handles GET requests for a single tenant.
+func GetTenantByID(w http.ResponseWriter, r *http.Request, id string) {
+	tenant, fetchErr := services.GetTenant(r.Context(), id)
+	if fetchErr != nil {
+		writeTenantLookupError(w, fetchErr, utils.MsgTenantFetchFail)
+		return
+	}
+	utils.WriteSuccessResponse(w, tenant, http.StatusOK)
+}
+
+// DeleteTenant handles DELETE requests that remove a tenant and revoke its API key.
+func DeleteTenant(w http.ResponseWriter, r *http.Request, id string) {
+	if deleteErr := services.DeleteTenant(r.Context(), id); deleteErr != nil {
+		writeTenantLookupError(w, deleteErr, utils.MsgTenantDeleteFail)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateTenantKey handles POST requests that issue a tenant a new API key, revoking the old one.
+func RotateTenantKey(w http.ResponseWriter, r *http.Request, id string) {
+	rotated, rotateErr := services.RotateTenantKey(r.Context(), id)
+	if rotateErr != nil {
+		writeTenantLookupError(w, rotateErr, utils.MsgTenantSaveFail)
+		return
+	}
+	utils.WriteSuccessResponse(w, rotated, http.StatusOK)
+}
+
+// writeTenantLookupError maps a missing tenant to 404 and anything else to 500 prefixed with failMsg.
+func writeTenantLookupError(w http.ResponseWriter, err error, failMsg string) {
+	if errors.Is(err, db.ErrNotFound) {
+		utils.WriteErrorResponse(w, utils.MsgTenantNotFound+err.Error(), http.StatusNotFound)
+		return
+	}
+	utils.WriteErrorResponse(w, failMsg+err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withAdminKey enables tenant authentication for the duration of a test.
+func withAdminKey(t *testing.T, key string) {
+	previous := utils.AdminAPIKey
+	utils.AdminAPIKey = key
+	t.Cleanup(func() { utils.AdminAPIKey = previous })
+}
+
+// createTestTenant creates a tenant through the admin handler and returns its API key.
+func createTestTenant(t *testing.T, adminKey, name string) utils.TenantKeyResponse {
+	req := httptest.NewRequest(http.MethodPost, "/dashboard/v1/tenants/", strings.NewReader(`{"name": "`+name+`"}`))
+	req.Header.Set(utils.HeaderAPIKey, adminKey)
+	rr := httptest.NewRecorder()
+	RequireAdmin(CreateTenant)(rr, req)
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created utils.TenantKeyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	return created
+}
+
+func TestRequireAdmin(t *testing.T) {
+	withAdminKey(t, "admin-secret")
+
+	rr := httptest.NewRecorder()
+	RequireAdmin(ListTenants)(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/tenants/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/tenants/", nil)
+	req.Header.Set(utils.HeaderAuthorization, utils.BearerPrefix+"admin-secret")
+	rr = httptest.NewRecorder()
+	RequireAdmin(ListTenants)(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "keyHash")
+}
+
+func TestRequireTenant_Isolation(t *testing.T) {
+	withAdminKey(t, "admin-secret")
+	alice := createTestTenant(t, "admin-secret", "alice")
+	bob := createTestTenant(t, "admin-secret", "bob")
+
+	// Missing and unknown keys are rejected
+	rr := httptest.NewRecorder()
+	RequireTenant(GetAllRegistrations)(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/", nil)
+	req.Header.Set(utils.HeaderAPIKey, "dk_unknown")
+	rr = httptest.NewRecorder()
+	RequireTenant(GetAllRegistrations)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Alice registers a dashboard
+	req = httptest.NewRequest(http.MethodPost, "/dashboard/v1/registrations/",
+		strings.NewReader(`{"country": "Norway", "isoCode": "NO", "features": {}}`))
+	req.Header.Set(utils.HeaderAPIKey, alice.APIKey)
+	rr = httptest.NewRecorder()
+	RequireTenant(HandleRegisterDashboard)(rr, req)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var created map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	id := created[utils.KeyID]
+
+	getAs := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/"+id, nil)
+		req.Header.Set(utils.HeaderAPIKey, apiKey)
+		rr := httptest.NewRecorder()
+		RequireTenant(func(w http.ResponseWriter, r *http.Request) { GetRegistrationByID(w, r, id) })(rr, req)
+		return rr.Code
+	}
+	assert.Equal(t, http.StatusOK, getAs(alice.APIKey))
+	assert.Equal(t, http.StatusNotFound, getAs(bob.APIKey))
+
+	// Bob's listing does not include Alice's dashboard
+	req = httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/", nil)
+	req.Header.Set(utils.HeaderAPIKey, bob.APIKey)
+	rr = httptest.NewRecorder()
+	RequireTenant(GetAllRegistrations)(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), id)
+
+	// A rotated key replaces the old one
+	req = httptest.NewRequest(http.MethodPost, "/dashboard/v1/tenants/"+alice.ID+"/rotate", nil)
+	req.Header.Set(utils.HeaderAPIKey, "admin-secret")
+	rr = httptest.NewRecorder()
+	RequireAdmin(func(w http.ResponseWriter, r *http.Request) { RotateTenantKey(w, r, alice.ID) })(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var rotated utils.TenantKeyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotated))
+	assert.Equal(t, http.StatusUnauthorized, getAs(alice.APIKey))
+	assert.Equal(t, http.StatusOK, getAs(rotated.APIKey))
+}
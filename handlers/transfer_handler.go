@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/amundfpl/Assignment-2/services"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ExportRegistrations handles GET /registrations/export?format=json|ndjson|csv and streams
+// every live registration as a downloadable file. The format defaults to JSON.
+func ExportRegistrations(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforceMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	format := r.URL.Query().Get(utils.QueryFormat)
+	if format == "" {
+		format = utils.FormatJSON
+	}
+	contentType, formatErr := services.ExportContentType(format)
+	if formatErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgExportFail+formatErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(utils.HeaderContentType, contentType)
+	w.Header().Set(utils.HeaderContentDisp, fmt.Sprintf(utils.ExportFilenameFmt, format))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure part-way can only be logged
+	if exportErr := services.ExportDashboardConfigs(r.Context(), format, w); exportErr != nil {
+		log.Println(utils.MsgExportFail, exportErr)
+	}
+}
+
+// ImportRegistrations handles POST /registrations/import. The format comes from ?format= or,
+// failing that, the Content-Type. ?mode=upsert replaces registrations by ID, ?dryRun=true only
+// validates, and ?webhooks=true fires REGISTER/CHANGE webhooks (off by default).
+func ImportRegistrations(w http.ResponseWriter, r *http.Request) {
+	if !utils.EnforceMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get(utils.QueryFormat)
+	if format == "" {
+		format = importFormatFromContentType(r.Header.Get(utils.HeaderContentType))
+	}
+	opts := services.ImportOptions{
+		Mode:     query.Get(utils.QueryMode),
+		DryRun:   query.Get(utils.QueryDryRun) == "true",
+		Webhooks: query.Get(utils.QueryWebhooks) == "true",
+	}
+
+	body := http.MaxBytesReader(w, r.Body, utils.MaxImportBytes)
+	report, importErr := services.ImportDashboardConfigs(r.Context(), format, body, opts)
+	if importErr != nil {
+		utils.WriteErrorResponse(w, utils.MsgImportFail+importErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Per-row failures are part of the report rather than the status code
+	utils.WriteSuccessResponse(w, report, http.StatusOK)
+}
+
+// importFormatFromContentType maps a request Content-Type to an import format, defaulting to JSON.
+func importFormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case utils.ContentTypeCSV:
+		return utils.FormatCSV
+	case utils.ContentTypeNDJSON:
+		return utils.FormatNDJSON
+	}
+	return utils.FormatJSON
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRegistrations(t *testing.T) {
+	id := createTestDashboard(t)
+
+	rr := httptest.NewRecorder()
+	ExportRegistrations(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/export?format=csv", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, utils.ContentTypeCSV, rr.Header().Get(utils.HeaderContentType))
+	assert.True(t, strings.HasPrefix(rr.Body.String(), strings.Join(utils.CSVColumns, ",")))
+	assert.Contains(t, rr.Body.String(), id)
+
+	rr = httptest.NewRecorder()
+	ExportRegistrations(rr, httptest.NewRequest(http.MethodGet, "/dashboard/v1/registrations/export?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestImportRegistrations(t *testing.T) {
+	body := "country,isoCode,area\nImportland,IML,true\n,,\n"
+	req := httptest.NewRequest(http.MethodPost, "/dashboard/v1/registrations/import?dryRun=true", strings.NewReader(body))
+	req.Header.Set(utils.HeaderContentType, "text/csv; charset=utf-8")
+	rr := httptest.NewRecorder()
+	ImportRegistrations(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var report utils.ImportReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Failed)
+
+	rr = httptest.NewRecorder()
+	ImportRegistrations(rr, httptest.NewRequest(http.MethodPost, "/dashboard/v1/registrations/import", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
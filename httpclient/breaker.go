@@ -0,0 +1,182 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrCircuitOpen is wrapped into the error of a request refused because its host's breaker is open.
+var ErrCircuitOpen = errors.New(utils.ErrCircuitOpen)
+
+// BreakerSettings decide when a circuit breaker trips and how long it stays open.
+type BreakerSettings struct {
+	Window      int           // Most recent requests the failure rate is computed over
+	MinRequests int           // Fewer requests in the window never trip the breaker
+	FailureRate float64       // Share of failed requests in the window that trips the breaker
+	Cooldown    time.Duration // How long a tripped breaker fails fast before letting one trial request through
+}
+
+// DefaultBreakerSettings returns the settings every upstream host's breaker starts with.
+func DefaultBreakerSettings() BreakerSettings {
+	return BreakerSettings{
+		Window:      utils.CircuitBreakerWindow,
+		MinRequests: utils.CircuitBreakerMinRequests,
+		FailureRate: utils.CircuitBreakerFailureRate,
+		Cooldown:    utils.CircuitBreakerCooldown,
+	}
+}
+
+// breaker is the circuit breaker of one upstream host. Closed, it lets requests through and tracks
+// their outcomes. Open, it refuses them until the cooldown has passed. Half-open, it lets one trial
+// request through, whose outcome closes or reopens it.
+type breaker struct {
+	mu       sync.Mutex
+	host     string
+	settings BreakerSettings
+	state    string
+	outcomes []bool // Most recent outcomes while closed, oldest first; true is a failure
+	openedAt time.Time
+	probing  bool // A half-open trial request is in flight
+}
+
+// allow reports whether a request may go through, returning an error wrapping ErrCircuitOpen if not.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case utils.BreakerOpen:
+		if time.Since(b.openedAt) < b.settings.Cooldown {
+			return fmt.Errorf(utils.ErrCircuitOpenHost, b.host, ErrCircuitOpen)
+		}
+		b.state = utils.BreakerHalfOpen
+	case utils.BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf(utils.ErrCircuitOpenHost, b.host, ErrCircuitOpen)
+		}
+	default:
+		return nil
+	}
+	b.probing = true
+	return nil
+}
+
+// record takes the outcome of a request that allow let through.
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case utils.BreakerHalfOpen:
+		b.probing = false
+		if failed {
+			b.trip()
+			log.Printf(utils.MsgBreakerReopened, b.host)
+			return
+		}
+		b.state, b.outcomes = utils.BreakerClosed, nil
+		log.Printf(utils.MsgBreakerClosed, b.host)
+		return
+	case utils.BreakerOpen:
+		return // Started before the breaker tripped; the cooldown decides what happens next
+	}
+
+	b.outcomes = append(b.outcomes, failed)
+	if len(b.outcomes) > b.settings.Window {
+		b.outcomes = b.outcomes[len(b.outcomes)-b.settings.Window:]
+	}
+	if failures := b.failures(); len(b.outcomes) >= b.settings.MinRequests &&
+		float64(failures) >= b.settings.FailureRate*float64(len(b.outcomes)) {
+		b.trip()
+		log.Printf(utils.MsgBreakerOpened, b.host, failures, len(b.outcomes))
+	}
+}
+
+// release forgets a request that allow let through but that ended without an outcome, such as one
+// its caller cancelled. A half-open breaker then lets the next trial request through.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// trip opens the breaker, starting its cooldown.
+func (b *breaker) trip() {
+	b.state, b.openedAt, b.outcomes = utils.BreakerOpen, time.Now(), nil
+}
+
+// failures counts the failed requests among the recorded outcomes.
+func (b *breaker) failures() int {
+	failures := 0
+	for _, failed := range b.outcomes {
+		if failed {
+			failures++
+		}
+	}
+	return failures
+}
+
+// status describes the breaker for the status endpoint.
+func (b *breaker) status() utils.CircuitBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := utils.CircuitBreakerStatus{Host: b.host, State: b.state}
+	if len(b.outcomes) > 0 {
+		status.FailureRate = float64(b.failures()) / float64(len(b.outcomes))
+	}
+	if b.state == utils.BreakerOpen {
+		status.RetryInSeconds = max(int64((b.settings.Cooldown - time.Since(b.openedAt)).Seconds()), 0)
+	}
+	return status
+}
+
+// breakers holds one breaker per upstream host, shared by every Client.
+var (
+	breakersMu      sync.Mutex
+	breakers        = map[string]*breaker{}
+	breakerSettings = DefaultBreakerSettings()
+)
+
+// breakerFor returns the breaker of the host serving rawURL, creating a closed one on first use.
+func breakerFor(rawURL string) *breaker {
+	host := hostOf(rawURL)
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[host]
+	if !ok {
+		b = &breaker{host: host, settings: breakerSettings, state: utils.BreakerClosed}
+		breakers[host] = b
+	}
+	return b
+}
+
+// hostOf returns the host, with any port, serving rawURL; rawURL itself if it has none.
+func hostOf(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return rawURL
+}
+
+// BreakerStatus returns the state of the circuit breaker guarding the host serving rawURL.
+// A host that has not been called yet is reported as closed.
+func BreakerStatus(rawURL string) utils.CircuitBreakerStatus {
+	return breakerFor(rawURL).status()
+}
+
+// ResetBreakers closes every circuit breaker and applies settings to the breakers created from now on.
+// Used by tests.
+func ResetBreakers(settings BreakerSettings) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	breakers = map[string]*breaker{}
+	breakerSettings = settings
+}
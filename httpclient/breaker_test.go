@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quickBreakers trip after two failed requests out of at least four and cool down fast.
+var quickBreakers = BreakerSettings{Window: 4, MinRequests: 4, FailureRate: 0.5, Cooldown: 50 * time.Millisecond}
+
+func TestBreaker_OpensAndFailsFast(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusServiceUnavailable, ""))
+	ResetBreakers(quickBreakers)
+	client := NewClientWithRetry(fastRetries)
+
+	_, err := client.Get(context.Background(), server.URL, WithMaxAttempts(4))
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(4), calls.Load())
+	assert.Equal(t, utils.BreakerOpen, BreakerStatus(server.URL).State)
+
+	_, err = client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen, "an open breaker fails fast")
+	assert.Equal(t, int32(4), calls.Load(), "without calling the host")
+
+	time.Sleep(quickBreakers.Cooldown)
+	_, err = client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen, "the failed trial request reopens the breaker, and is not retried")
+	assert.Equal(t, int32(5), calls.Load())
+	assert.Equal(t, utils.BreakerOpen, BreakerStatus(server.URL).State)
+}
+
+func TestBreaker_SuccessfulTrialCloses(t *testing.T) {
+	server, calls := flakyServer(t,
+		status(http.StatusBadGateway, ""), status(http.StatusBadGateway, ""), status(http.StatusBadGateway, ""),
+		status(http.StatusBadGateway, ""), ok)
+	ResetBreakers(quickBreakers)
+	client := NewClientWithRetry(fastRetries)
+
+	_, _ = client.Get(context.Background(), server.URL, WithMaxAttempts(4))
+	require.Equal(t, utils.BreakerOpen, BreakerStatus(server.URL).State)
+
+	time.Sleep(quickBreakers.Cooldown)
+	body, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(5), calls.Load())
+
+	status := BreakerStatus(server.URL)
+	assert.Equal(t, utils.BreakerClosed, status.State)
+	assert.Zero(t, status.FailureRate, "a closed breaker starts counting afresh")
+}
+
+func TestBreaker_IgnoresClientErrorsAndOtherHosts(t *testing.T) {
+	missing, _ := flakyServer(t, status(http.StatusNotFound, ""))
+	down, _ := flakyServer(t, status(http.StatusServiceUnavailable, ""))
+	ResetBreakers(quickBreakers)
+	client := NewClientWithRetry(fastRetries)
+
+	for i := 0; i < 4; i++ {
+		_, _ = client.Get(context.Background(), missing.URL)
+	}
+	assert.Equal(t, utils.BreakerClosed, BreakerStatus(missing.URL).State, "a 404 says nothing about the host's health")
+
+	_, _ = client.Get(context.Background(), down.URL, WithMaxAttempts(4))
+	assert.Equal(t, utils.BreakerOpen, BreakerStatus(down.URL).State)
+	assert.Equal(t, utils.BreakerClosed, BreakerStatus(missing.URL).State, "breakers are per host")
+}
+
+func TestBreaker_HalfOpenLetsOneTrialThrough(t *testing.T) {
+	b := &breaker{host: "example.com", settings: quickBreakers, state: utils.BreakerClosed}
+	b.trip()
+	time.Sleep(quickBreakers.Cooldown)
+
+	require.NoError(t, b.allow())
+	assert.Equal(t, utils.BreakerHalfOpen, b.status().State)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen, "other requests wait for the trial's outcome")
+
+	b.record(false)
+	assert.NoError(t, b.allow())
+}
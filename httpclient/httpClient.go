@@ -2,10 +2,13 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/amundfpl/Assignment-2/utils"
 	"io"
+	"log"
 	"net/http"
 	"time"
 )
@@ -13,28 +16,130 @@ import (
 // Client provides a wrapper around http.Client with convenience methods.
 type Client struct {
 	httpClient *http.Client
+	retry      RetryPolicy
 }
 
-// NewClient initializes an HTTP client with a timeout to prevent hanging requests.
+// NewClient initializes an HTTP client with a timeout to prevent hanging requests,
+// retrying GET requests with DefaultRetryPolicy.
 func NewClient() *Client {
+	return NewClientWithRetry(DefaultRetryPolicy())
+}
+
+// NewClientWithRetry is NewClient with its own retry policy for GET requests.
+func NewClientWithRetry(policy RetryPolicy) *Client {
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		retry: policy,
 	}
 }
 
+// Response is the outcome of a conditional GET request.
+type Response struct {
+	Body        []byte           // Empty if NotModified
+	Validators  utils.Validators // The response's validators, to send back when revalidating it
+	NotModified bool             // The server answered 304: the copy the request's validators came from is current
+}
+
 // Get performs a GET request and returns the response body as bytes.
-// Returns an error if the request fails or returns a non-200 status.
-func (c *Client) Get(url string) ([]byte, error) {
-	resp, reqErr := c.httpClient.Get(url)
+// Network errors, 429 and 5xx responses are retried with exponential backoff, waiting at least as long
+// as a Retry-After header asks. Returns an error, a *StatusError for a non-200 status, once the
+// failure is not retryable or the attempts are used up. While the circuit breaker of the URL's host
+// is open, Get fails fast with an error wrapping ErrCircuitOpen. Every attempt queues for the host's
+// rate limit, failing with an error wrapping ErrRateLimited if its turn would come after ctx's
+// deadline. Cancelling ctx aborts the request and any wait for a retry or for the rate limit.
+func (c *Client) Get(ctx context.Context, url string, opts ...CallOption) ([]byte, error) {
+	resp, getErr := c.GetConditional(ctx, url, utils.Validators{}, opts...)
+	if getErr != nil {
+		return nil, getErr
+	}
+	return resp.Body, nil
+}
+
+// GetConditional is Get revalidating a cached copy: the copy's validators are sent as If-None-Match
+// and If-Modified-Since, and a 304 Not Modified answer is a success with NotModified set.
+// With empty validators it is a plain Get that also returns the response's validators.
+func (c *Client) GetConditional(ctx context.Context, url string, validators utils.Validators, opts ...CallOption) (*Response, error) {
+	settings := callSettings{retry: c.retry}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	policy := settings.retry
+	hostBreaker, hostLimiter := breakerFor(url), limiterFor(url)
+
+	for attempt := 1; ; attempt++ {
+		if waitErr := hostLimiter.wait(ctx); waitErr != nil {
+			return nil, rateLimitError(waitErr)
+		}
+		if openErr := hostBreaker.allow(); openErr != nil {
+			return nil, openErr
+		}
+		resp, getErr := c.getOnce(ctx, url, validators)
+		var requestErr *RequestError
+		if ctx.Err() != nil || errors.As(getErr, &requestErr) {
+			hostBreaker.release() // The caller gave up or the request was never sent, which says nothing about the host's health
+			return nil, getErr
+		}
+		hostBreaker.record(getErr != nil && retryable(getErr)) // Neither does a 404
+		if getErr == nil || attempt >= policy.MaxAttempts || !retryable(getErr) {
+			return resp, getErr
+		}
+
+		wait := policy.backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(getErr, &statusErr) && statusErr.RetryAfter > wait {
+			if statusErr.RetryAfter > policy.MaxDelay {
+				return nil, getErr
+			}
+			wait = statusErr.RetryAfter
+		}
+		log.Printf(utils.MsgHTTPRetry, url, wait, attempt+1, policy.MaxAttempts, getErr)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf(utils.ErrHTTPGetFailed, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimitError returns the error of a request that did not get its turn under the rate limit.
+func rateLimitError(waitErr error) error {
+	if errors.Is(waitErr, ErrRateLimited) {
+		return waitErr
+	}
+	return fmt.Errorf(utils.ErrHTTPGetFailed, waitErr)
+}
+
+// getOnce performs a single GET request, conditional on validators unless they are empty.
+func (c *Client) getOnce(ctx context.Context, url string, validators utils.Validators) (*Response, error) {
+	req, buildErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if buildErr != nil {
+		return nil, &RequestError{Err: buildErr}
+	}
+	if validators.ETag != "" {
+		req.Header.Set(utils.HeaderIfNoneMatch, validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set(utils.HeaderIfModifiedSince, validators.LastModified)
+	}
+	resp, reqErr := c.httpClient.Do(req)
 	if reqErr != nil {
 		return nil, fmt.Errorf(utils.ErrHTTPGetFailed, reqErr)
 	}
 	defer utils.CloseBody(resp.Body)
 
+	if resp.StatusCode == http.StatusNotModified && validators != (utils.Validators{}) {
+		return &Response{Validators: responseValidators(resp.Header, validators), NotModified: true}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(utils.ErrHTTPGetStatus, resp.StatusCode)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get(utils.HeaderRetryAfter)),
+		}
 	}
 
 	bodyBytes, readErr := io.ReadAll(resp.Body)
@@ -42,12 +147,37 @@ func (c *Client) Get(url string) ([]byte, error) {
 		return nil, fmt.Errorf(utils.ErrHTTPReadBody, readErr)
 	}
 
-	return bodyBytes, nil
+	return &Response{Body: bodyBytes, Validators: responseValidators(resp.Header, utils.Validators{})}, nil
+}
+
+// responseValidators reads a response's validators. Those it leaves out, as a 304 answer may, are
+// taken from fallback.
+func responseValidators(header http.Header, fallback utils.Validators) utils.Validators {
+	validators := utils.Validators{
+		ETag:         header.Get(utils.HeaderETag),
+		LastModified: header.Get(utils.HeaderLastModified),
+	}
+	if validators.ETag == "" {
+		validators.ETag = fallback.ETag
+	}
+	if validators.LastModified == "" {
+		validators.LastModified = fallback.LastModified
+	}
+	return validators
 }
 
 // GetStatusCode performs a GET request and returns only the status code.
-func (c *Client) GetStatusCode(url string) (int, error) {
-	resp, reqErr := c.httpClient.Get(url)
+// It reports what the upstream answers right now, so it is never retried, but it does queue for the
+// host's rate limit.
+func (c *Client) GetStatusCode(ctx context.Context, url string) (int, error) {
+	if waitErr := limiterFor(url).wait(ctx); waitErr != nil {
+		return 0, rateLimitError(waitErr)
+	}
+	req, buildErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if buildErr != nil {
+		return 0, &RequestError{Err: buildErr}
+	}
+	resp, reqErr := c.httpClient.Do(req)
 	if reqErr != nil {
 		return 0, fmt.Errorf(utils.ErrHTTPGetFailed, reqErr)
 	}
@@ -58,13 +188,18 @@ func (c *Client) GetStatusCode(url string) (int, error) {
 
 // Post sends a POST request with a JSON payload and returns the response body.
 // Errors if the request fails or returns a non-200 response.
-func (c *Client) Post(url string, body map[string]string) ([]byte, error) {
+func (c *Client) Post(ctx context.Context, url string, body map[string]string) ([]byte, error) {
 	requestBody, marshalErr := json.Marshal(body)
 	if marshalErr != nil {
 		return nil, fmt.Errorf(utils.ErrHTTPPostMarshal, marshalErr)
 	}
 
-	resp, postErr := c.httpClient.Post(url, utils.ContentTypeJSON, bytes.NewBuffer(requestBody))
+	req, buildErr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if buildErr != nil {
+		return nil, &RequestError{Err: buildErr}
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	resp, postErr := c.httpClient.Do(req)
 	if postErr != nil {
 		return nil, fmt.Errorf(utils.ErrHTTPPostFailed, postErr)
 	}
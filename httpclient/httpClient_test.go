@@ -0,0 +1,229 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetries retries quickly so that tests do not wait on real backoff delays.
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+// neverTrip keeps circuit breakers out of the way of tests about retries.
+var neverTrip = BreakerSettings{Window: 20, MinRequests: 1000, FailureRate: 1, Cooldown: time.Minute}
+
+// flakyServer answers with the given handlers in turn, repeating the last one, and counts the requests.
+// Its breaker never trips unless the test resets the breakers with other settings.
+func flakyServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	ResetBreakers(neverTrip)
+	t.Cleanup(func() { ResetBreakers(DefaultBreakerSettings()) })
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		handlers[min(call, len(handlers))-1](w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func status(code int, retryAfter string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+}
+
+func TestGet_RetriesTransientFailures(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusServiceUnavailable, ""), status(http.StatusTooManyRequests, ""), ok)
+
+	body, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGet_GivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusBadGateway, ""))
+
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGet_DoesNotRetryClientErrors(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusNotFound, ""), ok)
+
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGet_RetriesNetworkErrors(t *testing.T) {
+	dropConnection := func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		_ = conn.Close()
+	}
+	server, calls := flakyServer(t, dropConnection, ok)
+
+	body, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestGet_HonoursRetryAfter(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusTooManyRequests, "1"), ok)
+
+	start := time.Now()
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "the retry waits as long as the server asked")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestGet_RetryAfterBeyondMaxDelayFails(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusServiceUnavailable, "120"), ok)
+
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "waiting two minutes is worse than failing")
+}
+
+func TestGet_PerCallOverrides(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusServiceUnavailable, ""))
+	client := NewClientWithRetry(fastRetries)
+
+	_, err := client.Get(context.Background(), server.URL, WithoutRetries())
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	calls.Store(0)
+	_, err = client.Get(context.Background(), server.URL, WithMaxAttempts(5))
+	assert.Error(t, err)
+	assert.Equal(t, int32(5), calls.Load())
+
+	calls.Store(0)
+	_, err = client.Get(context.Background(), server.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}))
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestBackoff_GrowsWithJitterUpToMaxDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 8: time.Second} {
+		wait := policy.backoff(attempt)
+		assert.GreaterOrEqual(t, wait, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, wait, want, "attempt %d", attempt)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Zero(t, parseRetryAfter(""))
+	assert.Zero(t, parseRetryAfter("soon"))
+	assert.Zero(t, parseRetryAfter("-5"))
+
+	date := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, time.Minute.Seconds(), date.Seconds(), 2)
+	assert.Zero(t, parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)))
+}
+
+func TestGet_CancelledRequestIsAbandoned(t *testing.T) {
+	hang := func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() }
+	server, _ := flakyServer(t, hang)
+	ResetBreakers(BreakerSettings{Window: 1, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewClientWithRetry(fastRetries).Get(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, utils.BreakerClosed, BreakerStatus(server.URL).State, "a caller giving up does not count against the host")
+}
+
+func TestGet_CancelledWhileWaitingToRetry(t *testing.T) {
+	server, calls := flakyServer(t, status(http.StatusServiceUnavailable, "1"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewClientWithRetry(fastRetries).Get(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "the Retry-After wait is cut short")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetConditional_NotModified(t *testing.T) {
+	server, calls := flakyServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(utils.HeaderIfNoneMatch) == `"v1"` || r.Header.Get(utils.HeaderIfModifiedSince) != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(utils.HeaderETag, `"v1"`)
+		w.Header().Set(utils.HeaderLastModified, "Mon, 02 Jan 2006 15:04:05 GMT")
+		ok(w, r)
+	})
+	client := NewClientWithRetry(fastRetries)
+
+	resp, err := client.GetConditional(context.Background(), server.URL, utils.Validators{})
+	require.NoError(t, err)
+	assert.False(t, resp.NotModified)
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.Equal(t, utils.Validators{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, resp.Validators)
+
+	resp, err = client.GetConditional(context.Background(), server.URL, resp.Validators)
+	require.NoError(t, err)
+	assert.True(t, resp.NotModified)
+	assert.Empty(t, resp.Body)
+	assert.Equal(t, `"v1"`, resp.Validators.ETag, "validators a 304 leaves out are kept")
+
+	resp, err = client.GetConditional(context.Background(), server.URL, utils.Validators{LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"})
+	require.NoError(t, err)
+	assert.True(t, resp.NotModified)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGet_UnrequestedNotModifiedIsAnError(t *testing.T) {
+	server, _ := flakyServer(t, status(http.StatusNotModified, ""))
+
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr, "without validators there is no copy a 304 could refer to")
+	assert.Equal(t, http.StatusNotModified, statusErr.StatusCode)
+}
+
+func TestGet_DoesNotRetryRequestsThatCannotBeBuilt(t *testing.T) {
+	server, calls := flakyServer(t, ok)
+	ResetBreakers(quickBreakers)
+
+	for i := 0; i < quickBreakers.MinRequests; i++ {
+		_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL+"/%zz")
+		var requestErr *RequestError
+		require.ErrorAs(t, err, &requestErr)
+	}
+	assert.Zero(t, calls.Load())
+	assert.Equal(t, utils.BreakerClosed, BreakerStatus(server.URL).State, "a malformed URL says nothing about the host's health")
+
+	_, err := NewClientWithRetry(fastRetries).Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+}
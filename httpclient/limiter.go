@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrRateLimited is wrapped into the error of a request whose turn under its host's rate limit
+// would only come after its context's deadline.
+var ErrRateLimited = errors.New(utils.ErrRateLimited)
+
+// RateLimit caps how fast requests are sent to one upstream host.
+type RateLimit struct {
+	PerSecond float64 // Tokens added per second; 0 or less disables the limit
+	Burst     int     // Tokens the bucket holds, and so requests sent at once after a quiet spell
+}
+
+// DefaultRateLimit returns the limit every upstream host's bucket starts with.
+func DefaultRateLimit() RateLimit {
+	return RateLimit{PerSecond: utils.HTTPRateLimit, Burst: utils.HTTPRateBurst}
+}
+
+// limiter is the token bucket of one upstream host. Every request takes a token; a request finding
+// the bucket empty queues until a token is added, or fails at once if that is after its deadline.
+type limiter struct {
+	mu     sync.Mutex
+	host   string
+	limit  RateLimit
+	tokens float64 // Negative while requests are queued for tokens not yet added
+	last   time.Time
+
+	requests, delayed, rejected int64
+	waitTotal, waitMax          time.Duration
+}
+
+// newLimiter returns a limiter with a full bucket.
+func newLimiter(host string, limit RateLimit) *limiter {
+	limit.Burst = max(limit.Burst, 1)
+	return &limiter{host: host, limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+// wait blocks until the request may be sent. It returns an error wrapping ErrRateLimited without
+// waiting if the wait would outlast ctx's deadline, and ctx's error if ctx ends while waiting.
+func (l *limiter) wait(ctx context.Context) error {
+	delay, reserveErr := l.reserve(ctx)
+	if reserveErr != nil || delay == 0 {
+		return reserveErr
+	}
+
+	timer := time.NewTimer(delay)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		l.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token, returning how long the request must wait for it.
+func (l *limiter) reserve(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.PerSecond <= 0 {
+		l.requests++
+		return 0, nil
+	}
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.limit.PerSecond, float64(l.limit.Burst))
+	l.last = now
+
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / l.limit.PerSecond * float64(time.Second))
+		if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+			l.rejected++
+			return 0, fmt.Errorf(utils.ErrRateLimitedHost, l.host, ErrRateLimited)
+		}
+		l.delayed++
+		l.waitTotal += delay
+		l.waitMax = max(l.waitMax, delay)
+	}
+	l.tokens--
+	l.requests++
+	return delay, nil
+}
+
+// cancel hands back the token of a request that gave up waiting for it.
+func (l *limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+}
+
+// status describes the limiter for the status endpoint.
+func (l *limiter) status() utils.RateLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return utils.RateLimitStatus{
+		Host:              l.host,
+		RequestsPerSecond: max(l.limit.PerSecond, 0),
+		Burst:             l.limit.Burst,
+		Requests:          l.requests,
+		Delayed:           l.delayed,
+		Rejected:          l.rejected,
+		WaitTotalMs:       l.waitTotal.Milliseconds(),
+		WaitMaxMs:         l.waitMax.Milliseconds(),
+	}
+}
+
+// limiters holds one limiter per upstream host, shared by every Client. Until ResetRateLimits is
+// called, new limiters take DefaultRateLimit, so that flags parsed at startup apply.
+var (
+	limitersMu    sync.Mutex
+	limiters      = map[string]*limiter{}
+	limitOverride *RateLimit
+)
+
+// limiterFor returns the limiter of the host serving rawURL, creating one with a full bucket on first use.
+func limiterFor(rawURL string) *limiter {
+	host := hostOf(rawURL)
+
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[host]
+	if !ok {
+		limit := DefaultRateLimit()
+		if limitOverride != nil {
+			limit = *limitOverride
+		}
+		l = newLimiter(host, limit)
+		limiters[host] = l
+	}
+	return l
+}
+
+// RateLimitStatus returns the rate limit of the host serving rawURL and how long its requests have queued.
+func RateLimitStatus(rawURL string) utils.RateLimitStatus {
+	return limiterFor(rawURL).status()
+}
+
+// ResetRateLimits drops every limiter, with its counters, and applies limit to the limiters created from now on.
+// Used by tests.
+func ResetRateLimits(limit RateLimit) {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	limiters = map[string]*limiter{}
+	limitOverride = &limit
+}
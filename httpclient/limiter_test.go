@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limited resets the limiters to limit for the rest of the test.
+func limited(t *testing.T, limit RateLimit) {
+	t.Helper()
+	ResetRateLimits(limit)
+	t.Cleanup(func() { ResetRateLimits(DefaultRateLimit()) })
+}
+
+func TestLimiter_BurstThenQueuesAtRate(t *testing.T) {
+	server, calls := flakyServer(t, ok)
+	limited(t, RateLimit{PerSecond: 20, Burst: 3})
+	client := NewClient()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 40*time.Millisecond, "the burst goes out at once")
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "then one request per 50ms")
+	assert.Equal(t, int32(5), calls.Load())
+
+	status := RateLimitStatus(server.URL)
+	assert.Equal(t, int64(5), status.Requests)
+	assert.Equal(t, int64(2), status.Delayed)
+	assert.GreaterOrEqual(t, status.WaitTotalMs, int64(40))
+	assert.LessOrEqual(t, status.WaitMaxMs, status.WaitTotalMs)
+}
+
+func TestLimiter_RejectsRequestsThatWouldMissTheirDeadline(t *testing.T) {
+	server, calls := flakyServer(t, ok)
+	limited(t, RateLimit{PerSecond: 1, Burst: 1})
+	client := NewClient()
+
+	_, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Get(ctx, server.URL)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "a hopeless wait fails at once")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(1), RateLimitStatus(server.URL).Rejected)
+}
+
+func TestLimiter_CancelledWaitHandsBackItsToken(t *testing.T) {
+	l := newLimiter("example.com", RateLimit{PerSecond: 10, Burst: 1})
+	require.NoError(t, l.wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	assert.ErrorIs(t, l.wait(ctx), context.Canceled)
+
+	start := time.Now()
+	require.NoError(t, l.wait(context.Background()))
+	assert.Less(t, time.Since(start), 150*time.Millisecond, "the next request only waits for the first token")
+}
+
+func TestLimiter_ZeroRateDisablesLimit(t *testing.T) {
+	server, _ := flakyServer(t, ok)
+	limited(t, RateLimit{PerSecond: 0, Burst: 1})
+	client := NewClient()
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		_, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Zero(t, RateLimitStatus(server.URL).Delayed)
+}
+
+func TestGetStatusCode_QueuesForRateLimit(t *testing.T) {
+	server, _ := flakyServer(t, status(http.StatusNoContent, ""))
+	limited(t, RateLimit{PerSecond: 1, Burst: 1})
+	client := NewClient()
+
+	code, err := client.GetStatusCode(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.GetStatusCode(ctx, server.URL)
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
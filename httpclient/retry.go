@@ -0,0 +1,114 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// RetryPolicy decides how often, and how patiently, idempotent requests are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Including the first attempt; 1 disables retries
+	BaseDelay   time.Duration // Wait before the first retry, doubled for every retry after it
+	MaxDelay    time.Duration // Cap on any wait; a longer Retry-After fails the call instead
+}
+
+// DefaultRetryPolicy returns the policy of clients created with NewClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: utils.HTTPMaxAttempts,
+		BaseDelay:   utils.HTTPRetryBaseDelay,
+		MaxDelay:    utils.HTTPRetryMaxDelay,
+	}
+}
+
+// backoff returns the wait before retrying after the given failed attempt: the exponential delay
+// capped at MaxDelay, with the upper half jittered so that clients do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// CallOption overrides the client's settings for one call.
+type CallOption func(*callSettings)
+
+// callSettings are the settings of one call, starting from the client's.
+type callSettings struct {
+	retry RetryPolicy
+}
+
+// WithRetryPolicy replaces the client's retry policy for one call.
+func WithRetryPolicy(policy RetryPolicy) CallOption {
+	return func(s *callSettings) { s.retry = policy }
+}
+
+// WithMaxAttempts keeps the client's retry delays but changes how often one call is tried.
+func WithMaxAttempts(attempts int) CallOption {
+	return func(s *callSettings) { s.retry.MaxAttempts = attempts }
+}
+
+// WithoutRetries tries one call only once.
+func WithoutRetries() CallOption {
+	return WithMaxAttempts(1)
+}
+
+// StatusError is returned for a response whose status is not 200 OK.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // How long the server asked to wait, 0 if it did not say
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf(utils.ErrHTTPGetStatus, e.StatusCode)
+}
+
+// RequestError is returned for a request that could not be built, such as one for a malformed URL.
+// The request never reached the host, so it is neither retried nor counted against the host's breaker.
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf(utils.ErrHTTPBuildFailed, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether a failed attempt may succeed if tried again: network and read errors,
+// 429 Too Many Requests and 5xx responses.
+func retryable(err error) bool {
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return false
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date; 0 if it is absent or invalid.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
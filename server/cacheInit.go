@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/utils"
+	"log"
+)
+
+// CacheOptions selects and configures the shared cache tier.
+type CacheOptions struct {
+	Backend       string // utils.CacheBackendStore or utils.CacheBackendRedis
+	RedisAddr     string // host:port of the Redis-protocol server; ignored for the store backend
+	RedisPassword string // Optional AUTH password
+}
+
+// CacheInitialization installs the shared cache tier selected by opts.
+// The store backend keeps entries in the storage backend and needs no setup; the redis
+// backend connects to the server and checks that it answers.
+// Returns an error if the server cannot be reached or the backend is unknown.
+func CacheInitialization(opts CacheOptions) error {
+	log.Println(utils.MsgUsingCacheBackend, opts.Backend)
+
+	switch opts.Backend {
+	case utils.CacheBackendStore, "":
+		cache.UseBackend(cache.StoreBackend{})
+		return nil
+	case utils.CacheBackendRedis:
+		ctx, cancel := context.WithTimeout(context.Background(), utils.RedisDefaultTimeout)
+		defer cancel()
+		backend, err := cache.NewRESPBackend(ctx, opts.RedisAddr, opts.RedisPassword)
+		if err != nil {
+			return fmt.Errorf(utils.ErrCacheBackendInit, opts.Backend, err)
+		}
+		cache.UseBackend(backend)
+		return nil
+	default:
+		return fmt.Errorf(utils.ErrUnknownCacheBackend, opts.Backend)
+	}
+}
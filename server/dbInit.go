@@ -9,10 +9,42 @@ import (
 	"os"
 )
 
-// DatabaseInitialization sets up the Firebase and Firestore clients.
+// StoreOptions selects and configures the storage backend.
+type StoreOptions struct {
+	Backend string // utils.StoreFirestore, utils.StoreMemory, utils.StoreSQLite or utils.StorePostgres
+	DSN     string // Data source name for the SQL backends; ignored otherwise
+}
+
+// DatabaseInitialization sets up the storage backend selected by opts.
+// The firestore backend initializes the Firebase and Firestore clients, the SQL
+// backends open the database and apply migrations, and the memory backend needs
+// no external services at all.
 // It must be called before any database operations are performed.
-// Returns an error if either initialization step fails.
-func DatabaseInitialization() error {
+// Returns an error if initialization fails or the backend is unknown.
+func DatabaseInitialization(opts StoreOptions) error {
+	log.Println(utils.MsgUsingStore, opts.Backend)
+
+	switch opts.Backend {
+	case utils.StoreMemory:
+		db.UseStore(db.NewMemoryStore())
+		return nil
+	case utils.StoreSQLite, utils.StorePostgres:
+		store, err := db.NewSQLStore(context.Background(), opts.Backend, opts.DSN)
+		if err != nil {
+			return err
+		}
+		db.UseStore(store)
+		return nil
+	case utils.StoreFirestore, "":
+		return firestoreInitialization()
+	default:
+		return fmt.Errorf(utils.ErrUnknownStore, opts.Backend)
+	}
+}
+
+// firestoreInitialization sets up the Firebase and Firestore clients and installs
+// Firestore as the active store.
+func firestoreInitialization() error {
 	ctx := context.Background()
 
 	// Initialize Firebase app using credentials from the utils package
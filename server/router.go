@@ -17,18 +17,24 @@ func InitializeRoutes() http.Handler {
 	getOneHandler := handlers.NewDashboardHandler(realService)
 	router := http.NewServeMux()
 
-	// Dashboard registration endpoints
-	router.HandleFunc(utils.DashboardRegistrationsRoute2, registrationsDispatcher)
-	router.HandleFunc(utils.DashboardRegistrationsRoute, registrationsDispatcher)
+	// Dashboard registration endpoints, scoped to the caller's tenant
+	router.HandleFunc(utils.DashboardRegistrationsRoute2, handlers.RequireTenant(registrationsDispatcher))
+	router.HandleFunc(utils.DashboardRegistrationsRoute, handlers.RequireTenant(registrationsDispatcher))
 
 	// Dashboard visualization endpoints
-	router.HandleFunc(utils.DashboardDashboardsRoute, getOneHandler)
+	router.HandleFunc(utils.DashboardDashboardsRoute, handlers.RequireTenant(handlers.WithDeadline(utils.RequestTimeout, getOneHandler)))
 
 	// Webhook notification endpoints
-	router.HandleFunc(utils.DashboardNotificationsRoute, notificationsDispatcher)
+	router.HandleFunc(utils.DashboardNotificationsRoute, handlers.RequireTenant(handlers.WithDeadline(utils.RequestTimeout, notificationsDispatcher)))
+
+	// Tenant administration, guarded by the admin API key
+	router.HandleFunc(utils.DashboardTenantsRoute, handlers.RequireAdmin(tenantsDispatcher))
+
+	// Cache administration, guarded by the admin API key
+	router.HandleFunc(utils.DashboardCacheRoute, handlers.RequireAdmin(cacheDispatcher))
 
 	// Status check endpoint
-	router.HandleFunc(utils.DashboardStatusRoute, handlers.HandleServiceStatus)
+	router.HandleFunc(utils.DashboardStatusRoute, handlers.WithDeadline(utils.RequestTimeout, handlers.HandleServiceStatus))
 
 	// Serve static content and homepage fallback
 	router.HandleFunc(utils.RouteRoot, FileServerWithFallback(utils.StaticDir))
@@ -41,13 +47,32 @@ func registrationsDispatcher(w http.ResponseWriter, r *http.Request) {
 	basePath := utils.DashboardRegistrationsRoute2
 	trimmedPath := strings.TrimPrefix(r.URL.Path, basePath)
 	trimmedPath = strings.Trim(trimmedPath, "/")
-	id := trimmedPath
+	segments := strings.Split(trimmedPath, "/")
+	id := segments[0]
 
 	if id == "" {
 		id = r.URL.Query().Get("id")
 	}
 
+	// Bulk transfers may take minutes; everything else gets the usual request deadline
+	timeout := utils.RequestTimeout
+	if len(segments) == 1 && (id == utils.SegmentExport || id == utils.SegmentImport) {
+		timeout = utils.BulkRequestTimeout
+	}
+	handlers.WithDeadline(timeout, func(w http.ResponseWriter, r *http.Request) {
+		dispatchRegistration(w, r, id, segments)
+	})(w, r)
+}
+
+// dispatchRegistration routes a registration request to its handler by ID, sub-resource and method.
+func dispatchRegistration(w http.ResponseWriter, r *http.Request, id string, segments []string) {
 	switch {
+	case len(segments) > 1:
+		registrationSubresourceDispatcher(w, r, id, segments[1:])
+	case id == utils.SegmentExport:
+		handlers.ExportRegistrations(w, r)
+	case id == utils.SegmentImport:
+		handlers.ImportRegistrations(w, r)
 	case id == "":
 		switch r.Method {
 		case http.MethodGet:
@@ -75,6 +100,29 @@ func registrationsDispatcher(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// registrationSubresourceDispatcher handles /registrations/{id}/history[/{rev}],
+// /registrations/{id}/rollback/{rev} and /registrations/{id}/restore.
+func registrationSubresourceDispatcher(w http.ResponseWriter, r *http.Request, id string, segments []string) {
+	switch {
+	case segments[0] == utils.SegmentHistory && len(segments) == 1:
+		if !utils.EnforceMethod(w, r, http.MethodGet) {
+			return
+		}
+		handlers.GetRegistrationHistory(w, r, id)
+	case segments[0] == utils.SegmentHistory && len(segments) == 2:
+		if !utils.EnforceMethod(w, r, http.MethodGet) {
+			return
+		}
+		handlers.GetRegistrationRevision(w, r, id, segments[1])
+	case segments[0] == utils.SegmentRollback && len(segments) == 2:
+		handlers.RollbackRegistration(w, r, id, segments[1])
+	case segments[0] == utils.SegmentRestore && len(segments) == 1:
+		handlers.RestoreDashboardRegistration(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 // notificationsDispatcher handles webhook registration and deletion endpoints.
 func notificationsDispatcher(w http.ResponseWriter, r *http.Request) {
 	basePath := utils.DashboardNotificationsRoute
@@ -104,6 +152,79 @@ func notificationsDispatcher(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// tenantsDispatcher handles /tenants, /tenants/{id} and /tenants/{id}/rotate.
+func tenantsDispatcher(w http.ResponseWriter, r *http.Request) {
+	trimmedPath := strings.Trim(strings.TrimPrefix(r.URL.Path, utils.DashboardTenantsRoute), "/")
+	segments := strings.Split(trimmedPath, "/")
+	id := segments[0]
+
+	switch {
+	case id == "":
+		switch r.Method {
+		case http.MethodGet:
+			handlers.ListTenants(w, r)
+		case http.MethodPost:
+			handlers.CreateTenant(w, r)
+		default:
+			http.Error(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 2 && segments[1] == utils.SegmentRotate:
+		if !utils.EnforceMethod(w, r, http.MethodPost) {
+			return
+		}
+		handlers.RotateTenantKey(w, r, id)
+	case len(segments) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetTenantByID(w, r, id)
+		case http.MethodDelete:
+			handlers.DeleteTenant(w, r, id)
+		default:
+			http.Error(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// cacheDispatcher handles /cache (statistics), /cache/purge, /cache/{collection} and /cache/{collection}/{key}.
+func cacheDispatcher(w http.ResponseWriter, r *http.Request) {
+	trimmedPath := strings.Trim(strings.TrimPrefix(r.URL.Path, utils.DashboardCacheRoute), "/")
+	segments := strings.SplitN(trimmedPath, "/", 2)
+	collection := segments[0]
+
+	switch {
+	case collection == "":
+		if !utils.EnforceMethod(w, r, http.MethodGet) {
+			return
+		}
+		handlers.GetCacheStats(w, r)
+	case collection == utils.SegmentPurge && len(segments) == 1:
+		if !utils.EnforceMethod(w, r, http.MethodPost) {
+			return
+		}
+		handlers.PurgeCaches(w, r)
+	case len(segments) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			handlers.ListCacheEntries(w, r, collection)
+		case http.MethodDelete:
+			handlers.InvalidateCacheCollection(w, r, collection)
+		default:
+			http.Error(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+		}
+	default:
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetCacheEntry(w, r, collection, segments[1])
+		case http.MethodDelete:
+			handlers.InvalidateCacheEntry(w, r, collection, segments[1])
+		default:
+			http.Error(w, utils.ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+		}
+	}
+}
+
 // FileServerWithFallback serves static files from the provided directory.
 // If a file is not found, it falls back to serving "index.html".
 func FileServerWithFallback(dir string) http.HandlerFunc {
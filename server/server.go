@@ -1,30 +1,71 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"github.com/amundfpl/Assignment-2/cache"
 	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/services"
 	"github.com/amundfpl/Assignment-2/utils"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 )
 
-// StartServer initializes services, sets up routes, and runs the HTTP server.
-func StartServer() {
-	// Initialize Firebase and Firestore clients
-	if dbInitErr := DatabaseInitialization(); dbInitErr != nil {
+// StartServer initializes services, sets up routes, and runs the HTTP server until SIGINT or SIGTERM.
+// On shutdown it lets in-flight requests, the cache and trash purges and cache warming finish before closing the storage backend.
+// storeOpts selects the persistence layer (see StoreOptions) and cacheOpts the shared cache tier (see CacheOptions).
+func StartServer(storeOpts StoreOptions, cacheOpts CacheOptions) {
+	// Cancelled when the process is asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize the selected storage backend
+	if dbInitErr := DatabaseInitialization(storeOpts); dbInitErr != nil {
 		log.Fatalf(utils.ErrMsgInitDB, dbInitErr)
 	}
 
-	// Ensure Firestore client closes on shutdown
+	// Ensure the storage backend closes on shutdown
 	defer func() {
-		if closeErr := db.CloseFirestore(); closeErr != nil {
-			log.Printf(utils.ErrMsgCloseFirestore, closeErr)
+		if closeErr := db.CloseStore(); closeErr != nil {
+			log.Printf(utils.ErrMsgCloseStore, closeErr)
 		}
 	}()
 
-	// Start cache purge loop in background
-	go cache.StartCachePurgeLoop()
+	// Install the shared cache tier
+	if cacheInitErr := CacheInitialization(cacheOpts); cacheInitErr != nil {
+		log.Fatalf(utils.ErrMsgInitCache, cacheInitErr)
+	}
+
+	// Without an admin key every request acts as the default tenant
+	if utils.AdminAPIKey == "" {
+		log.Println(utils.MsgAuthDisabled)
+	}
+
+	// Start cache purge loop in background; it is waited for before the store closes
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		cache.StartCachePurgeLoop(ctx)
+	}()
+
+	// Start refreshing cache entries of registered dashboards before they expire
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		services.StartCacheWarmLoop(ctx)
+	}()
+
+	// Start hard purge of expired soft-deleted registrations in background
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		services.StartTrashPurgeLoop(ctx)
+	}()
 
 	// Determine port from environment variable
 	port := os.Getenv(utils.EnvPort)
@@ -39,7 +80,20 @@ func StartServer() {
 	log.Println(utils.MsgServerStart, port)
 
 	// Start the HTTP server
-	if listenErr := http.ListenAndServe(utils.AddrPrefix+port, router); listenErr != nil {
-		log.Fatalf(utils.ErrMsgServerStart, listenErr)
+	httpServer := &http.Server{Addr: utils.AddrPrefix + port, Handler: router}
+	go func() {
+		if listenErr := httpServer.ListenAndServe(); listenErr != nil && !errors.Is(listenErr, http.ErrServerClosed) {
+			log.Fatalf(utils.ErrMsgServerStart, listenErr)
+		}
+	}()
+
+	// Wait for a shutdown signal, then stop accepting requests and let the background loops finish
+	<-ctx.Done()
+	log.Println(utils.MsgServerShutdown)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), utils.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Printf(utils.ErrMsgServerShutdown, shutdownErr)
 	}
+	background.Wait()
 }
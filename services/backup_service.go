@@ -0,0 +1,628 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrInvalidBackup is returned when a backup archive is malformed, truncated or fails its checksum.
+var ErrInvalidBackup = errors.New(utils.ErrInvalidBackup)
+
+// RestoreOptions controls how RestoreBackup applies an archive.
+type RestoreOptions struct {
+	Mode       string // utils.RestoreModeMerge (default) or utils.RestoreModeReplace
+	VerifyOnly bool   // Check the archive without touching the store
+}
+
+// backupKinds are the document record kinds an archive may contain, in the order they are written.
+var backupKinds = []string{
+	utils.BackupKindTenant, utils.BackupKindRegistration, utils.BackupKindRevision,
+	utils.BackupKindWebhook, utils.BackupKindCache,
+}
+
+// backupWriter writes archive lines, hashing every line and counting records per kind.
+type backupWriter struct {
+	w      io.Writer
+	hash   hash.Hash
+	counts map[string]int
+}
+
+// write appends one record. Header records are hashed but not counted.
+func (b *backupWriter) write(kind, collection string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(utils.BackupRecord{Kind: kind, Collection: collection, Data: raw})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	b.hash.Write(line)
+	if _, err := b.w.Write(line); err != nil {
+		return err
+	}
+	if kind != utils.BackupKindHeader {
+		b.counts[kind]++
+	}
+	return nil
+}
+
+// WriteBackup streams every tenant, registration (including the trash and revision history) and
+// webhook of every tenant to w as a gzip-compressed NDJSON archive, plus every cache entry if caches
+// is set. The first line is a utils.BackupHeader and the last a utils.BackupTrailer holding the
+// record counts and the SHA-256 of all preceding lines.
+func WriteBackup(ctx context.Context, w io.Writer, caches bool) (*utils.BackupSummary, error) {
+	gz := gzip.NewWriter(w)
+	out := &backupWriter{w: gz, hash: sha256.New(), counts: map[string]int{}}
+
+	header := utils.BackupHeader{
+		Format:        utils.BackupFormat,
+		FormatVersion: utils.BackupFormatVersion,
+		SchemaVersion: db.DashboardSchemaVersion(),
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		Caches:        caches,
+	}
+	if err := out.write(utils.BackupKindHeader, "", header); err != nil {
+		return nil, err
+	}
+	if err := writeBackupRecords(ctx, out, caches); err != nil {
+		return nil, err
+	}
+
+	summary := &utils.BackupSummary{Header: header, Counts: out.counts, SHA256: hex.EncodeToString(out.hash.Sum(nil))}
+	trailer, err := json.Marshal(utils.BackupTrailer{Counts: summary.Counts, SHA256: summary.SHA256})
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(utils.BackupRecord{Kind: utils.BackupKindTrailer, Data: trailer})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gz.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// writeBackupRecords writes every document record of an archive.
+func writeBackupRecords(ctx context.Context, out *backupWriter, caches bool) error {
+	tenants, err := db.GetAllTenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		if err := out.write(utils.BackupKindTenant, "", utils.BackupTenant{Tenant: tenant, KeyHash: tenant.KeyHash}); err != nil {
+			return err
+		}
+	}
+
+	err = forEachStoredDashboardConfig(ctx, func(config utils.DashboardConfig) error {
+		if err := out.write(utils.BackupKindRegistration, "", config); err != nil {
+			return err
+		}
+		revisions, err := db.GetRevisions(ctx, config.ID)
+		if err != nil {
+			return err
+		}
+		for _, revision := range revisions {
+			if err := out.write(utils.BackupKindRevision, "", revision); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = forEachStoredWebhook(ctx, func(hook utils.Webhook) error {
+		return out.write(utils.BackupKindWebhook, "", hook)
+	})
+	if err != nil || !caches {
+		return err
+	}
+
+	for _, collection := range utils.CacheCollections {
+		err := db.ForEachCacheEntry(ctx, collection, func(entry utils.CacheRecord) error {
+			return out.write(utils.BackupKindCache, collection, entry)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachStoredDashboardConfig visits the registrations of every tenant, live ones first and then
+// the trash, one page at a time.
+func forEachStoredDashboardConfig(ctx context.Context, fn func(utils.DashboardConfig) error) error {
+	for _, deleted := range []bool{false, true} {
+		query := db.DashboardQuery{ListOptions: db.ListOptions{Limit: utils.MaxPageLimit}, Deleted: deleted, AllTenants: true}
+		for {
+			page, err := db.QueryDashboardConfigs(ctx, query)
+			if err != nil {
+				return err
+			}
+			for _, config := range page.Items {
+				if err := fn(config); err != nil {
+					return err
+				}
+			}
+			if page.Next == nil {
+				break
+			}
+			query.After = page.Next
+		}
+	}
+	return nil
+}
+
+// forEachStoredWebhook visits the webhooks of every tenant one page at a time.
+func forEachStoredWebhook(ctx context.Context, fn func(utils.Webhook) error) error {
+	query := db.WebhookQuery{ListOptions: db.ListOptions{Limit: utils.MaxPageLimit}, AllTenants: true}
+	for {
+		page, err := db.QueryWebhooks(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, hook := range page.Items {
+			if err := fn(hook); err != nil {
+				return err
+			}
+		}
+		if page.Next == nil {
+			return nil
+		}
+		query.After = page.Next
+	}
+}
+
+// readBackup streams an archive written by WriteBackup, calling fn for every document record.
+// It fails with ErrInvalidBackup if the header is unsupported, the archive is truncated, or the
+// trailer's checksum or counts do not match. Since the trailer comes last, callers that must not
+// act on a corrupt archive read it once with a nil fn first.
+func readBackup(r io.Reader, fn func(utils.BackupRecord) error) (*utils.BackupSummary, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrBackupRecord, 1, err, ErrInvalidBackup)
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	digest := sha256.New()
+	summary := &utils.BackupSummary{Counts: map[string]int{}}
+	trailerSeen := false
+
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) == 0 && readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf(utils.ErrBackupRecord, lineNo, readErr, ErrInvalidBackup)
+		}
+		if trailerSeen {
+			return nil, fmt.Errorf(utils.ErrBackupTrailingData, lineNo, ErrInvalidBackup)
+		}
+
+		var record utils.BackupRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf(utils.ErrBackupRecord, lineNo, err, ErrInvalidBackup)
+		}
+
+		switch {
+		case lineNo == 1:
+			if err := readBackupHeader(record, &summary.Header); err != nil {
+				return nil, err
+			}
+		case record.Kind == utils.BackupKindTrailer:
+			summary.SHA256 = hex.EncodeToString(digest.Sum(nil))
+			if err := checkBackupTrailer(record, summary); err != nil {
+				return nil, err
+			}
+			trailerSeen = true
+			continue
+		case !isBackupKind(record.Kind):
+			return nil, fmt.Errorf(utils.ErrBackupRecordKind, lineNo, record.Kind, ErrInvalidBackup)
+		default:
+			summary.Counts[record.Kind]++
+			if fn != nil {
+				if err := fn(record); err != nil {
+					return nil, err
+				}
+			}
+		}
+		digest.Write(line)
+	}
+
+	if !trailerSeen {
+		return nil, fmt.Errorf(utils.ErrBackupTruncated, ErrInvalidBackup)
+	}
+	return summary, nil
+}
+
+// readBackupHeader decodes and validates the header record.
+func readBackupHeader(record utils.BackupRecord, header *utils.BackupHeader) error {
+	if record.Kind != utils.BackupKindHeader {
+		return fmt.Errorf(utils.ErrBackupHeader, utils.BackupFormat, ErrInvalidBackup)
+	}
+	if err := json.Unmarshal(record.Data, header); err != nil || header.Format != utils.BackupFormat {
+		return fmt.Errorf(utils.ErrBackupHeader, utils.BackupFormat, ErrInvalidBackup)
+	}
+	if header.FormatVersion != utils.BackupFormatVersion {
+		return fmt.Errorf(utils.ErrBackupFormatVersion, header.FormatVersion, ErrInvalidBackup)
+	}
+	if header.SchemaVersion > db.DashboardSchemaVersion() {
+		return fmt.Errorf(utils.ErrBackupSchemaTooNew, header.SchemaVersion, db.DashboardSchemaVersion(), ErrInvalidBackup)
+	}
+	return nil
+}
+
+// checkBackupTrailer compares the trailer against the checksum and counts computed while reading.
+func checkBackupTrailer(record utils.BackupRecord, summary *utils.BackupSummary) error {
+	var trailer utils.BackupTrailer
+	if err := json.Unmarshal(record.Data, &trailer); err != nil {
+		return fmt.Errorf(utils.ErrBackupTruncated, ErrInvalidBackup)
+	}
+	if trailer.SHA256 != summary.SHA256 {
+		return fmt.Errorf(utils.ErrBackupChecksum, trailer.SHA256, summary.SHA256, ErrInvalidBackup)
+	}
+	for _, kind := range backupKinds {
+		if trailer.Counts[kind] != summary.Counts[kind] {
+			return fmt.Errorf(utils.ErrBackupCount, kind, trailer.Counts[kind], summary.Counts[kind], ErrInvalidBackup)
+		}
+	}
+	return nil
+}
+
+// isBackupKind reports whether kind is a document record kind.
+func isBackupKind(kind string) bool {
+	for _, known := range backupKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreBackup replays an archive written by WriteBackup into the active store. The whole archive
+// is verified before anything is written. In merge mode documents with the same ID are overwritten
+// and everything else is kept; in replace mode all registrations, revisions, webhooks and tenants
+// (and caches, if the archive has them) are deleted first. Afterwards every restored document is
+// read back and compared against the archive; differences are listed in the report's failures.
+func RestoreBackup(ctx context.Context, archive io.ReadSeeker, opts RestoreOptions) (*utils.RestoreReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = utils.RestoreModeMerge
+	}
+	if opts.Mode != utils.RestoreModeMerge && opts.Mode != utils.RestoreModeReplace {
+		return nil, fmt.Errorf(utils.ErrUnknownRestoreMode, opts.Mode)
+	}
+
+	// Pass 1: verify the archive and note which histories it brings along
+	archivedIDs := map[string]bool{}
+	summary, err := readBackup(archive, func(record utils.BackupRecord) error {
+		if record.Kind == utils.BackupKindRegistration {
+			var config utils.DashboardConfig
+			_ = json.Unmarshal(record.Data, &config)
+			archivedIDs[config.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &utils.RestoreReport{
+		Mode:       opts.Mode,
+		VerifyOnly: opts.VerifyOnly,
+		Archive:    *summary,
+		Deleted:    map[string]int{},
+		Restored:   map[string]int{},
+		Failures:   []utils.DocumentFailure{},
+	}
+	if opts.VerifyOnly {
+		return report, nil
+	}
+
+	if opts.Mode == utils.RestoreModeReplace {
+		if err := clearStore(ctx, summary.Header.Caches, archivedIDs, report.Deleted); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pass 2: write every record
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	_, err = readBackup(archive, func(record utils.BackupRecord) error {
+		if err := restoreRecord(ctx, record); err != nil {
+			return err
+		}
+		report.Restored[record.Kind]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pass 3: read everything back
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	_, err = readBackup(archive, func(record utils.BackupRecord) error {
+		id, problem, err := verifyRecord(ctx, record)
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			report.Failures = append(report.Failures, utils.DocumentFailure{ID: record.Kind + "/" + id, Error: problem})
+			return nil
+		}
+		report.Verified++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mode == utils.RestoreModeReplace {
+		if err := verifyStoreCounts(ctx, summary.Counts, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// clearStore deletes every registration with its history, webhook and tenant, plus the histories
+// of archived registrations that were already purged, and every cache entry if caches is set.
+func clearStore(ctx context.Context, caches bool, archivedIDs map[string]bool, deleted map[string]int) error {
+	// Collect IDs before deleting, so that deletes never run against an open page
+	var configIDs []string
+	if err := forEachStoredDashboardConfig(ctx, func(config utils.DashboardConfig) error {
+		configIDs = append(configIDs, config.ID)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, id := range configIDs {
+		if err := db.DeleteDashboardConfig(ctx, id); err != nil {
+			return err
+		}
+		deleted[utils.BackupKindRegistration]++
+		archivedIDs[id] = true
+	}
+	for id := range archivedIDs {
+		if err := db.DeleteRevisions(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	var hookIDs []string
+	if err := forEachStoredWebhook(ctx, func(hook utils.Webhook) error {
+		hookIDs = append(hookIDs, hook.ID)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, id := range hookIDs {
+		if err := db.DeleteWebhook(ctx, id); err != nil {
+			return err
+		}
+		deleted[utils.BackupKindWebhook]++
+	}
+
+	tenants, err := db.GetAllTenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		if err := db.DeleteTenant(ctx, tenant.ID); err != nil {
+			return err
+		}
+		deleted[utils.BackupKindTenant]++
+	}
+
+	if !caches {
+		return nil
+	}
+	for _, collection := range utils.CacheCollections {
+		// Every entry was stored before now, so a threshold slightly ahead clears the collection
+		purged, err := db.PurgeCacheEntries(ctx, collection, time.Now().Add(time.Minute))
+		if err != nil {
+			return err
+		}
+		deleted[utils.BackupKindCache] += purged
+	}
+	return nil
+}
+
+// restoreRecord writes one archived document, keeping its ID, version and timestamps.
+// Registrations are upgraded to the current schema first. Revisions that already exist are
+// kept, since history is immutable; verification reports them if they differ.
+func restoreRecord(ctx context.Context, record utils.BackupRecord) error {
+	switch record.Kind {
+	case utils.BackupKindTenant:
+		var archived utils.BackupTenant
+		if err := json.Unmarshal(record.Data, &archived); err != nil {
+			return err
+		}
+		archived.Tenant.KeyHash = archived.KeyHash
+		return db.PutTenant(ctx, archived.Tenant)
+
+	case utils.BackupKindRegistration:
+		config, err := db.DecodeDashboardJSON(record.Data)
+		if err != nil {
+			return err
+		}
+		return db.UpdateDashboardConfig(ctx, *config)
+
+	case utils.BackupKindRevision:
+		var revision utils.DashboardRevision
+		if err := json.Unmarshal(record.Data, &revision); err != nil {
+			return err
+		}
+		if err := db.SaveRevision(ctx, revision); err != nil && !errors.Is(err, db.ErrVersionConflict) {
+			return err
+		}
+		return nil
+
+	case utils.BackupKindWebhook:
+		var hook utils.Webhook
+		if err := json.Unmarshal(record.Data, &hook); err != nil {
+			return err
+		}
+		return db.PutWebhook(ctx, hook)
+
+	case utils.BackupKindCache:
+		var entry utils.CacheRecord
+		if err := json.Unmarshal(record.Data, &entry); err != nil {
+			return err
+		}
+		return db.PutCacheEntry(ctx, record.Collection, entry)
+	}
+	return nil
+}
+
+// verifyRecord reads an archived document back from the store. It returns the document's ID and,
+// if it is missing or differs from the archive, a description of the problem. Cache entries are
+// only checked for presence, since backends store their timestamps with different precision.
+func verifyRecord(ctx context.Context, record utils.BackupRecord) (string, string, error) {
+	var id string
+	var want, got interface{}
+	var getErr error
+
+	switch record.Kind {
+	case utils.BackupKindTenant:
+		var archived utils.BackupTenant
+		if err := json.Unmarshal(record.Data, &archived); err != nil {
+			return "", "", err
+		}
+		archived.Tenant.KeyHash = archived.KeyHash
+		id, want = archived.ID, archived.Tenant
+		var stored *utils.Tenant
+		if stored, getErr = db.GetTenantByID(ctx, id); getErr == nil {
+			got = *stored
+		}
+
+	case utils.BackupKindRegistration:
+		config, err := db.DecodeDashboardJSON(record.Data)
+		if err != nil {
+			return "", "", err
+		}
+		id, want = config.ID, *config
+		var stored *utils.DashboardConfig
+		if stored, getErr = db.GetDashboardConfigByID(ctx, id); getErr == nil {
+			got = *stored
+		}
+
+	case utils.BackupKindRevision:
+		var revision utils.DashboardRevision
+		if err := json.Unmarshal(record.Data, &revision); err != nil {
+			return "", "", err
+		}
+		id, want = fmt.Sprintf("%s/%d", revision.DashboardID, revision.Revision), revision
+		var stored *utils.DashboardRevision
+		if stored, getErr = db.GetRevision(ctx, revision.DashboardID, revision.Revision); getErr == nil {
+			got = *stored
+		}
+
+	case utils.BackupKindWebhook:
+		var hook utils.Webhook
+		if err := json.Unmarshal(record.Data, &hook); err != nil {
+			return "", "", err
+		}
+		id, want = hook.ID, hook
+		var stored *utils.Webhook
+		if stored, getErr = db.GetWebhookByID(ctx, id); getErr == nil {
+			got = *stored
+		}
+
+	case utils.BackupKindCache:
+		var entry utils.CacheRecord
+		if err := json.Unmarshal(record.Data, &entry); err != nil {
+			return "", "", err
+		}
+		id = record.Collection + "/" + entry.Key
+		var stored struct {
+			Timestamp time.Time `json:"timestamp" firestore:"timestamp"`
+		}
+		getErr = db.GetCacheEntry(ctx, record.Collection, entry.Key, &stored)
+		want, got = true, true
+	}
+
+	if errors.Is(getErr, db.ErrNotFound) {
+		return id, utils.MsgRestoreMissing, nil
+	}
+	if getErr != nil {
+		return "", "", getErr
+	}
+	if !sameJSON(want, got) {
+		return id, utils.MsgRestoreMismatch, nil
+	}
+	return id, "", nil
+}
+
+// sameJSON reports whether a and b serialize to equivalent JSON, so that values whose dynamic
+// types changed on a round trip through a backend (such as int64 and float64) still compare equal.
+func sameJSON(a, b interface{}) bool {
+	var decodedA, decodedB interface{}
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if json.Unmarshal(rawA, &decodedA) != nil || json.Unmarshal(rawB, &decodedB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(decodedA, decodedB)
+}
+
+// verifyStoreCounts checks that, after a replace, the store holds exactly the archived registrations,
+// webhooks and tenants.
+func verifyStoreCounts(ctx context.Context, archived map[string]int, report *utils.RestoreReport) error {
+	stored := map[string]int{}
+	if err := forEachStoredDashboardConfig(ctx, func(utils.DashboardConfig) error {
+		stored[utils.BackupKindRegistration]++
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := forEachStoredWebhook(ctx, func(utils.Webhook) error {
+		stored[utils.BackupKindWebhook]++
+		return nil
+	}); err != nil {
+		return err
+	}
+	tenants, err := db.GetAllTenants(ctx)
+	if err != nil {
+		return err
+	}
+	stored[utils.BackupKindTenant] = len(tenants)
+
+	for _, kind := range []string{utils.BackupKindTenant, utils.BackupKindRegistration, utils.BackupKindWebhook} {
+		if stored[kind] != archived[kind] {
+			report.Failures = append(report.Failures, utils.DocumentFailure{
+				ID:    kind,
+				Error: fmt.Sprintf(utils.MsgRestoreCountMismatch, stored[kind], archived[kind]),
+			})
+		}
+	}
+	return nil
+}
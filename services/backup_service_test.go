@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useBackupStore installs store for the rest of the test, so that replace restores never touch the
+// shared test store.
+func useBackupStore(t *testing.T, store db.Store) {
+	previous := db.CurrentStore()
+	db.UseStore(store)
+	t.Cleanup(func() { db.UseStore(previous) })
+}
+
+// newBackupSQLiteStore opens a fresh SQLite database in a temporary directory.
+func newBackupSQLiteStore(t *testing.T) db.Store {
+	store, err := db.NewSQLStore(context.Background(), utils.StoreSQLite, filepath.Join(t.TempDir(), "restore.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// seedBackupSource fills the active store with one of every archived document kind.
+func seedBackupSource(t *testing.T) {
+	ctx := context.Background()
+	tenantID, err := db.SaveTenant(ctx, utils.Tenant{Name: "acme", KeyHash: "hash-acme", CreatedAt: "20250101 10:00"})
+	require.NoError(t, err)
+
+	tenantCtx := WithTenant(ctx, tenantID)
+	live, err := createDashboardConfig(tenantCtx, utils.DashboardConfig{Country: "Norway", ISOCode: "NO",
+		Features: utils.FeatureConfig{Capital: true, TargetCurrencies: []string{"EUR"}}})
+	require.NoError(t, err)
+	_, err = PatchDashboardConfig(tenantCtx, live.ID, map[string]interface{}{utils.KeyFeatures: map[string]interface{}{utils.KeyArea: true}}, "")
+	require.NoError(t, err)
+
+	trashed, err := createDashboardConfig(tenantCtx, utils.DashboardConfig{Country: "Sweden", ISOCode: "SE"})
+	require.NoError(t, err)
+	require.NoError(t, DeleteRegistrationByID(tenantCtx, trashed.ID, ""))
+
+	_, err = RegisterWebhook(tenantCtx, utils.Webhook{URL: "http://hook", Event: utils.EventChange})
+	require.NoError(t, err)
+	require.NoError(t, db.SetCacheEntry(ctx, utils.CountryCacheCollection, "no", map[string]interface{}{"population": 5}))
+}
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	useBackupStore(t, db.NewMemoryStore())
+	seedBackupSource(t)
+
+	var archive bytes.Buffer
+	summary, err := WriteBackup(ctx, &archive, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Counts[utils.BackupKindTenant])
+	assert.Equal(t, 2, summary.Counts[utils.BackupKindRegistration])
+	assert.Equal(t, 4, summary.Counts[utils.BackupKindRevision])
+	assert.Equal(t, 1, summary.Counts[utils.BackupKindWebhook])
+	assert.Equal(t, 1, summary.Counts[utils.BackupKindCache])
+
+	// Restore into an empty SQLite store, then back up that store and compare
+	useBackupStore(t, newBackupSQLiteStore(t))
+	report, err := RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{Mode: utils.RestoreModeReplace})
+	require.NoError(t, err)
+	assert.Empty(t, report.Failures)
+	assert.Equal(t, summary.Counts, report.Restored)
+	assert.Equal(t, 9, report.Verified)
+	assert.Equal(t, summary.SHA256, report.Archive.SHA256)
+
+	restoredTenant, err := db.GetTenantByKeyHash(ctx, "hash-acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", restoredTenant.Name)
+
+	var again bytes.Buffer
+	resummary, err := WriteBackup(ctx, &again, true)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Counts, resummary.Counts)
+}
+
+func TestRestoreBackup_MergeAndReplace(t *testing.T) {
+	ctx := context.Background()
+	useBackupStore(t, db.NewMemoryStore())
+	seedBackupSource(t)
+	var archive bytes.Buffer
+	_, err := WriteBackup(ctx, &archive, false)
+	require.NoError(t, err)
+
+	target := db.NewMemoryStore()
+	useBackupStore(t, target)
+	_, err = createDashboardConfig(ctx, utils.DashboardConfig{Country: "Denmark", ISOCode: "DK"})
+	require.NoError(t, err)
+
+	report, err := RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{Mode: utils.RestoreModeMerge})
+	require.NoError(t, err)
+	assert.Empty(t, report.Failures)
+	assert.Empty(t, report.Deleted)
+	all, err := db.GetAllDashboardConfigs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "merge keeps registrations that are not in the archive")
+
+	report, err = RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{Mode: utils.RestoreModeReplace})
+	require.NoError(t, err)
+	assert.Empty(t, report.Failures)
+	assert.Equal(t, 3, report.Deleted[utils.BackupKindRegistration])
+	all, err = db.GetAllDashboardConfigs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2, "replace drops registrations that are not in the archive")
+
+	_, err = RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{Mode: "overwrite"})
+	assert.Error(t, err)
+}
+
+// rewriteArchive decompresses an archive, applies edit to its lines and compresses it again.
+func rewriteArchive(t *testing.T, archive []byte, edit func([]string) []string) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	var out bytes.Buffer
+	writer := gzip.NewWriter(&out)
+	_, err = writer.Write([]byte(strings.Join(edit(lines), "\n") + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return out.Bytes()
+}
+
+func TestRestoreBackup_RejectsDamagedArchives(t *testing.T) {
+	ctx := context.Background()
+	useBackupStore(t, db.NewMemoryStore())
+	seedBackupSource(t)
+	var archive bytes.Buffer
+	_, err := WriteBackup(ctx, &archive, false)
+	require.NoError(t, err)
+
+	cases := map[string][]byte{
+		"tampered": rewriteArchive(t, archive.Bytes(), func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], "acme", "evil", 1)
+			return lines
+		}),
+		"truncated": rewriteArchive(t, archive.Bytes(), func(lines []string) []string {
+			return lines[:len(lines)-1]
+		}),
+		"dropped record": rewriteArchive(t, archive.Bytes(), func(lines []string) []string {
+			return append(lines[:1:1], lines[2:]...)
+		}),
+		"not gzip": []byte("plain text"),
+	}
+	for name, damaged := range cases {
+		t.Run(name, func(t *testing.T) {
+			target := db.NewMemoryStore()
+			useBackupStore(t, target)
+
+			_, err := RestoreBackup(ctx, bytes.NewReader(damaged), RestoreOptions{Mode: utils.RestoreModeReplace})
+			assert.ErrorIs(t, err, ErrInvalidBackup)
+			tenants, err := db.GetAllTenants(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, tenants, "nothing is written from a damaged archive")
+		})
+	}
+}
+
+func TestRestoreBackup_VerifyOnlyAndDetectsDifferences(t *testing.T) {
+	ctx := context.Background()
+	useBackupStore(t, db.NewMemoryStore())
+	seedBackupSource(t)
+	var archive bytes.Buffer
+	_, err := WriteBackup(ctx, &archive, false)
+	require.NoError(t, err)
+
+	useBackupStore(t, db.NewMemoryStore())
+	report, err := RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{VerifyOnly: true})
+	require.NoError(t, err)
+	assert.True(t, report.VerifyOnly)
+	assert.Zero(t, report.Verified)
+	all, err := db.GetAllDashboardConfigs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	// A differing revision already in the target is kept and reported
+	_, err = RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{})
+	require.NoError(t, err)
+	source, err := db.GetAllDashboardConfigs(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, source)
+	require.NoError(t, db.DeleteRevisions(ctx, source[0].ID))
+	require.NoError(t, db.SaveRevision(ctx, utils.DashboardRevision{DashboardID: source[0].ID, Revision: 1,
+		Event: utils.EventRegister, Timestamp: time.Now().Format(utils.TimestampLayout)}))
+
+	report, err = RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), RestoreOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, utils.BackupKindRevision+"/"+source[0].ID+"/1", report.Failures[0].ID)
+	assert.Equal(t, utils.MsgRestoreMismatch, report.Failures[0].Error)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrUnknownCacheCollection is returned for a collection that is not one of utils.CacheCollections.
+var ErrUnknownCacheCollection = errors.New(utils.ErrUnknownCacheCollection)
+
+// checkCacheCollection fails with ErrUnknownCacheCollection unless collection is a cache collection.
+func checkCacheCollection(collection string) error {
+	if !slices.Contains(utils.CacheCollections, collection) {
+		return fmt.Errorf("%w: %s", ErrUnknownCacheCollection, collection)
+	}
+	return nil
+}
+
+// ListCacheEntries lists the entries of a cache collection whose keys start with prefix.
+func ListCacheEntries(ctx context.Context, collection, prefix string) ([]utils.CacheEntrySummary, error) {
+	if err := checkCacheCollection(collection); err != nil {
+		return nil, err
+	}
+	return cache.ListEntries(ctx, collection, prefix)
+}
+
+// GetCacheEntry returns one cache entry with its data. Returns db.ErrNotFound if there is none.
+func GetCacheEntry(ctx context.Context, collection, key string) (*utils.CacheEntryDetail, error) {
+	if err := checkCacheCollection(collection); err != nil {
+		return nil, err
+	}
+	return cache.InspectEntry(ctx, collection, key)
+}
+
+// InvalidateCacheEntry removes one cache entry.
+func InvalidateCacheEntry(ctx context.Context, collection, key string) error {
+	if err := checkCacheCollection(collection); err != nil {
+		return err
+	}
+	return cache.Invalidate(ctx, collection, key)
+}
+
+// InvalidateCachePrefix removes the entries of a cache collection whose keys start with prefix,
+// or the whole collection if prefix is empty.
+func InvalidateCachePrefix(ctx context.Context, collection, prefix string) (*utils.CacheInvalidation, error) {
+	if err := checkCacheCollection(collection); err != nil {
+		return nil, err
+	}
+	removed, err := cache.InvalidatePrefix(ctx, collection, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.CacheInvalidation{Collection: collection, Prefix: prefix, Removed: removed}, nil
+}
+
+// GetCacheStats returns the lookup counters of every cache collection since startup.
+func GetCacheStats() []utils.CacheCollectionStats {
+	return cache.Stats()
+}
+
+// PurgeCaches runs one cache purge cycle immediately and reports the outcome of each collection.
+func PurgeCaches(ctx context.Context) []utils.CachePurgeResult {
+	return cache.RunPurgeCycle(ctx)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// flight is one upstream call shared by every caller that missed the same cache entry.
+type flight struct {
+	done    chan struct{} // Closed once data and err are set
+	data    interface{}
+	err     error
+	waiters int                // Callers still waiting for the result, guarded by inFlightMu
+	cancel  context.CancelFunc // Cancels the call once no caller is waiting for it
+}
+
+// inFlight holds the upstream calls currently running, by cache collection and key.
+var (
+	inFlightMu sync.Mutex
+	inFlight   = map[string]*flight{}
+
+	coalescedCalls atomic.Int64
+)
+
+// coalesce runs fn once for all concurrent callers with the same key and hands each of them its result.
+// Callers that join a running call are counted in CoalescedCalls. The result is shared and must not be modified.
+// A caller whose ctx ends stops waiting and gets ctx's error. fn's context keeps ctx's values but is only
+// cancelled once every caller has stopped waiting, so one client going away does not fail the others.
+func coalesce[T any](ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	inFlightMu.Lock()
+	call, running := inFlight[key]
+	if running {
+		coalescedCalls.Add(1)
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flight{done: make(chan struct{}), cancel: cancel}
+		inFlight[key] = call
+		go func() {
+			data, err := fn(callCtx)
+			inFlightMu.Lock()
+			forget(key, call)
+			call.data, call.err = data, err
+			inFlightMu.Unlock()
+			close(call.done)
+			cancel()
+		}()
+	}
+	call.waiters++
+	inFlightMu.Unlock()
+
+	select {
+	case <-call.done:
+		data, _ := call.data.(T)
+		return data, call.err
+	case <-ctx.Done():
+		inFlightMu.Lock()
+		if call.waiters--; call.waiters == 0 {
+			call.cancel()
+			forget(key, call) // Later callers start a call of their own rather than join a cancelled one
+		}
+		inFlightMu.Unlock()
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// forget removes call from the running calls unless another call has replaced it. inFlightMu must be held.
+func forget(key string, call *flight) {
+	if inFlight[key] == call {
+		delete(inFlight, key)
+	}
+}
+
+// CoalescedCalls returns how many upstream calls were saved by joining one already in flight.
+func CoalescedCalls() int64 {
+	return coalescedCalls.Load()
+}
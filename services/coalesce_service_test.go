@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichCountryData_CoalescesConcurrentMisses(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	countryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`[{"capital": ["Oslo"], "latlng": [60, 10]}]`))
+	}))
+	defer countryServer.Close()
+	original := utils.RESTCountriesAPI
+	utils.RESTCountriesAPI = countryServer.URL
+	defer func() { utils.RESTCountriesAPI = original }()
+
+	const callers = 50
+	iso := fmt.Sprintf("C%d", time.Now().UnixNano())
+	cfg := utils.DashboardConfig{ISOCode: iso, Features: utils.FeatureConfig{Capital: true}}
+	before := CoalescedCalls()
+
+	var wg sync.WaitGroup
+	capitals := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp := &utils.DashboardResponse{}
+			if _, err := enrichCountryData(context.Background(), httpclient.NewClient(), cfg, resp); err == nil {
+				capitals[i] = resp.Capital
+			}
+		}(i)
+	}
+	require.Eventually(t, func() bool { return CoalescedCalls()-before == callers-1 }, 2*time.Second, time.Millisecond,
+		"every caller but the first joins the call in flight")
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "one upstream request")
+	for _, capital := range capitals {
+		assert.Equal(t, "Oslo", capital)
+	}
+	cached, err := cache.GetCachedCountryInfo(context.Background(), iso, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Oslo"}, cached.Capital)
+}
+
+func TestCoalesce_SharesErrorsAndForgetsFinishedCalls(t *testing.T) {
+	failure := errors.New("upstream down")
+	key := fmt.Sprintf("test/%d", time.Now().UnixNano())
+
+	_, err := coalesce(context.Background(), key, func(context.Context) (int, error) { return 0, failure })
+	assert.ErrorIs(t, err, failure)
+
+	value, err := coalesce(context.Background(), key, func(context.Context) (int, error) { return 7, nil })
+	require.NoError(t, err, "a finished call is not reused")
+	assert.Equal(t, 7, value)
+}
+
+func TestCoalesce_CancelledCallerStopsWaiting(t *testing.T) {
+	key := fmt.Sprintf("test/%d", time.Now().UnixNano())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var callCancelled atomic.Bool
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := coalesce(leaderCtx, key, func(ctx context.Context) (int, error) {
+			close(started)
+			select {
+			case <-release:
+				return 7, nil
+			case <-ctx.Done():
+				callCancelled.Store(true)
+				return 0, ctx.Err()
+			}
+		})
+		leaderErr <- err
+	}()
+	<-started
+
+	joined := make(chan int, 1)
+	go func() {
+		value, _ := coalesce(context.Background(), key, func(context.Context) (int, error) { return 0, nil })
+		joined <- value
+	}()
+	require.Eventually(t, func() bool {
+		inFlightMu.Lock()
+		defer inFlightMu.Unlock()
+		return inFlight[key] != nil && inFlight[key].waiters == 2
+	}, time.Second, time.Millisecond)
+
+	cancelLeader()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled, "the cancelled caller returns at once")
+
+	close(release)
+	assert.Equal(t, 7, <-joined, "the call goes on for the caller still waiting")
+	assert.False(t, callCancelled.Load())
+}
+
+func TestCoalesce_CancelsCallOnceEveryCallerLeft(t *testing.T) {
+	key := fmt.Sprintf("test/%d", time.Now().UnixNano())
+	callCancelled := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := coalesce(ctx, key, func(callCtx context.Context) (int, error) {
+		<-callCtx.Done()
+		close(callCancelled)
+		return 0, callCtx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case <-callCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the upstream call to be cancelled once no caller waited for it")
+	}
+
+	value, err := coalesce(context.Background(), key, func(context.Context) (int, error) { return 3, nil })
+	require.NoError(t, err, "later callers do not join the cancelled call")
+	assert.Equal(t, 3, value)
+}
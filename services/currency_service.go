@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// rateTable loads a cached rate table, either cachedSource.cached or cachedSource.live.
+type rateTable func(cachedSource[map[string]float64], context.Context) (map[string]float64, cache.Freshness, error)
+
+// rateTableSource is the cached table of every exchange rate quoted from base. One entry serves
+// all dashboards with that base, whatever their target currencies.
+func rateTableSource(client *httpclient.Client, base string, maxAge time.Duration) cachedSource[map[string]float64] {
+	key := cache.CurrencyCacheKey(base)
+	return cachedSource[map[string]float64]{
+		name:   utils.CurrencyCacheCollection + "/" + key,
+		maxAge: maxAge,
+		lookup: func(ctx context.Context, maxAge time.Duration) (map[string]float64, cache.Freshness, error) {
+			return cache.LookupCurrencyRates(ctx, key, maxAge)
+		},
+		fetch: func(ctx context.Context) (map[string]float64, error) { return fetchRateTable(ctx, client, key) },
+		save: func(ctx context.Context, rates map[string]float64) error {
+			return cache.SaveCurrencyRatesToCache(ctx, key, rates)
+		},
+	}
+}
+
+// exchangeRates returns the rates from base into targets, taken from base's rate table as loaded by load.
+// Targets missing from that table, or every target if base is not quoted at all, are cross-computed
+// from the utils.CrossRateCurrency table. Targets neither table covers are left out, as the currency
+// API does. The freshness is that of the oldest table used.
+func exchangeRates(ctx context.Context, client *httpclient.Client, base string, targets []string,
+	maxAge time.Duration, load rateTable) (map[string]float64, cache.Freshness, error) {
+
+	table, freshness, tableErr := load(rateTableSource(client, base, maxAge), ctx)
+	rates := map[string]float64{}
+	var missing []string
+	for _, target := range targets {
+		rate, quoted := table[target]
+		switch {
+		case target == base:
+			rates[target] = 1
+		case tableErr == nil && quoted:
+			rates[target] = rate
+		default:
+			missing = append(missing, target)
+		}
+	}
+	if len(missing) == 0 {
+		return rates, freshness, nil
+	}
+	if base == utils.CrossRateCurrency {
+		return rates, freshness, tableErr
+	}
+
+	crossTable, crossFreshness, crossErr := load(rateTableSource(client, utils.CrossRateCurrency, maxAge), ctx)
+	baseRate, baseQuoted := crossTable[base]
+	if crossErr != nil || !baseQuoted || baseRate == 0 {
+		return rates, freshness, tableErr
+	}
+
+	crossed := false
+	for _, target := range missing {
+		if target == utils.CrossRateCurrency {
+			rates[target] = 1 / baseRate
+			crossed = true
+		} else if rate, quoted := crossTable[target]; quoted {
+			rates[target] = rate / baseRate
+			crossed = true
+		}
+	}
+	if !crossed {
+		return rates, freshness, tableErr
+	}
+	if tableErr != nil {
+		return rates, crossFreshness, nil
+	}
+	return rates, olderFreshness(freshness, crossFreshness), nil
+}
+
+// olderFreshness combines the freshness of two pieces of data used together: the result is as old as
+// the older of the two, and stale if either is.
+func olderFreshness(a, b cache.Freshness) cache.Freshness {
+	combined := a
+	if b.StoredAt.Before(a.StoredAt) {
+		combined.StoredAt = b.StoredAt
+	}
+	combined.Stale = a.Stale || b.Stale
+	return combined
+}
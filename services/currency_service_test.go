@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startRateTables serves the given rate table per base currency and 404 for any other base.
+// It returns the number of requests made for each base.
+func startRateTables(t *testing.T, tables map[string]string) map[string]int {
+	useBackupStore(t, db.NewMemoryStore())
+	cache.ResetLocalCache()
+	t.Cleanup(cache.ResetLocalCache)
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := r.URL.Query().Get("from")
+		assert.Empty(t, r.URL.Query().Get("to"), "the whole table is requested")
+		mu.Lock()
+		calls[base]++
+		mu.Unlock()
+		table, ok := tables[base]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"base": "` + base + `", "rates": ` + table + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	original := utils.CurrencyAPI
+	utils.CurrencyAPI = server.URL
+	t.Cleanup(func() { utils.CurrencyAPI = original })
+	return calls
+}
+
+func TestExchangeRates_SubsetsShareOneTable(t *testing.T) {
+	calls := startRateTables(t, map[string]string{"NOK": `{"EUR": 0.085, "USD": 0.093, "SEK": 1.01}`})
+	ctx := context.Background()
+	client := httpclient.NewClient()
+
+	rates, _, err := exchangeRates(ctx, client, "NOK", []string{"EUR", "USD"}, utils.CurrencyCacheTTL, cachedSource[map[string]float64].cached)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"EUR": 0.085, "USD": 0.093}, rates)
+
+	rates, _, err = exchangeRates(ctx, client, "NOK", []string{"USD", "NOK"}, utils.CurrencyCacheTTL, cachedSource[map[string]float64].cached)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"USD": 0.093, "NOK": 1}, rates)
+	assert.Equal(t, 1, calls["NOK"], "the second dashboard is served from the cached table")
+}
+
+func TestExchangeRates_CrossRatesFromEUR(t *testing.T) {
+	calls := startRateTables(t, map[string]string{
+		"NOK": `{"EUR": 0.1}`,
+		"EUR": `{"NOK": 10, "USD": 1.1, "XAU": 0.5, "KES": 140}`,
+	})
+	ctx := context.Background()
+	client := httpclient.NewClient()
+
+	// USD and XAU are missing from the NOK table; ZZZ is quoted by neither
+	rates, _, err := exchangeRates(ctx, client, "NOK", []string{"EUR", "USD", "XAU", "ZZZ"}, utils.CurrencyCacheTTL, cachedSource[map[string]float64].live)
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, rates["EUR"])
+	assert.InDelta(t, 0.11, rates["USD"], 1e-9)
+	assert.InDelta(t, 0.05, rates["XAU"], 1e-9)
+	assert.NotContains(t, rates, "ZZZ")
+
+	// KES is not quoted as a base at all, so every rate comes from the cached EUR table
+	rates, _, err = exchangeRates(ctx, client, "KES", []string{"USD", "EUR"}, utils.CurrencyCacheTTL, cachedSource[map[string]float64].cached)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.1/140, rates["USD"], 1e-9)
+	assert.InDelta(t, 1.0/140, rates["EUR"], 1e-9)
+	assert.Equal(t, 1, calls["EUR"], "the EUR table is fetched once and then read from the cache")
+}
+
+func TestExchangeRates_FailsWhenNothingCanBeDerived(t *testing.T) {
+	startRateTables(t, map[string]string{"EUR": `{"USD": 1.1}`})
+
+	_, _, err := exchangeRates(context.Background(), httpclient.NewClient(), "KES", []string{"USD"}, utils.CurrencyCacheTTL, cachedSource[map[string]float64].cached)
+	assert.Error(t, err, "KES is quoted neither as a base nor in the EUR table")
+}
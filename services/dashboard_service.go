@@ -7,34 +7,32 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/httpclient"
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
 // DashboardService defines an interface for dashboard operations.
 type DashboardService interface {
-	GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, error)
-	GetEnrichedDashboards() ([]utils.DashboardResponse, error)
+	GetPopulatedDashboardByID(ctx context.Context, id string) (*utils.PopulatedDashboardResponse, error)
+	GetEnrichedDashboards(ctx context.Context) ([]utils.DashboardResponse, error)
 }
 
 // RealDashboardService is a concrete implementation of DashboardService.
 type RealDashboardService struct{}
 
-func (r RealDashboardService) GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, error) {
-	return GetPopulatedDashboardByID(id)
+func (r RealDashboardService) GetPopulatedDashboardByID(ctx context.Context, id string) (*utils.PopulatedDashboardResponse, error) {
+	return GetPopulatedDashboardByID(ctx, id)
 }
 
-func (r RealDashboardService) GetEnrichedDashboards() ([]utils.DashboardResponse, error) {
-	return GetEnrichedDashboards()
+func (r RealDashboardService) GetEnrichedDashboards(ctx context.Context) ([]utils.DashboardResponse, error) {
+	return GetEnrichedDashboards(ctx)
 }
 
 // GetPopulatedDashboardByID builds a full dashboard response by enriching a config with live data.
-func GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, error) {
-	ctx := context.Background()
-
+// Fetched data is cached; if an upstream API fails, recent cached data is served and marked as stale.
+func GetPopulatedDashboardByID(ctx context.Context, id string) (*utils.PopulatedDashboardResponse, error) {
 	// Step 1: Retrieve dashboard config from Firestore
-	config, fetchErr := db.GetDashboardConfigByID(ctx, id)
+	config, fetchErr := GetActiveDashboardConfig(ctx, id)
 	if fetchErr != nil {
 		return nil, fmt.Errorf("%s: %w", utils.ErrFetchConfig, fetchErr)
 	}
@@ -46,11 +44,16 @@ func GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, er
 		ISOCode: config.ISOCode,
 	}
 
-	// Step 3: Fetch country information from API
-	countryInfo, countryErr := fetchCountryInfo(client, config.ISOCode)
+	// Step 3: Fetch country information from API; each data type honours the dashboard's maxAge if stricter than its TTL
+	maxAges := featureMaxAges(config.Features)
+	countryMaxAge := stricterMaxAge(utils.CountryCacheTTL, maxAges.Country)
+	countryInfo, countryFreshness, countryErr := countrySource(client, config.ISOCode, countryMaxAge).live(ctx)
 	if countryErr != nil {
 		return nil, fmt.Errorf("%s: %w", utils.ErrInvalidCountryResp, countryErr)
 	}
+	if countryFreshness.Stale {
+		resp.Staleness = markStale(resp.Staleness, countryFreshness.StoredAt)
+	}
 
 	features := utils.PopulatedFeatures{}
 
@@ -79,16 +82,21 @@ func GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, er
 
 	// Step 8: If weather data is needed and coordinates are available, fetch it
 	if (config.Features.Temperature || config.Features.Precipitation) && features.Coordinates != nil {
-		weather, weatherErr := fetchWeather(client, features.Coordinates.Latitude, features.Coordinates.Longitude)
+		weatherMaxAge := stricterMaxAge(utils.WeatherCacheTTL, maxAges.Weather)
+		source := weatherSource(client, features.Coordinates.Latitude, features.Coordinates.Longitude, weatherMaxAge)
+		weather, weatherFreshness, weatherErr := source.live(ctx)
 		if weatherErr != nil {
 			return nil, fmt.Errorf("%s: %w", utils.ErrFetchWeather, weatherErr)
 		}
+		if weatherFreshness.Stale {
+			resp.Staleness = markStale(resp.Staleness, weatherFreshness.StoredAt)
+		}
 
 		// Step 9: Add temperature if requested, trigger LOW_TEMP webhook if under 0°C
 		if config.Features.Temperature {
 			features.Temperature = weather.Temperature
 			if weather.Temperature < 0 {
-				TriggerWebhooks(utils.EventLowTemp, config.ISOCode)
+				TriggerWebhooks(ctx, utils.EventLowTemp, config.ISOCode)
 			}
 		}
 
@@ -100,10 +108,19 @@ func GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, er
 
 	// Step 11: If currency data is requested, fetch exchange rates
 	if len(config.Features.TargetCurrencies) > 0 {
-		rates, currencyErr := fetchCurrencyRates(client, countryInfo.Currencies, config.Features.TargetCurrencies)
+		base := baseCurrency(countryInfo.Currencies)
+		if base == "" {
+			return nil, fmt.Errorf("%s: %s", utils.ErrFetchCurrency, utils.ErrNoBaseCurrency)
+		}
+		currencyMaxAge := stricterMaxAge(utils.CurrencyCacheTTL, maxAges.Currency)
+		rates, currencyFreshness, currencyErr := exchangeRates(ctx, client, base, config.Features.TargetCurrencies,
+			currencyMaxAge, cachedSource[map[string]float64].live)
 		if currencyErr != nil {
 			return nil, fmt.Errorf("%s: %w", utils.ErrFetchCurrency, currencyErr)
 		}
+		if currencyFreshness.Stale {
+			resp.Staleness = markStale(resp.Staleness, currencyFreshness.StoredAt)
+		}
 		features.TargetCurrencies = rates
 	}
 
@@ -112,30 +129,52 @@ func GetPopulatedDashboardByID(id string) (*utils.PopulatedDashboardResponse, er
 	resp.LastRetrieval = utils.CurrentTimestamp()
 
 	// Step 13: Trigger INVOKE webhook for dashboard access
-	TriggerWebhooks(utils.EventInvoke, config.ISOCode)
+	TriggerWebhooks(ctx, utils.EventInvoke, config.ISOCode)
 	return resp, nil
 }
 
 // fetchCountryInfo retrieves country metadata from the external REST Countries API.
-func fetchCountryInfo(client *httpclient.Client, isoCode string) (utils.CountryInfoResponse, error) {
+func fetchCountryInfo(ctx context.Context, client *httpclient.Client, isoCode string) (utils.CountryInfoResponse, error) {
+	info, _, err := revalidateCountryInfo(ctx, client, isoCode, nil)
+	return info, err
+}
+
+// revalidateCountryInfo is fetchCountryInfo refreshing a cached copy, if there is one. The copy's validators
+// are sent along, and if REST Countries reports the country unchanged, the copy is returned with notModified
+// set instead of downloading it again. Fetched data keeps the response's validators for the next refresh.
+func revalidateCountryInfo(ctx context.Context, client *httpclient.Client, isoCode string, cached *utils.CountryInfoResponse) (info utils.CountryInfoResponse, notModified bool, err error) {
+	var validators utils.Validators
+	if cached != nil && cached.Validators != nil {
+		validators = *cached.Validators
+	}
+
 	url := utils.RESTCountriesAPI + utils.RESTCountriesByAlpha + strings.ToUpper(isoCode)
-	body, getErr := client.Get(url)
+	resp, getErr := client.GetConditional(ctx, url, validators)
 	if getErr != nil {
-		return utils.CountryInfoResponse{}, fmt.Errorf("%s: %w", utils.ErrFetchCountry, getErr)
+		return utils.CountryInfoResponse{}, false, fmt.Errorf("%s: %w", utils.ErrFetchCountry, getErr)
+	}
+	if resp.NotModified {
+		info = *cached
+		info.Validators = &resp.Validators
+		return info, true, nil
 	}
 
 	var countries []utils.CountryInfoResponse
-	if decodeErr := json.Unmarshal(body, &countries); decodeErr != nil || len(countries) == 0 {
-		return utils.CountryInfoResponse{}, fmt.Errorf("%s: %w", utils.ErrInvalidCountryResp, decodeErr)
+	if decodeErr := json.Unmarshal(resp.Body, &countries); decodeErr != nil || len(countries) == 0 {
+		return utils.CountryInfoResponse{}, false, fmt.Errorf("%s: %w", utils.ErrInvalidCountryResp, decodeErr)
 	}
 
-	return countries[0], nil
+	info = countries[0]
+	if resp.Validators != (utils.Validators{}) {
+		info.Validators = &resp.Validators
+	}
+	return info, false, nil
 }
 
 // fetchWeather retrieves current weather metrics (temperature and precipitation) for the provided coordinates.
-func fetchWeather(client *httpclient.Client, lat, lon float64) (utils.WeatherData, error) {
+func fetchWeather(ctx context.Context, client *httpclient.Client, lat, lon float64) (utils.WeatherData, error) {
 	url := fmt.Sprintf(utils.OpenMeteoWeatherURLFmt, utils.OpenMeteoAPI, utils.OpenMeteoForecast, lat, lon)
-	body, weatherErr := client.Get(url)
+	body, weatherErr := client.Get(ctx, url)
 	if weatherErr != nil {
 		return utils.WeatherData{}, fmt.Errorf("%s: %w", utils.ErrFetchWeather, weatherErr)
 	}
@@ -157,21 +196,11 @@ func fetchWeather(client *httpclient.Client, lat, lon float64) (utils.WeatherDat
 	}, nil
 }
 
-// fetchCurrencyRates retrieves and filters currency exchange rates based on a base and target currencies.
-func fetchCurrencyRates(client *httpclient.Client, baseCurrencies map[string]utils.CurrencyDetails, targets []string) (map[string]float64, error) {
-	var base string
-	for k := range baseCurrencies {
-		base = k
-		break
-	}
-	if base == "" {
-		return nil, fmt.Errorf(utils.ErrNoBaseCurrency)
-	}
-
-	targetStr := strings.Join(targets, ",")
-	url := fmt.Sprintf("https://api.frankfurter.app/latest?from=%s&to=%s", base, targetStr)
+// fetchRateTable retrieves every exchange rate the currency API quotes from base.
+func fetchRateTable(ctx context.Context, client *httpclient.Client, base string) (map[string]float64, error) {
+	url := fmt.Sprintf(utils.CurrencyRateTableURLFmt, strings.TrimSuffix(utils.CurrencyAPI, "/"), base)
 
-	body, err := client.Get(url)
+	body, err := client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", utils.ErrFetchCurrency, err)
 	}
@@ -3,9 +3,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/httpclient"
@@ -18,7 +21,7 @@ import (
 )
 
 func TestMain(m *testing.M) {
-	testsetup.InitTestFirebase()
+	testsetup.InitTestStore()
 	clearTestCacheCollections()
 	os.Exit(m.Run())
 }
@@ -27,10 +30,7 @@ func clearTestCacheCollections() {
 	ctx := context.Background()
 	collections := []string{"country_cache", "weather_cache", "currency_cache"}
 	for _, col := range collections {
-		docs, _ := db.GetClient().Collection(col).Documents(ctx).GetAll()
-		for _, doc := range docs {
-			_, _ = doc.Ref.Delete(ctx)
-		}
+		_, _ = db.PurgeCacheEntries(ctx, col, time.Now())
 	}
 }
 
@@ -38,17 +38,18 @@ func TestGetPopulatedDashboardByID(t *testing.T) {
 	testID := "dash-test-service"
 	ctx := context.Background()
 
-	_, err := db.GetClient().Collection("dashboard_configs").Doc(testID).Set(ctx, map[string]interface{}{
-		"country": "Mockland",
-		"isoCode": "NO",
-		"features": map[string]interface{}{
-			"capital":          true,
-			"coordinates":      true,
-			"population":       true,
-			"area":             true,
-			"temperature":      true,
-			"precipitation":    true,
-			"targetCurrencies": []string{"USD", "EUR"},
+	err := db.UpdateDashboardConfig(ctx, utils.DashboardConfig{
+		ID:      testID,
+		Country: "Mockland",
+		ISOCode: "NO",
+		Features: utils.FeatureConfig{
+			Capital:          true,
+			Coordinates:      true,
+			Population:       true,
+			Area:             true,
+			Temperature:      true,
+			Precipitation:    true,
+			TargetCurrencies: []string{"USD", "EUR"},
 		},
 	})
 	if err != nil {
@@ -96,7 +97,7 @@ func TestGetPopulatedDashboardByID(t *testing.T) {
 		utils.CurrencyAPI = originalCurrencyAPI
 	}()
 
-	resp, err := GetPopulatedDashboardByID(testID)
+	resp, err := GetPopulatedDashboardByID(context.Background(), testID)
 	if err != nil {
 		t.Fatalf("Failed to get populated dashboard: %v", err)
 	}
@@ -123,7 +124,7 @@ func TestFetchCountryInfo(t *testing.T) {
 	defer server.Close()
 
 	utils.RESTCountriesAPI = server.URL
-	info, err := fetchCountryInfo(client, "XYZ")
+	info, err := fetchCountryInfo(context.Background(), client, "XYZ")
 	if err != nil {
 		t.Fatalf("Error fetching country info: %v", err)
 	}
@@ -145,7 +146,7 @@ func TestFetchWeather(t *testing.T) {
 	defer server.Close()
 
 	utils.OpenMeteoAPI = server.URL
-	weather, err := fetchWeather(client, 60.0, 10.0)
+	weather, err := fetchWeather(context.Background(), client, 60.0, 10.0)
 	if err != nil {
 		t.Fatalf("Error fetching weather: %v", err)
 	}
@@ -154,13 +155,13 @@ func TestFetchWeather(t *testing.T) {
 	}
 }
 
-func TestFetchCurrencyRates(t *testing.T) {
+func TestFetchRateTable(t *testing.T) {
 	client := httpclient.NewClient()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{
 			"rates": {
-			"EUR": 0.9,
-			"NOK": 10.5
+			"USD": 1.23,
+			"EUR": 0.98
   }
 }
 `))
@@ -168,8 +169,7 @@ func TestFetchCurrencyRates(t *testing.T) {
 	defer server.Close()
 
 	utils.CurrencyAPI = server.URL
-	currencies := map[string]utils.CurrencyDetails{"NOK": {Name: "Krone"}}
-	rates, err := fetchCurrencyRates(client, currencies, []string{"USD", "EUR"})
+	rates, err := fetchRateTable(context.Background(), client, "NOK")
 	if err != nil {
 		t.Fatalf("Error fetching currency rates: %v", err)
 	}
@@ -180,3 +180,40 @@ func TestFetchCurrencyRates(t *testing.T) {
 		t.Errorf("Expected EUR=0.98, got: %f", rates["EUR"])
 	}
 }
+
+func TestGetPopulatedDashboardByID_CancelledRequestCancelsUpstream(t *testing.T) {
+	testID := "dash-test-cancel"
+	ctx := context.Background()
+	if err := db.UpdateDashboardConfig(ctx, utils.DashboardConfig{
+		ID:       testID,
+		ISOCode:  "CXL",
+		Features: utils.FeatureConfig{Capital: true},
+	}); err != nil {
+		t.Fatalf("Failed to seed test config: %v", err)
+	}
+	defer func() { _ = db.DeleteDashboardConfig(ctx, testID) }()
+
+	upstreamCancelled := make(chan struct{})
+	var once sync.Once
+	countryStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		once.Do(func() { close(upstreamCancelled) })
+	}))
+	defer countryStub.Close()
+	originalCountryAPI := utils.RESTCountriesAPI
+	utils.RESTCountriesAPI = countryStub.URL
+	defer func() { utils.RESTCountriesAPI = originalCountryAPI }()
+
+	requestCtx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := GetPopulatedDashboardByID(requestCtx, testID)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the dashboard to fail with the request's cancellation, got: %v", err)
+	}
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the upstream request to be cancelled with the client's request")
+	}
+}
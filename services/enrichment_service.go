@@ -4,20 +4,15 @@ package services
 import (
 	"context"
 	"fmt"
-	"time"
 
-	"github.com/amundfpl/Assignment-2/cache"
-	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/httpclient"
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
 // GetEnrichedDashboards fetches and enriches all dashboard configs with live data.
 // It adds country, weather, and currency info using cache where available.
-func GetEnrichedDashboards() ([]utils.DashboardResponse, error) {
-	ctx := context.Background()
-
-	configs, configFetchErr := db.GetAllDashboardConfigs(ctx)
+func GetEnrichedDashboards(ctx context.Context) ([]utils.DashboardResponse, error) {
+	configs, configFetchErr := ListDashboardConfigs(ctx, false)
 	if configFetchErr != nil {
 		return nil, fmt.Errorf("%s: %w", utils.ErrFetchAllConfigs, configFetchErr)
 	}
@@ -33,19 +28,19 @@ func GetEnrichedDashboards() ([]utils.DashboardResponse, error) {
 		}
 
 		// Step 1: Enrich with country-level info (capital, coords, etc.)
-		countryInfo, enrichCountryErr := enrichCountryData(client, cfg, &resp)
+		countryInfo, enrichCountryErr := enrichCountryData(ctx, client, cfg, &resp)
 		if enrichCountryErr != nil {
 			return nil, fmt.Errorf("%s: %w", utils.ErrEnrichCountry, enrichCountryErr)
 		}
 
 		// Step 2: Enrich with weather info (temperature, precipitation)
-		enrichWeatherErr := enrichWeatherData(client, cfg, &resp)
+		enrichWeatherErr := enrichWeatherData(ctx, client, cfg, &resp)
 		if enrichWeatherErr != nil {
 			return nil, fmt.Errorf("%s: %w", utils.ErrEnrichWeather, enrichWeatherErr)
 		}
 
 		// Step 3: Enrich with currency exchange data
-		enrichCurrencyErr := enrichCurrencyData(client, cfg, countryInfo, &resp)
+		enrichCurrencyErr := enrichCurrencyData(ctx, client, cfg, countryInfo, &resp)
 		if enrichCurrencyErr != nil {
 			return nil, fmt.Errorf("%s: %w", utils.ErrEnrichCurrency, enrichCurrencyErr)
 		}
@@ -58,23 +53,22 @@ func GetEnrichedDashboards() ([]utils.DashboardResponse, error) {
 
 // enrichCountryData enriches a dashboard with capital, coordinates, population, and area info.
 // Attempts cache first, otherwise fetches from external API and stores to cache.
-func enrichCountryData(client *httpclient.Client, cfg utils.DashboardConfig, resp *utils.DashboardResponse) (utils.CountryInfoResponse, error) {
+// Cached data older than the country TTL, or the dashboard's own maxAge if stricter, is stale:
+// it is served while it is refreshed in the background, and marked in resp.
+func enrichCountryData(ctx context.Context, client *httpclient.Client, cfg utils.DashboardConfig, resp *utils.DashboardResponse) (utils.CountryInfoResponse, error) {
 	if !(cfg.Features.Capital || cfg.Features.Coordinates || cfg.Features.Population || cfg.Features.Area) {
 		return utils.CountryInfoResponse{}, nil // Nothing to enrich
 	}
 
-	cached, cacheHitErr := cache.GetCachedCountryInfo(context.Background(), cfg.ISOCode, 24*time.Hour)
-	if cacheHitErr == nil {
-		syncCountryFields(cfg, resp, *cached)
-		return *cached, nil
+	maxAge := stricterMaxAge(utils.CountryCacheTTL, featureMaxAges(cfg.Features).Country)
+	countryInfo, freshness, countryErr := countrySource(client, cfg.ISOCode, maxAge).cached(ctx)
+	if countryErr != nil {
+		return utils.CountryInfoResponse{}, countryErr
 	}
-
-	countryInfo, countryFetchErr := fetchCountryInfo(client, cfg.ISOCode)
-	if countryFetchErr != nil {
-		return utils.CountryInfoResponse{}, countryFetchErr
+	if freshness.Stale {
+		resp.Staleness = markStale(resp.Staleness, freshness.StoredAt)
 	}
 
-	_ = cache.SaveCountryInfoToCache(context.Background(), cfg.ISOCode, countryInfo)
 	syncCountryFields(cfg, resp, countryInfo)
 	return countryInfo, nil
 }
@@ -97,29 +91,20 @@ func syncCountryFields(cfg utils.DashboardConfig, resp *utils.DashboardResponse,
 }
 
 // enrichWeatherData adds temperature and precipitation values using cache or a fresh API call.
-func enrichWeatherData(client *httpclient.Client, cfg utils.DashboardConfig, resp *utils.DashboardResponse) error {
+func enrichWeatherData(ctx context.Context, client *httpclient.Client, cfg utils.DashboardConfig, resp *utils.DashboardResponse) error {
 	if !(cfg.Features.Temperature || cfg.Features.Precipitation) {
 		return nil // Nothing to enrich
 	}
 
-	key := cache.WeatherCacheKey(resp.Latitude, resp.Longitude)
-	cached, cacheErr := cache.GetCachedWeather(context.Background(), key, 2*time.Hour)
-	if cacheErr == nil {
-		if cfg.Features.Temperature {
-			resp.Temperature = cached.Temperature
-		}
-		if cfg.Features.Precipitation {
-			resp.Precipitation = cached.Precipitation
-		}
-		return nil
+	maxAge := stricterMaxAge(utils.WeatherCacheTTL, featureMaxAges(cfg.Features).Weather)
+	weather, freshness, weatherErr := weatherSource(client, resp.Latitude, resp.Longitude, maxAge).cached(ctx)
+	if weatherErr != nil {
+		return weatherErr
 	}
-
-	weather, weatherFetchErr := fetchWeather(client, resp.Latitude, resp.Longitude)
-	if weatherFetchErr != nil {
-		return weatherFetchErr
+	if freshness.Stale {
+		resp.Staleness = markStale(resp.Staleness, freshness.StoredAt)
 	}
 
-	_ = cache.SaveWeatherToCache(context.Background(), key, weather)
 	if cfg.Features.Temperature {
 		resp.Temperature = weather.Temperature
 	}
@@ -130,35 +115,26 @@ func enrichWeatherData(client *httpclient.Client, cfg utils.DashboardConfig, res
 }
 
 // enrichCurrencyData attaches exchange rate information to a dashboard response.
-func enrichCurrencyData(client *httpclient.Client, cfg utils.DashboardConfig, countryInfo utils.CountryInfoResponse, resp *utils.DashboardResponse) error {
+func enrichCurrencyData(ctx context.Context, client *httpclient.Client, cfg utils.DashboardConfig, countryInfo utils.CountryInfoResponse, resp *utils.DashboardResponse) error {
 	if len(cfg.Features.TargetCurrencies) == 0 {
 		return nil // Nothing to enrich
 	}
 
-	var base string
-	for currency := range countryInfo.Currencies {
-		base = currency
-		break
-	}
+	base := baseCurrency(countryInfo.Currencies)
 	if base == "" {
 		return fmt.Errorf(utils.ErrNoBaseCurrency)
 	}
 
-	ctx := context.Background()
-	key := cache.CurrencyCacheKey(base, cfg.Features.TargetCurrencies)
-
-	cached, cacheErr := cache.GetCachedCurrencyRates(ctx, key, 12*time.Hour)
-	if cacheErr == nil {
-		resp.ExchangeRates = cached
-		return nil
+	maxAge := stricterMaxAge(utils.CurrencyCacheTTL, featureMaxAges(cfg.Features).Currency)
+	rates, freshness, currencyErr := exchangeRates(ctx, client, base, cfg.Features.TargetCurrencies,
+		maxAge, cachedSource[map[string]float64].cached)
+	if currencyErr != nil {
+		return currencyErr
 	}
-
-	rates, currencyFetchErr := fetchCurrencyRates(client, countryInfo.Currencies, cfg.Features.TargetCurrencies)
-	if currencyFetchErr != nil {
-		return currencyFetchErr
+	if freshness.Stale {
+		resp.Staleness = markStale(resp.Staleness, freshness.StoredAt)
 	}
 
-	_ = cache.SaveCurrencyRatesToCache(ctx, key, rates)
 	resp.ExchangeRates = rates
 	return nil
 }
@@ -38,7 +38,7 @@ func TestGetEnrichedDashboards(t *testing.T) {
 	})
 
 	// Cache currency
-	currencyKey := cache.CurrencyCacheKey(currency, []string{"USD", "EUR"})
+	currencyKey := cache.CurrencyCacheKey(currency)
 	_ = cache.SaveCurrencyRatesToCache(context.Background(), currencyKey, map[string]float64{
 		"USD": 1.23,
 		"EUR": 0.95,
@@ -65,7 +65,7 @@ func TestGetEnrichedDashboards(t *testing.T) {
 		ISOCode: config.ISOCode,
 	}
 
-	cInfo, err := enrichCountryData(client, config, &resp)
+	cInfo, err := enrichCountryData(context.Background(), client, config, &resp)
 	if err != nil {
 		t.Fatalf("enrichCountryData failed: %v", err)
 	}
@@ -73,7 +73,7 @@ func TestGetEnrichedDashboards(t *testing.T) {
 		t.Errorf("Expected capital TestCity, got %s", resp.Capital)
 	}
 
-	err = enrichWeatherData(client, config, &resp)
+	err = enrichWeatherData(context.Background(), client, config, &resp)
 	if err != nil {
 		t.Fatalf("enrichWeatherData failed: %v", err)
 	}
@@ -81,7 +81,7 @@ func TestGetEnrichedDashboards(t *testing.T) {
 		t.Errorf("Expected temperature -3.5, got %f", resp.Temperature)
 	}
 
-	err = enrichCurrencyData(client, config, cInfo, &resp)
+	err = enrichCurrencyData(context.Background(), client, config, cInfo, &resp)
 	if err != nil {
 		t.Fatalf("enrichCurrencyData failed: %v", err)
 	}
@@ -151,7 +151,7 @@ func TestEnrichWeatherData_WithoutCache(t *testing.T) {
 	defer weatherServer.Close()
 	utils.OpenMeteoAPI = weatherServer.URL
 
-	err := enrichWeatherData(client, cfg, resp)
+	err := enrichWeatherData(context.Background(), client, cfg, resp)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -189,7 +189,7 @@ func TestEnrichCurrencyData_WithoutCache(t *testing.T) {
 		},
 	}
 
-	err := enrichCurrencyData(client, cfg, countryInfo, resp)
+	err := enrichCurrencyData(context.Background(), client, cfg, countryInfo, resp)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
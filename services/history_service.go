@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// recordRevision stores an immutable snapshot of config after a successful write.
+// previous is the config before the write (nil on creation) and is used to compute the diff.
+// Failures are logged rather than returned, since the write itself has already succeeded.
+func recordRevision(ctx context.Context, event string, previous *utils.DashboardConfig, config utils.DashboardConfig) {
+	revision := utils.DashboardRevision{
+		DashboardID: config.ID,
+		Revision:    config.Version,
+		Event:       event,
+		Timestamp:   time.Now().Format(utils.TimestampLayout),
+		Config:      config,
+		Diff:        diffDashboardConfigs(previous, &config),
+	}
+	if err := db.SaveRevision(ctx, revision); err != nil {
+		log.Printf(utils.ErrSaveRevision, revision.Revision, revision.DashboardID, err)
+	}
+}
+
+// diffDashboardConfigs lists the user-editable fields that differ between two configs,
+// sorted by dotted field path. A nil old config reports every non-empty field as new.
+func diffDashboardConfigs(old, new *utils.DashboardConfig) []utils.FieldChange {
+	before := flattenDashboardConfig(old)
+	after := flattenDashboardConfig(new)
+
+	changes := []utils.FieldChange{}
+	for field, newValue := range after {
+		oldValue, existed := before[field]
+		if !reflect.DeepEqual(oldValue, newValue) && (existed || !isZeroJSONValue(newValue)) {
+			changes = append(changes, utils.FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	for field, oldValue := range before {
+		if _, stillPresent := after[field]; !stillPresent {
+			changes = append(changes, utils.FieldChange{Field: field, Old: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flattenDashboardConfig converts a config into a map of dotted JSON paths to leaf values,
+// leaving out bookkeeping fields (ID, timestamp, version) that change on every write.
+func flattenDashboardConfig(config *utils.DashboardConfig) map[string]interface{} {
+	flat := map[string]interface{}{}
+	if config == nil {
+		return flat
+	}
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return flat
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return flat
+	}
+	delete(fields, utils.KeyID)
+	delete(fields, utils.KeyLastChange)
+	delete(fields, utils.KeyVersion)
+	delete(fields, utils.KeySchemaVersion)
+
+	flattenInto(flat, "", fields)
+	return flat
+}
+
+// flattenInto walks nested JSON objects, writing leaves under prefix-joined keys.
+func flattenInto(dest map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for key, value := range fields {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenInto(dest, path, nested)
+			continue
+		}
+		dest[path] = value
+	}
+}
+
+// isZeroJSONValue reports whether a decoded JSON value is empty (false, "", 0, null or []).
+func isZeroJSONValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if list, ok := value.([]interface{}); ok {
+		return len(list) == 0
+	}
+	return reflect.ValueOf(value).IsZero()
+}
+
+// GetDashboardHistory returns every recorded revision of a dashboard, oldest first.
+// Returns an error wrapping db.ErrNotFound if the dashboard neither exists nor has history,
+// or belongs to another tenant.
+func GetDashboardHistory(ctx context.Context, id string) ([]utils.DashboardRevision, error) {
+	revisions, err := db.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		if _, getErr := getOwnedDashboardConfig(ctx, id); getErr != nil {
+			return nil, fmt.Errorf(utils.ErrConfigNotFoundByID, getErr)
+		}
+	}
+	if len(revisions) > 0 && revisions[len(revisions)-1].Config.TenantID != TenantFromContext(ctx) {
+		return nil, fmt.Errorf(utils.ErrConfigNotFoundByID, db.ErrNotFound)
+	}
+	return revisions, nil
+}
+
+// GetDashboardRevision returns a single revision of a dashboard.
+// Returns an error wrapping db.ErrNotFound if the revision does not exist.
+func GetDashboardRevision(ctx context.Context, id string, revision int64) (*utils.DashboardRevision, error) {
+	found, err := db.GetRevision(ctx, id, revision)
+	if err == nil && found.Config.TenantID != TenantFromContext(ctx) {
+		err = db.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrRevisionNotFound, revision, id, err)
+	}
+	return found, nil
+}
+
+// RollbackDashboardConfig restores the configuration recorded in a past revision, leaving the registration
+// live even if that revision trashed it. The rollback is itself a new write (and revision); ifMatch is the request's If-Match header.
+// Triggers a CHANGE webhook upon success.
+func RollbackDashboardConfig(ctx context.Context, id string, revision int64, ifMatch string) (map[string]string, error) {
+	target, err := GetDashboardRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := writeDashboardConfig(ctx, id, ifMatch, utils.RevisionEventRollback, true, func(*utils.DashboardConfig) utils.DashboardConfig {
+		config := target.Config
+		config.DeletedAt = "" // A DELETE revision records the trashed config; rolling back to it keeps the registration live
+		return config
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	TriggerWebhooks(ctx, utils.EventChange, restored.ISOCode)
+
+	return map[string]string{
+		utils.KeyID:         id,
+		utils.KeyLastChange: restored.LastChange,
+		utils.KeyVersion:    strconv.FormatInt(restored.Version, 10),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDashboardConfigs(t *testing.T) {
+	old := &utils.DashboardConfig{ID: "a", Country: "Norway", Version: 1,
+		Features: utils.FeatureConfig{Capital: true, TargetCurrencies: []string{"EUR"}}}
+	updated := &utils.DashboardConfig{ID: "a", Country: "Norway", Version: 2, LastChange: "now",
+		Features: utils.FeatureConfig{Temperature: true, TargetCurrencies: []string{"EUR", "USD"}}}
+
+	diff := diffDashboardConfigs(old, updated)
+	require.Len(t, diff, 3)
+	assert.Equal(t, utils.FieldChange{Field: "features.capital", Old: true, New: false}, diff[0])
+	assert.Equal(t, "features.targetCurrencies", diff[1].Field)
+	assert.Equal(t, utils.FieldChange{Field: "features.temperature", Old: false, New: true}, diff[2])
+
+	// Creation only reports fields that were actually set
+	created := diffDashboardConfigs(nil, old)
+	assert.Len(t, created, 3)
+}
+
+func TestRevisionHistoryAndRollback(t *testing.T) {
+	ctx := context.Background()
+	id := "history-test"
+
+	_, err := UpdateDashboardConfig(ctx, id, []byte(`{"country": "Norway", "isoCode": "NO"}`), "")
+	require.NoError(t, err)
+	_, err = PatchDashboardConfig(ctx, id, map[string]interface{}{utils.KeyCountry: "Sweden"}, "")
+	require.NoError(t, err)
+
+	history, err := GetDashboardHistory(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, utils.EventChange, history[0].Event)
+	assert.Equal(t, utils.EventPatch, history[1].Event)
+	assert.Equal(t, []utils.FieldChange{{Field: utils.KeyCountry, Old: "Norway", New: "Sweden"}}, history[1].Diff)
+
+	result, err := RollbackDashboardConfig(ctx, id, 1, utils.VersionETag(2))
+	require.NoError(t, err)
+	assert.Equal(t, "3", result[utils.KeyVersion])
+
+	restored, err := db.GetDashboardConfigByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Norway", restored.Country)
+
+	rollback, err := GetDashboardRevision(ctx, id, 3)
+	require.NoError(t, err)
+	assert.Equal(t, utils.RevisionEventRollback, rollback.Event)
+
+	_, err = RollbackDashboardConfig(ctx, id, 1, utils.VersionETag(2))
+	assert.True(t, errors.Is(err, db.ErrVersionConflict))
+
+	_, err = RollbackDashboardConfig(ctx, id, 99, "")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	_, err = GetDashboardHistory(ctx, "missing-dashboard")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+}
+
+func TestRollbackToDeleteRevisionKeepsRegistrationLive(t *testing.T) {
+	ctx := context.Background()
+	id := "history-rollback-delete"
+
+	_, err := UpdateDashboardConfig(ctx, id, []byte(`{"country": "Norway", "isoCode": "NO"}`), "")
+	require.NoError(t, err)
+	require.NoError(t, DeleteRegistrationByID(ctx, id, ""))
+	_, err = RestoreRegistrationByID(ctx, id, "")
+	require.NoError(t, err)
+
+	deleted, err := GetDashboardRevision(ctx, id, 2)
+	require.NoError(t, err)
+	require.Equal(t, utils.EventDelete, deleted.Event)
+	require.NotEmpty(t, deleted.Config.DeletedAt)
+
+	_, err = RollbackDashboardConfig(ctx, id, 2, "")
+	require.NoError(t, err)
+
+	restored, err := GetActiveDashboardConfig(ctx, id)
+	require.NoError(t, err, "the rollback must not move the registration back into the trash")
+	assert.Empty(t, restored.DeletedAt)
+	assert.Equal(t, "Norway", restored.Country)
+}
@@ -12,18 +12,29 @@ import (
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
-// TriggerWebhooks looks up and notifies all webhooks registered for a specific event and country.
-// It builds a JSON payload with the event info and sends it to each webhook URL via HTTP POST.
-func TriggerWebhooks(event, country string) {
-	ctx := context.Background()
+// TriggerWebhooks looks up and notifies the calling tenant's webhooks registered for a specific event
+// and country. It builds a JSON payload with the event info and sends it to each webhook URL via HTTP POST.
+// The event has already happened, so delivery goes on if the caller's ctx is cancelled; each delivery
+// gets utils.WebhookTimeout instead.
+func TriggerWebhooks(ctx context.Context, event, country string) {
+	ctx = context.WithoutCancel(ctx)
 
 	// Fetch all webhooks that match the event and country (including wildcards).
-	matchingWebhooks, fetchErr := db.GetMatchingWebhooks(ctx, event, country)
+	candidates, fetchErr := db.GetMatchingWebhooks(ctx, event, country)
 	if fetchErr != nil {
 		fmt.Printf(utils.ErrFetchWebhooks, fetchErr)
 		return
 	}
 
+	// Only deliver to webhooks owned by the tenant that caused the event
+	tenantID := TenantFromContext(ctx)
+	var matchingWebhooks []utils.Webhook
+	for _, webhook := range candidates {
+		if webhook.TenantID == tenantID {
+			matchingWebhooks = append(matchingWebhooks, webhook)
+		}
+	}
+
 	fmt.Printf(utils.MsgFoundWebhooks, len(matchingWebhooks), event, country)
 
 	// Loop through the matched webhooks and trigger each one
@@ -46,19 +57,59 @@ func TriggerWebhooks(event, country string) {
 		fmt.Printf(utils.MsgSendingWebhook, webhook.URL)
 
 		// Send HTTP POST
-		resp, postErr := http.Post(webhook.URL, utils.ContentTypeJSON, bytes.NewBuffer(jsonBody))
+		status, postErr := deliverWebhook(ctx, webhook.URL, jsonBody)
 		if postErr != nil {
 			fmt.Printf(utils.ErrSendWebhook, webhook.ID, postErr)
 			continue
 		}
-		defer utils.CloseBody(resp.Body)
 
 		// Log response
-		fmt.Printf(utils.MsgWebhookStatus, webhook.ID, resp.Status)
+		fmt.Printf(utils.MsgWebhookStatus, webhook.ID, status)
+	}
+}
+
+// deliverWebhook POSTs a JSON payload to url within utils.WebhookTimeout and returns the response status.
+func deliverWebhook(ctx context.Context, url string, payload []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.WebhookTimeout)
+	defer cancel()
+
+	req, buildErr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if buildErr != nil {
+		return "", buildErr
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	resp, postErr := http.DefaultClient.Do(req)
+	if postErr != nil {
+		return "", postErr
 	}
+	defer utils.CloseBody(resp.Body)
+	return resp.Status, nil
 }
 
-// DeleteWebhook removes a webhook with the specified ID from the Firestore database.
+// RegisterWebhook stores a webhook owned by the calling tenant and returns its generated ID.
+func RegisterWebhook(ctx context.Context, webhook utils.Webhook) (string, error) {
+	webhook.TenantID = TenantFromContext(ctx)
+	return db.SaveWebhook(ctx, webhook)
+}
+
+// GetWebhookByID retrieves a webhook of the calling tenant.
+// Webhooks of other tenants are reported as db.ErrNotFound.
+func GetWebhookByID(ctx context.Context, id string) (*utils.Webhook, error) {
+	webhook, err := db.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.TenantID != TenantFromContext(ctx) {
+		return nil, db.ErrNotFound
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook of the calling tenant from the database.
+// Returns db.ErrNotFound if it does not exist or belongs to another tenant.
 func DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := GetWebhookByID(ctx, id); err != nil {
+		return err
+	}
 	return db.DeleteWebhook(ctx, id)
 }
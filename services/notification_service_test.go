@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
 )
 
 type mockWebhook struct {
@@ -34,15 +35,18 @@ func TestTriggerWebhooks(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Insert a test webhook into Firestore
-	db.GetClient().Collection("webhooks").Doc("test-webhook").Set(context.Background(), map[string]interface{}{
-		"id":      "test-webhook",
-		"event":   "INVOKE",
-		"country": "NO",
-		"url":     server.URL,
+	// Insert a test webhook into the store
+	hookID, err := db.SaveWebhook(context.Background(), utils.Webhook{
+		Event:   "INVOKE",
+		Country: "NO",
+		URL:     server.URL,
 	})
+	if err != nil {
+		t.Fatalf("Failed to save test webhook: %v", err)
+	}
+	defer db.DeleteWebhook(context.Background(), hookID)
 
-	TriggerWebhooks("INVOKE", "NO")
+	TriggerWebhooks(context.Background(), "INVOKE", "NO")
 
 	if len(received) == 0 {
 		t.Fatal("Expected webhook to be triggered but got none")
@@ -55,8 +59,8 @@ func TestTriggerWebhooks(t *testing.T) {
 	if got["country"] != "NO" {
 		t.Errorf("Expected country 'NO', got %s", got["country"])
 	}
-	if got["id"] != "test-webhook" {
-		t.Errorf("Expected ID 'test-webhook', got %s", got["id"])
+	if got["id"] != hookID {
+		t.Errorf("Expected ID '%s', got %s", hookID, got["id"])
 	}
 	if got["time"] == "" {
 		t.Error("Expected non-empty timestamp")
@@ -67,12 +71,10 @@ func TestDeleteWebhook(t *testing.T) {
 	ctx := context.Background()
 
 	// Insert a test webhook
-	id := "delete-me"
-	_, _ = db.GetClient().Collection("webhooks").Doc(id).Set(ctx, map[string]interface{}{
-		"id":      id,
-		"event":   "DELETE",
-		"country": "NO",
-		"url":     "http://example.com",
+	id, _ := db.SaveWebhook(ctx, utils.Webhook{
+		Event:   "DELETE",
+		Country: "NO",
+		URL:     "http://example.com",
 	})
 
 	err := DeleteWebhook(ctx, id)
@@ -81,8 +83,7 @@ func TestDeleteWebhook(t *testing.T) {
 	}
 
 	// Confirm deletion
-	doc, err := db.GetClient().Collection("webhooks").Doc(id).Get(ctx)
-	if err == nil && doc.Exists() {
+	if _, err := db.GetWebhookByID(ctx, id); err == nil {
 		t.Error("Expected webhook to be deleted, but it still exists")
 	}
 }
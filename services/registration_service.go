@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/amundfpl/Assignment-2/db"
@@ -15,7 +16,7 @@ import (
 
 // RegisterDashboardConfig processes a registration payload, resolves the country name if needed,
 // stores the config in Firestore, and triggers a webhook for the REGISTER event.
-func RegisterDashboardConfig(payload []byte) (map[string]string, error) {
+func RegisterDashboardConfig(ctx context.Context, payload []byte) (map[string]string, error) {
 	var request utils.RegistrationRequest
 
 	if err := json.Unmarshal(payload, &request); err != nil {
@@ -27,46 +28,63 @@ func RegisterDashboardConfig(payload []byte) (map[string]string, error) {
 		return nil, errors.New(utils.ErrMissingCountryOrISOCode)
 	}
 
-	client := httpclient.NewClient()
-	countryName := request.Country
-
 	// Resolve country name from ISOCode if needed
-	if countryName == "" {
-		resolvedName, err := getCountryNameByISO(client, request.ISOCode)
-		if err != nil {
-			return nil, err
-		}
-		countryName = resolvedName
-	}
-
-	// Construct the dashboard configuration
-	config := utils.DashboardConfig{
-		Country:    countryName,
-		ISOCode:    request.ISOCode,
-		Features:   request.Features,
-		LastChange: time.Now().Format(utils.TimestampLayout),
+	countryName, err := resolveCountryName(ctx, httpclient.NewClient(), request.Country, request.ISOCode)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store config in Firestore
-	id, err := db.SaveDashboardConfig(context.Background(), config)
+	// Construct and store the dashboard configuration
+	config, err := createDashboardConfig(ctx, utils.DashboardConfig{
+		Country:  countryName,
+		ISOCode:  request.ISOCode,
+		Features: request.Features,
+	})
 	if err != nil {
-		return nil, fmt.Errorf(utils.ErrFirestoreSaveFailed, err)
+		return nil, err
 	}
 
 	// Trigger webhook for registration event
-	TriggerWebhooks(utils.EventRegister, config.ISOCode)
+	TriggerWebhooks(ctx, utils.EventRegister, config.ISOCode)
 
 	return map[string]string{
-		utils.KeyID:         id,
+		utils.KeyID:         config.ID,
 		utils.KeyLastChange: config.LastChange,
+		utils.KeyVersion:    strconv.FormatInt(config.Version, 10),
 	}, nil
 }
 
+// resolveCountryName returns country, or looks the name up by ISO code if country is empty.
+func resolveCountryName(ctx context.Context, client *httpclient.Client, country, isoCode string) (string, error) {
+	if country != "" {
+		return country, nil
+	}
+	return getCountryNameByISO(ctx, client, isoCode)
+}
+
+// createDashboardConfig stores config as a new registration of the calling tenant with a generated ID
+// and version 1, and records its first revision. Webhooks are left to the caller.
+func createDashboardConfig(ctx context.Context, config utils.DashboardConfig) (utils.DashboardConfig, error) {
+	config.LastChange = time.Now().Format(utils.TimestampLayout)
+	config.Version = 1
+	config.DeletedAt = ""
+	config.TenantID = TenantFromContext(ctx)
+
+	id, err := db.SaveDashboardConfig(ctx, config)
+	if err != nil {
+		return config, fmt.Errorf(utils.ErrFirestoreSaveFailed, err)
+	}
+
+	config.ID = id
+	recordRevision(ctx, utils.EventRegister, nil, config)
+	return config, nil
+}
+
 // getCountryNameByISO queries the REST Countries API using an ISO code and returns the full country name.
-func getCountryNameByISO(client *httpclient.Client, isoCode string) (string, error) {
+func getCountryNameByISO(ctx context.Context, client *httpclient.Client, isoCode string) (string, error) {
 	url := fmt.Sprintf("%s/alpha/%s", utils.RESTCountriesAPI, isoCode)
 
-	responseData, err := client.Get(url)
+	responseData, err := client.Get(ctx, url)
 	if err != nil {
 		return "", fmt.Errorf(utils.ErrRESTCountryFetchFailed, err)
 	}
@@ -84,62 +102,117 @@ func getCountryNameByISO(client *httpclient.Client, isoCode string) (string, err
 	return name, nil
 }
 
+// writeDashboardConfig performs an optimistic read-modify-write of the configuration stored under id.
+// mutate receives the current config (nil if it does not exist) and returns the config to store.
+// ifMatch is the client's If-Match header: when set, a mismatch fails with db.ErrVersionConflict;
+// when empty, a lost race against a concurrent writer is retried up to utils.MaxWriteAttempts times.
+// Every successful write is recorded as a revision tagged with event. Soft-deleted configs are
+// treated as missing, except by RESTORE which in turn only accepts soft-deleted configs. Configs of
+// another tenant are always treated as missing, so they can be neither read nor overwritten.
+func writeDashboardConfig(ctx context.Context, id, ifMatch, event string, mustExist bool,
+	mutate func(current *utils.DashboardConfig) utils.DashboardConfig) (*utils.DashboardConfig, error) {
+
+	for attempt := 1; ; attempt++ {
+		current, getErr := db.GetDashboardConfigByID(ctx, id)
+		exists := getErr == nil
+		if getErr != nil && (mustExist || !errors.Is(getErr, db.ErrNotFound)) {
+			return nil, fmt.Errorf(utils.ErrConfigNotFoundByID, getErr)
+		}
+
+		var currentVersion int64
+		if exists && current.TenantID != TenantFromContext(ctx) {
+			return nil, fmt.Errorf(utils.ErrConfigNotFoundByID, db.ErrNotFound)
+		}
+		if exists {
+			currentVersion = current.Version
+			if err := checkTrashState(current, event); err != nil {
+				return nil, err
+			}
+		}
+		if !utils.IfMatchSatisfied(ifMatch, currentVersion, exists) {
+			return nil, fmt.Errorf(utils.ErrPreconditionFailed, utils.VersionETag(currentVersion), db.ErrVersionConflict)
+		}
+
+		updated := mutate(current)
+		updated.ID = id
+		updated.TenantID = TenantFromContext(ctx)
+		updated.LastChange = time.Now().Format(utils.TimestampLayout)
+
+		writeErr := db.UpdateDashboardConfigIfVersion(ctx, updated, currentVersion)
+		if writeErr == nil {
+			updated.Version = currentVersion + 1
+			recordRevision(ctx, event, current, updated)
+			return &updated, nil
+		}
+		if !errors.Is(writeErr, db.ErrVersionConflict) {
+			return nil, fmt.Errorf(utils.ErrFirestoreUpdateFailed, writeErr)
+		}
+		if ifMatch != "" || attempt >= utils.MaxWriteAttempts {
+			return nil, fmt.Errorf(utils.ErrPreconditionFailed, utils.VersionETag(currentVersion), writeErr)
+		}
+	}
+}
+
 // UpdateDashboardConfig replaces the entire dashboard configuration with the provided update.
-// Triggers a CHANGE webhook upon success.
-func UpdateDashboardConfig(ctx context.Context, id string, body []byte) (map[string]string, error) {
-	var updatedConfig utils.DashboardConfig
+// ifMatch is the request's If-Match header (may be empty). Triggers a CHANGE webhook upon success.
+func UpdateDashboardConfig(ctx context.Context, id string, body []byte, ifMatch string) (map[string]string, error) {
+	var replacement utils.DashboardConfig
 
-	if err := json.Unmarshal(body, &updatedConfig); err != nil {
+	if err := json.Unmarshal(body, &replacement); err != nil {
 		return nil, fmt.Errorf(utils.ErrInvalidJSONBodyFormat, err)
 	}
 
-	updatedConfig.ID = id
-	updatedConfig.LastChange = time.Now().Format(utils.TimestampLayout)
+	// Trashing goes through DELETE and schema versions are set by the store, so the body cannot set either
+	replacement.DeletedAt = ""
+	replacement.SchemaVersion = 0
 
-	if err := db.UpdateDashboardConfig(ctx, updatedConfig); err != nil {
-		return nil, fmt.Errorf(utils.ErrFirestoreUpdateFailed, err)
+	updatedConfig, err := writeDashboardConfig(ctx, id, ifMatch, utils.EventChange, false, func(*utils.DashboardConfig) utils.DashboardConfig {
+		return replacement
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	TriggerWebhooks(utils.EventChange, updatedConfig.ISOCode)
+	TriggerWebhooks(ctx, utils.EventChange, updatedConfig.ISOCode)
 
 	return map[string]string{
 		utils.KeyID:         id,
 		utils.KeyLastChange: updatedConfig.LastChange,
+		utils.KeyVersion:    strconv.FormatInt(updatedConfig.Version, 10),
 	}, nil
 }
 
 // PatchDashboardConfig applies a partial update to an existing dashboard configuration.
-// It allows updating the country, ISO code, and individual feature flags. Triggers a PATCH webhook.
-func PatchDashboardConfig(ctx context.Context, id string, patch map[string]interface{}) (map[string]string, error) {
-	existingConfig, err := db.GetDashboardConfigByID(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf(utils.ErrConfigNotFoundByID, err)
-	}
-
-	// Update top-level fields if present in patch
-	if country, ok := patch[utils.KeyCountry].(string); ok {
-		existingConfig.Country = country
-	}
-	if isoCode, ok := patch[utils.KeyISOCode].(string); ok {
-		existingConfig.ISOCode = isoCode
-	}
-
-	// Apply patch to nested feature configuration
-	if features, ok := patch[utils.KeyFeatures].(map[string]interface{}); ok {
-		applyFeaturePatch(&existingConfig.Features, features)
-	}
-
-	existingConfig.LastChange = time.Now().Format(utils.TimestampLayout)
+// It allows updating the country, ISO code, and individual feature flags.
+// ifMatch is the request's If-Match header (may be empty). Triggers a PATCH webhook.
+func PatchDashboardConfig(ctx context.Context, id string, patch map[string]interface{}, ifMatch string) (map[string]string, error) {
+	existingConfig, err := writeDashboardConfig(ctx, id, ifMatch, utils.EventPatch, true, func(current *utils.DashboardConfig) utils.DashboardConfig {
+		patched := *current
+
+		// Update top-level fields if present in patch
+		if country, ok := patch[utils.KeyCountry].(string); ok {
+			patched.Country = country
+		}
+		if isoCode, ok := patch[utils.KeyISOCode].(string); ok {
+			patched.ISOCode = isoCode
+		}
 
-	if err := db.UpdateDashboardConfig(ctx, *existingConfig); err != nil {
-		return nil, fmt.Errorf(utils.ErrFirestoreUpdateFailed, err)
+		// Apply patch to nested feature configuration
+		if features, ok := patch[utils.KeyFeatures].(map[string]interface{}); ok {
+			applyFeaturePatch(&patched.Features, features)
+		}
+		return patched
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	TriggerWebhooks(utils.EventPatch, existingConfig.ISOCode)
+	TriggerWebhooks(ctx, utils.EventPatch, existingConfig.ISOCode)
 
 	return map[string]string{
 		utils.KeyID:         existingConfig.ID,
 		utils.KeyLastChange: existingConfig.LastChange,
+		utils.KeyVersion:    strconv.FormatInt(existingConfig.Version, 10),
 	}, nil
 }
 
@@ -174,21 +247,55 @@ func applyFeaturePatch(dest *utils.FeatureConfig, patch map[string]interface{})
 		}
 		dest.TargetCurrencies = currencies
 	}
+	if v, ok := patch[utils.KeyMaxAge].(map[string]interface{}); ok {
+		applyMaxAgePatch(dest, v)
+	}
 
 	log.Println("applyFeaturePatch - updated config:", dest)
 }
 
-// DeleteRegistrationByID removes a dashboard config by ID and triggers a DELETE webhook event.
-func DeleteRegistrationByID(ctx context.Context, id string) error {
-	config, err := db.GetDashboardConfigByID(ctx, id)
-	if err != nil {
-		return fmt.Errorf(utils.ErrConfigNotFoundByID, err)
+// applyMaxAgePatch updates the per data type age limits present in patch. A limit of "0s" removes it;
+// values that are not valid durations are ignored, like mistyped feature flags.
+func applyMaxAgePatch(dest *utils.FeatureConfig, patch map[string]interface{}) {
+	maxAge := featureMaxAges(*dest)
+	limits := map[string]*utils.Duration{
+		utils.KeyCountry:  &maxAge.Country,
+		utils.KeyWeather:  &maxAge.Weather,
+		utils.KeyCurrency: &maxAge.Currency,
+	}
+	for key, limit := range limits {
+		raw, present := patch[key]
+		if !present {
+			continue
+		}
+		encoded, _ := json.Marshal(raw)
+		var parsed utils.Duration
+		if parsed.UnmarshalJSON(encoded) == nil {
+			*limit = parsed
+		}
+	}
+
+	if maxAge == (utils.FeatureMaxAge{}) {
+		dest.MaxAge = nil
+		return
 	}
+	dest.MaxAge = &maxAge
+}
 
-	if err := db.DeleteDashboardConfig(ctx, id); err != nil {
-		return fmt.Errorf(utils.ErrFirestoreDeleteFailed, err)
+// DeleteRegistrationByID soft-deletes a dashboard config by ID and triggers a DELETE webhook event.
+// The config is marked with deletedAt and hard-deleted by the trash purge after utils.TrashRetention.
+// ifMatch is the request's If-Match header (may be empty); the delete only succeeds if the
+// config has not changed since it was read.
+func DeleteRegistrationByID(ctx context.Context, id string, ifMatch string) error {
+	deleted, err := writeDashboardConfig(ctx, id, ifMatch, utils.EventDelete, true, func(current *utils.DashboardConfig) utils.DashboardConfig {
+		trashed := *current
+		trashed.DeletedAt = time.Now().Format(utils.TimestampLayout)
+		return trashed
+	})
+	if err != nil {
+		return err
 	}
 
-	TriggerWebhooks(utils.EventDelete, config.ISOCode)
+	TriggerWebhooks(ctx, utils.EventDelete, deleted.ISOCode)
 	return nil
 }
@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/amundfpl/Assignment-2/db"
 	"github.com/amundfpl/Assignment-2/utils"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestRegisterDashboardConfig(t *testing.T) {
@@ -24,7 +26,7 @@ func TestRegisterDashboardConfig(t *testing.T) {
 		"features": {"temperature": true, "capital": true}
 	}`)
 
-	resp, err := RegisterDashboardConfig(payload)
+	resp, err := RegisterDashboardConfig(context.Background(), payload)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -49,7 +51,7 @@ func TestUpdateDashboardConfig(t *testing.T) {
 		"features": {"capital": true, "temperature": true}
 	}`)
 
-	resp, err := UpdateDashboardConfig(ctx, id, body)
+	resp, err := UpdateDashboardConfig(ctx, id, body, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -73,7 +75,7 @@ func TestPatchDashboardConfig(t *testing.T) {
 			"area":    true,
 		},
 	}
-	resp, err := PatchDashboardConfig(ctx, id, patch)
+	resp, err := PatchDashboardConfig(ctx, id, patch, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -91,13 +93,81 @@ func TestDeleteRegistrationByID(t *testing.T) {
 	}
 	id, _ := db.SaveDashboardConfig(ctx, cfg)
 
-	err := DeleteRegistrationByID(ctx, id)
+	err := DeleteRegistrationByID(ctx, id, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
 
-	_, err = db.GetDashboardConfigByID(ctx, id)
+	_, err = GetActiveDashboardConfig(ctx, id)
 	if err == nil {
 		t.Fatal("Expected error getting deleted config, got none")
 	}
+
+	// Deletes are soft: the document stays in the trash until purged
+	trashed, err := db.GetDashboardConfigByID(ctx, id)
+	if err != nil || trashed.DeletedAt == "" {
+		t.Fatalf("Expected soft-deleted config with deletedAt, got %+v (%v)", trashed, err)
+	}
+}
+
+func TestPatchDashboardConfig_IfMatch(t *testing.T) {
+	ctx := context.Background()
+	id, _ := db.SaveDashboardConfig(ctx, utils.DashboardConfig{Country: "EtagLand", ISOCode: "ETG", Version: 1})
+
+	patch := map[string]interface{}{"country": "FirstWriter"}
+	resp, err := PatchDashboardConfig(ctx, id, patch, `"1"`)
+	if err != nil {
+		t.Fatalf("Expected matching If-Match to succeed, got: %v", err)
+	}
+	if resp["version"] != "2" {
+		t.Errorf("Expected version 2, got %s", resp["version"])
+	}
+
+	// A second client still holding the old ETag must be rejected
+	_, err = PatchDashboardConfig(ctx, id, map[string]interface{}{"country": "SecondWriter"}, `"1"`)
+	if !errors.Is(err, db.ErrVersionConflict) {
+		t.Fatalf("Expected version conflict, got: %v", err)
+	}
+
+	config, _ := db.GetDashboardConfigByID(ctx, id)
+	if config.Country != "FirstWriter" {
+		t.Errorf("Expected first write to survive, got %s", config.Country)
+	}
+}
+
+func TestApplyFeaturePatch_MaxAge(t *testing.T) {
+	features := utils.FeatureConfig{Temperature: true}
+
+	applyFeaturePatch(&features, map[string]interface{}{
+		"maxAge": map[string]interface{}{"weather": "15m", "currency": "not a duration"},
+	})
+	if features.MaxAge == nil || features.MaxAge.Weather != utils.Duration(15*time.Minute) {
+		t.Fatalf("Expected a 15m weather maxAge, got: %+v", features.MaxAge)
+	}
+	if features.MaxAge.Currency != 0 {
+		t.Errorf("Expected the invalid currency maxAge to be ignored, got: %v", features.MaxAge.Currency)
+	}
+
+	applyFeaturePatch(&features, map[string]interface{}{"maxAge": map[string]interface{}{"weather": "0s"}})
+	if features.MaxAge != nil {
+		t.Errorf("Expected clearing the only limit to remove maxAge, got: %+v", features.MaxAge)
+	}
+}
+
+func TestUpdateDashboardConfig_IgnoresDeletedAt(t *testing.T) {
+	ctx := context.Background()
+	id, _ := db.SaveDashboardConfig(ctx, utils.DashboardConfig{Country: "PutLand", ISOCode: "PUT"})
+
+	body := []byte(`{"country": "PutLand", "isoCode": "PUT", "deletedAt": "20250101 00:00", "schemaVersion": 99}`)
+	if _, err := UpdateDashboardConfig(ctx, id, body, ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	stored, err := GetActiveDashboardConfig(ctx, id)
+	if err != nil {
+		t.Fatalf("Expected the registration to stay live after a PUT, got: %v", err)
+	}
+	if stored.SchemaVersion == 99 {
+		t.Errorf("Expected the store to set the schema version, got %d", stored.SchemaVersion)
+	}
 }
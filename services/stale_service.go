@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// cachedSource ties one kind of enrichment data to its cache entry and upstream API.
+type cachedSource[T any] struct {
+	name   string // Cache collection and key; identifies in-flight fetches and background refreshes
+	maxAge time.Duration
+	lookup func(ctx context.Context, maxAge time.Duration) (T, cache.Freshness, error)
+	fetch  func(ctx context.Context) (T, error)
+	save   func(ctx context.Context, data T) error
+}
+
+// refreshing holds the names of sources with a background refresh in flight.
+var refreshing sync.Map
+
+// cached serves fresh cached data, or stale cached data while it is refreshed in the background.
+// On a miss it fetches the data upstream and caches it.
+func (s cachedSource[T]) cached(ctx context.Context) (T, cache.Freshness, error) {
+	data, freshness, lookupErr := s.lookup(ctx, s.maxAge)
+	if lookupErr == nil {
+		if freshness.Stale {
+			s.refreshInBackground()
+		}
+		return data, freshness, nil
+	}
+
+	fetched, fetchErr := s.fetchAndSave(ctx)
+	if fetchErr != nil {
+		return fetched, cache.Freshness{}, fetchErr
+	}
+	return fetched, cache.Freshness{StoredAt: time.Now()}, nil
+}
+
+// live fetches the data upstream and caches it. If the upstream fails, cached data up to
+// utils.CacheStaleGrace past maxAge is served instead, marked as stale.
+func (s cachedSource[T]) live(ctx context.Context) (T, cache.Freshness, error) {
+	fetched, fetchErr := s.fetchAndSave(ctx)
+	if fetchErr == nil {
+		return fetched, cache.Freshness{StoredAt: time.Now()}, nil
+	}
+
+	data, freshness, lookupErr := s.lookup(ctx, s.maxAge)
+	if lookupErr != nil {
+		return fetched, cache.Freshness{}, fetchErr
+	}
+	log.Printf(utils.MsgServingStale, s.name, fetchErr)
+	freshness.Stale = true // Not what the caller asked for, however recent
+	return data, freshness, nil
+}
+
+// refreshInBackground fetches and caches the data unless a refresh is already running.
+func (s cachedSource[T]) refreshInBackground() {
+	if _, running := refreshing.LoadOrStore(s.name, true); running {
+		return
+	}
+	go func() {
+		defer refreshing.Delete(s.name)
+
+		// The request that triggered the refresh may be gone, so it gets its own context. Its deadline bounds
+		// the refresh, queueing for the rate limit included, so that it cannot hold up later refreshes of the entry
+		ctx, cancel := context.WithTimeout(context.Background(), utils.RequestTimeout)
+		defer cancel()
+		if _, fetchErr := s.fetchAndSave(ctx); fetchErr != nil {
+			log.Printf(utils.ErrBackgroundRefresh, s.name, fetchErr)
+		}
+	}()
+}
+
+// fetchAndSave fetches the data upstream and caches it. Concurrent calls for the same cache entry
+// share one upstream call and one cache write; the call is cancelled once every caller's ctx has ended.
+func (s cachedSource[T]) fetchAndSave(ctx context.Context) (T, error) {
+	return coalesce(ctx, s.name, func(ctx context.Context) (T, error) {
+		fetched, fetchErr := s.fetch(ctx)
+		if fetchErr != nil {
+			return fetched, fetchErr
+		}
+		// Callers that joined this call rely on the write, even if the first caller goes away
+		if saveErr := s.save(context.WithoutCancel(ctx), fetched); saveErr != nil {
+			log.Printf(utils.ErrCacheSaveFailed, s.name, saveErr)
+		}
+		return fetched, nil
+	})
+}
+
+// countrySource is the cached REST Countries lookup for an ISO code. A cached copy, even an expired one,
+// is revalidated rather than downloaded again; if it has not changed, saving it again extends its lifetime.
+func countrySource(client *httpclient.Client, isoCode string, maxAge time.Duration) cachedSource[utils.CountryInfoResponse] {
+	name := utils.CountryCacheCollection + "/" + cache.CountryCacheKey(isoCode)
+	return cachedSource[utils.CountryInfoResponse]{
+		name:   name,
+		maxAge: maxAge,
+		lookup: func(ctx context.Context, maxAge time.Duration) (utils.CountryInfoResponse, cache.Freshness, error) {
+			info, freshness, err := cache.LookupCountryInfo(ctx, isoCode, maxAge)
+			if err != nil {
+				return utils.CountryInfoResponse{}, freshness, err
+			}
+			return *info, freshness, nil
+		},
+		fetch: func(ctx context.Context) (utils.CountryInfoResponse, error) {
+			cached, _ := cache.PeekCountryInfo(ctx, isoCode) // Without a copy the data is simply downloaded
+			info, notModified, err := revalidateCountryInfo(ctx, client, isoCode, cached)
+			if notModified {
+				log.Printf(utils.MsgNotModified, name)
+			}
+			return info, err
+		},
+		save: func(ctx context.Context, info utils.CountryInfoResponse) error {
+			return cache.SaveCountryInfoToCache(ctx, isoCode, info)
+		},
+	}
+}
+
+// weatherSource is the cached Open-Meteo lookup for a pair of coordinates. A miss may be answered
+// by a nearby cached cell; fetched weather is cached for the coordinates' own cell.
+func weatherSource(client *httpclient.Client, lat, lon float64, maxAge time.Duration) cachedSource[utils.WeatherData] {
+	key := cache.WeatherCacheKey(lat, lon)
+	return cachedSource[utils.WeatherData]{
+		name:   utils.WeatherCacheCollection + "/" + key,
+		maxAge: maxAge,
+		lookup: func(ctx context.Context, maxAge time.Duration) (utils.WeatherData, cache.Freshness, error) {
+			weather, freshness, err := cache.LookupNearestWeather(ctx, lat, lon, maxAge)
+			if err != nil {
+				return utils.WeatherData{}, freshness, err
+			}
+			return *weather, freshness, nil
+		},
+		fetch: func(ctx context.Context) (utils.WeatherData, error) { return fetchWeather(ctx, client, lat, lon) },
+		save: func(ctx context.Context, weather utils.WeatherData) error {
+			return cache.SaveWeatherToCache(ctx, key, weather)
+		},
+	}
+}
+
+// featureMaxAges returns the dashboard's own data age limits, all zero if it sets none.
+func featureMaxAges(features utils.FeatureConfig) utils.FeatureMaxAge {
+	if features.MaxAge == nil {
+		return utils.FeatureMaxAge{}
+	}
+	return *features.MaxAge
+}
+
+// stricterMaxAge returns ttl, or the dashboard's limit for the same data if that is set and shorter.
+func stricterMaxAge(ttl time.Duration, limit utils.Duration) time.Duration {
+	if limit > 0 && time.Duration(limit) < ttl {
+		return time.Duration(limit)
+	}
+	return ttl
+}
+
+// baseCurrency picks the currency exchange rates are quoted from, or "" if the country has none.
+func baseCurrency(currencies map[string]utils.CurrencyDetails) string {
+	for currency := range currencies {
+		return currency
+	}
+	return ""
+}
+
+// markStale records stale data fetched upstream at storedAt, keeping the oldest such data in the indicator.
+func markStale(current *utils.Staleness, storedAt time.Time) *utils.Staleness {
+	age := int64(time.Since(storedAt).Seconds())
+	if current != nil && current.AgeSeconds >= age {
+		return current
+	}
+	return &utils.Staleness{Stale: true, DataAsOf: storedAt.Format(utils.TimestampLayout), AgeSeconds: age}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// putAgedCacheEntry stores data in a cache collection as if it had been fetched age ago.
+func putAgedCacheEntry(t *testing.T, collection, key string, data interface{}, age time.Duration) {
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	require.NoError(t, db.PutCacheEntry(context.Background(), collection, utils.CacheRecord{
+		Key: key, Timestamp: time.Now().Add(-age), Data: raw,
+	}))
+	cache.ResetLocalCache()
+}
+
+func TestEnrichWeatherData_ServesStaleWhileRefreshing(t *testing.T) {
+	var calls atomic.Int32
+	weatherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"current": {"temperature_2m": 12.5, "precipitation": 0}}`))
+	}))
+	defer weatherServer.Close()
+	original := utils.OpenMeteoAPI
+	utils.OpenMeteoAPI = weatherServer.URL
+	defer func() { utils.OpenMeteoAPI = original }()
+
+	lat, lon := 33.3, float64(time.Now().UnixNano()%1000)/10.0
+	key := cache.WeatherCacheKey(lat, lon)
+	putAgedCacheEntry(t, utils.WeatherCacheCollection, key, utils.WeatherData{Temperature: 4}, 3*time.Hour)
+
+	cfg := utils.DashboardConfig{Features: utils.FeatureConfig{Temperature: true}}
+	resp := &utils.DashboardResponse{Latitude: lat, Longitude: lon}
+	require.NoError(t, enrichWeatherData(context.Background(), httpclient.NewClient(), cfg, resp))
+	assert.Equal(t, 4.0, resp.Temperature, "the stale value is served without waiting for the upstream")
+	require.NotNil(t, resp.Staleness)
+	assert.True(t, resp.Staleness.Stale)
+	assert.GreaterOrEqual(t, resp.Staleness.AgeSeconds, int64(3*time.Hour/time.Second))
+
+	assert.Eventually(t, func() bool {
+		weather, err := cache.GetCachedWeather(context.Background(), key, 2*time.Hour)
+		return err == nil && weather.Temperature == 12.5
+	}, 2*time.Second, 10*time.Millisecond, "the entry is refreshed in the background")
+	assert.Equal(t, int32(1), calls.Load())
+
+	fresh := &utils.DashboardResponse{Latitude: lat, Longitude: lon}
+	require.NoError(t, enrichWeatherData(context.Background(), httpclient.NewClient(), cfg, fresh))
+	assert.Equal(t, 12.5, fresh.Temperature)
+	assert.Nil(t, fresh.Staleness)
+}
+
+func TestCountrySource_LiveFallsBackOnUpstreamFailure(t *testing.T) {
+	countryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer countryServer.Close()
+	original := utils.RESTCountriesAPI
+	utils.RESTCountriesAPI = countryServer.URL
+	defer func() { utils.RESTCountriesAPI = original }()
+
+	ctx := context.Background()
+	client := httpclient.NewClient()
+	iso := fmt.Sprintf("S%d", time.Now().UnixNano())
+	stored := utils.CountryInfoResponse{Capital: []string{"Staleville"}}
+
+	_, _, err := countrySource(client, iso, utils.CountryCacheTTL).live(ctx)
+	assert.Error(t, err, "nothing cached to fall back to")
+
+	putAgedCacheEntry(t, utils.CountryCacheCollection, cache.CountryCacheKey(iso), stored, 25*time.Hour)
+	info, freshness, err := countrySource(client, iso, utils.CountryCacheTTL).live(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Staleville", info.Capital[0])
+	assert.True(t, freshness.Stale)
+
+	putAgedCacheEntry(t, utils.CountryCacheCollection, cache.CountryCacheKey(iso), stored, utils.CountryCacheTTL+utils.CacheStaleGrace+time.Hour)
+	_, _, err = countrySource(client, iso, utils.CountryCacheTTL).live(ctx)
+	assert.Error(t, err, "data past the grace window is not served")
+}
+
+func TestCountrySource_RevalidatesExpiredCopy(t *testing.T) {
+	var downloads, notModified atomic.Int32
+	countryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(utils.HeaderIfNoneMatch) == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set(utils.HeaderETag, `"v1"`)
+		_, _ = w.Write([]byte(`[{"capital": ["Revalidville"]}]`))
+	}))
+	defer countryServer.Close()
+	original := utils.RESTCountriesAPI
+	utils.RESTCountriesAPI = countryServer.URL
+	defer func() { utils.RESTCountriesAPI = original }()
+
+	ctx := context.Background()
+	client := httpclient.NewClient()
+	iso := fmt.Sprintf("R%d", time.Now().UnixNano())
+
+	_, _, err := countrySource(client, iso, utils.CountryCacheTTL).live(ctx)
+	require.NoError(t, err)
+	cached, err := cache.GetCachedCountryInfo(ctx, iso, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, cached.Validators, "the response's validators are cached with the data")
+	assert.Equal(t, `"v1"`, cached.Validators.ETag)
+
+	putAgedCacheEntry(t, utils.CountryCacheCollection, cache.CountryCacheKey(iso), *cached, utils.CountryCacheTTL+time.Hour)
+	info, freshness, err := countrySource(client, iso, utils.CountryCacheTTL).live(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Revalidville", info.Capital[0])
+	assert.False(t, freshness.Stale)
+	assert.Equal(t, int32(1), downloads.Load(), "the unchanged country is not downloaded again")
+	assert.Equal(t, int32(1), notModified.Load())
+
+	_, err = cache.GetCachedCountryInfo(ctx, iso, time.Hour)
+	assert.NoError(t, err, "the expired copy's lifetime is extended")
+}
+
+func TestMarkStale_KeepsOldest(t *testing.T) {
+	older := time.Now().Add(-5 * time.Hour)
+	staleness := markStale(nil, time.Now().Add(-time.Hour))
+	staleness = markStale(staleness, older)
+	staleness = markStale(staleness, time.Now().Add(-2*time.Hour))
+	assert.Equal(t, older.Format(utils.TimestampLayout), staleness.DataAsOf)
+	assert.Equal(t, int64(5*time.Hour/time.Second), staleness.AgeSeconds)
+}
+
+func TestEnrichWeatherData_HonoursStricterMaxAge(t *testing.T) {
+	var calls atomic.Int32
+	weatherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"current": {"temperature_2m": 7, "precipitation": 0}}`))
+	}))
+	defer weatherServer.Close()
+	original := utils.OpenMeteoAPI
+	utils.OpenMeteoAPI = weatherServer.URL
+	defer func() { utils.OpenMeteoAPI = original }()
+
+	lat, lon := 44.4, float64(time.Now().UnixNano()%1000)/10.0
+	key := cache.WeatherCacheKey(lat, lon)
+	putAgedCacheEntry(t, utils.WeatherCacheCollection, key, utils.WeatherData{Temperature: 4}, 30*time.Minute)
+
+	// Within the weather TTL, so fresh for a dashboard without its own limit
+	relaxed := utils.DashboardConfig{Features: utils.FeatureConfig{Temperature: true}}
+	resp := &utils.DashboardResponse{Latitude: lat, Longitude: lon}
+	require.NoError(t, enrichWeatherData(context.Background(), httpclient.NewClient(), relaxed, resp))
+	assert.Nil(t, resp.Staleness)
+	assert.Equal(t, int32(0), calls.Load())
+
+	// Too old for an operations dashboard that wants weather no older than 15 minutes
+	strict := relaxed
+	strict.Features.MaxAge = &utils.FeatureMaxAge{Weather: utils.Duration(15 * time.Minute)}
+	resp = &utils.DashboardResponse{Latitude: lat, Longitude: lon}
+	require.NoError(t, enrichWeatherData(context.Background(), httpclient.NewClient(), strict, resp))
+	require.NotNil(t, resp.Staleness)
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStricterMaxAge(t *testing.T) {
+	assert.Equal(t, 2*time.Hour, stricterMaxAge(2*time.Hour, 0), "no limit leaves the TTL")
+	assert.Equal(t, 15*time.Minute, stricterMaxAge(2*time.Hour, utils.Duration(15*time.Minute)))
+	assert.Equal(t, 2*time.Hour, stricterMaxAge(2*time.Hour, utils.Duration(3*time.Hour)), "a laxer limit is ignored")
+}
+
+func TestFeatureMaxAge_JSON(t *testing.T) {
+	var features utils.FeatureConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"temperature": true, "maxAge": {"weather": "15m"}}`), &features))
+	require.NotNil(t, features.MaxAge)
+	assert.Equal(t, utils.Duration(15*time.Minute), features.MaxAge.Weather)
+
+	encoded, err := json.Marshal(features)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"maxAge":{"weather":"15m0s"}`)
+
+	// Stores that encode the struct directly keep nanoseconds
+	require.NoError(t, json.Unmarshal([]byte(`{"maxAge": {"Currency": 3600000000000}}`), &features))
+	assert.Equal(t, utils.Duration(time.Hour), features.MaxAge.Currency)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"maxAge": {"weather": "soon"}}`), &features))
+	assert.Error(t, json.Unmarshal([]byte(`{"maxAge": {"weather": "-5m"}}`), &features))
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
@@ -18,22 +19,54 @@ var serviceStartTime = time.Now()
 // - Number of registered webhooks
 // - Service version
 // - Uptime since start
+// - Upstream calls saved by request coalescing
+// - The circuit breaker state of each third-party API
+// - The rate limit of each third-party API and how long requests queued for it
 func GetSystemStatus(ctx context.Context) utils.StatusReport {
 	return utils.StatusReport{
-		CountriesAPI:    checkService(utils.RESTCountriesAPI + utils.CountriesAlphaNorwayPath), // Valid ISO code
-		MeteoAPI:        checkService(utils.OpenMeteoAPI + utils.MeteoForecastPath),            // Valid weather test
-		CurrencyAPI:     checkService(utils.CurrencyAPI + utils.CurrencyEURToNOKPath),
+		CountriesAPI:    checkService(ctx, utils.RESTCountriesAPI+utils.CountriesAlphaNorwayPath), // Valid ISO code
+		MeteoAPI:        checkService(ctx, utils.OpenMeteoAPI+utils.MeteoForecastPath),            // Valid weather test
+		CurrencyAPI:     checkService(ctx, utils.CurrencyAPI+utils.CurrencyEURToNOKPath),
 		NotificationDB:  checkFirestore(ctx),
 		Webhooks:        db.CountWebhooks(ctx),
 		Version:         utils.StatusVersion,
 		UptimeInSeconds: int64(time.Since(serviceStartTime).Seconds()),
+		CoalescedCalls:  CoalescedCalls(),
+		CircuitBreakers: []utils.CircuitBreakerStatus{
+			dependencyBreaker(utils.DependencyCountriesAPI, utils.RESTCountriesAPI),
+			dependencyBreaker(utils.DependencyMeteoAPI, utils.OpenMeteoAPI),
+			dependencyBreaker(utils.DependencyCurrencyAPI, utils.CurrencyAPI),
+		},
+		RateLimits: []utils.RateLimitStatus{
+			dependencyRateLimit(utils.DependencyCountriesAPI, utils.RESTCountriesAPI),
+			dependencyRateLimit(utils.DependencyMeteoAPI, utils.OpenMeteoAPI),
+			dependencyRateLimit(utils.DependencyCurrencyAPI, utils.CurrencyAPI),
+		},
 	}
 }
 
+// dependencyBreaker reports the circuit breaker guarding the upstream API at baseURL.
+func dependencyBreaker(dependency, baseURL string) utils.CircuitBreakerStatus {
+	status := httpclient.BreakerStatus(baseURL)
+	status.Dependency = dependency
+	return status
+}
+
+// dependencyRateLimit reports the rate limit of the upstream API at baseURL.
+func dependencyRateLimit(dependency, baseURL string) utils.RateLimitStatus {
+	status := httpclient.RateLimitStatus(baseURL)
+	status.Dependency = dependency
+	return status
+}
+
 // checkService performs a health check against an external HTTP service.
-// Returns HTTP status code if successful, or 0 if the call fails.
-func checkService(url string) int {
-	resp, err := http.Get(url)
+// Returns HTTP status code if successful, or 503 if the call fails or ctx ends first.
+func checkService(ctx context.Context, url string) int {
+	req, buildErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if buildErr != nil {
+		return http.StatusServiceUnavailable
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return http.StatusServiceUnavailable
 	}
@@ -42,10 +75,10 @@ func checkService(url string) int {
 	return resp.StatusCode
 }
 
-// checkFirestore checks Firestore connectivity.
+// checkFirestore checks connectivity of the active storage backend.
 // Returns 200 on success, or 0 on failure.
 func checkFirestore(ctx context.Context) int {
-	if err := db.PingFirestore(ctx); err != nil {
+	if err := db.PingStore(ctx); err != nil {
 		return http.StatusServiceUnavailable
 	}
 	return http.StatusOK
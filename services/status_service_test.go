@@ -2,12 +2,15 @@ package services
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
@@ -37,8 +40,8 @@ func TestGetSystemStatus(t *testing.T) {
 	// --- Currency API Stub ---
 	currencyStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Accept both /NOK and NOK to make test agnostic to slash logic
-		if !strings.HasSuffix(r.URL.Path, "NOK") {
-			t.Errorf("Expected path ending in 'NOK', got '%s'", r.URL.Path)
+		if !strings.HasSuffix(r.URL.String(), "NOK") {
+			t.Errorf("Expected path ending in 'NOK', got '%s'", r.URL.String())
 			http.Error(w, "not found", http.StatusNotFound)
 			return
 		}
@@ -48,10 +51,10 @@ func TestGetSystemStatus(t *testing.T) {
 	utils.CurrencyAPI = currencyStub.URL + "/" // Ensures base URL ends with slash
 
 	// --- Ensure webhook exists ---
-	_, err := db.GetClient().Collection("webhooks").Doc("test-webhook").Set(ctx, map[string]any{
-		"url":     "http://example.com",
-		"event":   "INVOKE",
-		"country": "NO",
+	_, err := db.SaveWebhook(ctx, utils.Webhook{
+		URL:     "http://example.com",
+		Event:   "INVOKE",
+		Country: "NO",
 	})
 	if err != nil {
 		t.Fatalf("Failed to set test webhook: %v", err)
@@ -81,6 +84,70 @@ func TestGetSystemStatus(t *testing.T) {
 	if status.UptimeInSeconds < 0 {
 		t.Errorf("Expected positive uptime, got %d", status.UptimeInSeconds)
 	}
+	if len(status.CircuitBreakers) != 3 {
+		t.Fatalf("Expected a circuit breaker per upstream API, got %+v", status.CircuitBreakers)
+	}
+	for _, breaker := range status.CircuitBreakers {
+		if breaker.State != utils.BreakerClosed {
+			t.Errorf("Expected the %s breaker to be closed, got %s", breaker.Dependency, breaker.State)
+		}
+	}
+}
+
+func TestGetSystemStatus_ShowsTrippedBreaker(t *testing.T) {
+	ctx := context.Background()
+	httpclient.ResetBreakers(httpclient.BreakerSettings{Window: 2, MinRequests: 2, FailureRate: 0.5, Cooldown: time.Minute})
+	t.Cleanup(func() { httpclient.ResetBreakers(httpclient.DefaultBreakerSettings()) })
+
+	meteoStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer meteoStub.Close()
+	originalMeteoAPI := utils.OpenMeteoAPI
+	utils.OpenMeteoAPI = meteoStub.URL
+	t.Cleanup(func() { utils.OpenMeteoAPI = originalMeteoAPI })
+
+	if _, err := fetchWeather(context.Background(), httpclient.NewClient(), 60, 10); err == nil {
+		t.Fatal("Expected the weather fetch to fail")
+	}
+	if _, err := fetchWeather(context.Background(), httpclient.NewClient(), 60, 10); !errors.Is(err, httpclient.ErrCircuitOpen) {
+		t.Fatalf("Expected the tripped breaker to fail fast, got: %v", err)
+	}
+
+	for _, breaker := range GetSystemStatus(ctx).CircuitBreakers {
+		if breaker.Dependency == utils.DependencyMeteoAPI && breaker.State != utils.BreakerOpen {
+			t.Errorf("Expected the meteo breaker to be open, got %+v", breaker)
+		}
+	}
+}
+
+func TestGetSystemStatus_ShowsRateLimitWaits(t *testing.T) {
+	ctx := context.Background()
+	httpclient.ResetRateLimits(httpclient.RateLimit{PerSecond: 20, Burst: 1})
+	t.Cleanup(func() { httpclient.ResetRateLimits(httpclient.DefaultRateLimit()) })
+
+	meteoStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temperature_2m": 3.5, "precipitation": 0}}`))
+	}))
+	defer meteoStub.Close()
+	originalMeteoAPI := utils.OpenMeteoAPI
+	utils.OpenMeteoAPI = meteoStub.URL
+	defer func() { utils.OpenMeteoAPI = originalMeteoAPI }()
+
+	for i := 0; i < 3; i++ {
+		if _, err := fetchWeather(ctx, httpclient.NewClient(), 60, 10); err != nil {
+			t.Fatalf("Expected the weather fetch to queue and succeed, got: %v", err)
+		}
+	}
+
+	for _, limit := range GetSystemStatus(ctx).RateLimits {
+		if limit.Dependency != utils.DependencyMeteoAPI {
+			continue
+		}
+		if limit.Requests != 3 || limit.Delayed != 2 || limit.WaitTotalMs <= 0 {
+			t.Errorf("Expected two of three meteo requests to have queued, got %+v", limit)
+		}
+	}
 }
 
 func getSystemStatusWithMockedFirestore(ctx context.Context, pingFn func(context.Context) error) utils.StatusReport {
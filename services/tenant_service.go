@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrInvalidAPIKey is returned when an API key is missing or does not belong to any tenant.
+var ErrInvalidAPIKey = errors.New(utils.ErrInvalidAPIKey)
+
+// ErrMissingTenantName is returned when creating a tenant without a name.
+var ErrMissingTenantName = errors.New(utils.ErrMissingTenantName)
+
+// tenantContextKey is the context key under which the calling tenant's ID is stored.
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the given tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to. Unscoped contexts belong to the
+// default tenant "", which owns all data when authentication is disabled.
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// getOwnedDashboardConfig retrieves a dashboard config, including soft-deleted ones,
+// treating configs owned by another tenant as missing.
+func getOwnedDashboardConfig(ctx context.Context, id string) (*utils.DashboardConfig, error) {
+	config, err := db.GetDashboardConfigByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if config.TenantID != TenantFromContext(ctx) {
+		return nil, db.ErrNotFound
+	}
+	return config, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key, which is all the store ever sees.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey generates a random, URL-safe API key.
+func newAPIKey() (string, error) {
+	raw := make([]byte, utils.APIKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf(utils.ErrGenerateAPIKey, err)
+	}
+	return utils.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// CreateTenant registers a new tenant and returns it together with its first API key.
+func CreateTenant(ctx context.Context, name string) (*utils.TenantKeyResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrMissingTenantName
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := db.SaveTenant(ctx, utils.Tenant{
+		Name:      name,
+		KeyHash:   hashAPIKey(apiKey),
+		CreatedAt: time.Now().Format(utils.TimestampLayout),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &utils.TenantKeyResponse{ID: id, Name: name, APIKey: apiKey}, nil
+}
+
+// ListTenants returns every tenant ordered by ID.
+func ListTenants(ctx context.Context) ([]utils.Tenant, error) {
+	return db.GetAllTenants(ctx)
+}
+
+// GetTenant returns a single tenant, or an error wrapping db.ErrNotFound.
+func GetTenant(ctx context.Context, id string) (*utils.Tenant, error) {
+	return db.GetTenantByID(ctx, id)
+}
+
+// RotateTenantKey replaces a tenant's API key. The old key stops working immediately.
+func RotateTenantKey(ctx context.Context, id string) (*utils.TenantKeyResponse, error) {
+	tenant, err := db.GetTenantByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	tenant.KeyHash = hashAPIKey(apiKey)
+	tenant.KeyRotatedAt = time.Now().Format(utils.TimestampLayout)
+	if err := db.UpdateTenant(ctx, *tenant); err != nil {
+		return nil, err
+	}
+	return &utils.TenantKeyResponse{ID: tenant.ID, Name: tenant.Name, APIKey: apiKey}, nil
+}
+
+// DeleteTenant removes a tenant, revoking its API key. Its registrations and webhooks are kept
+// but become unreachable. Returns an error wrapping db.ErrNotFound if the tenant does not exist.
+func DeleteTenant(ctx context.Context, id string) error {
+	if _, err := db.GetTenantByID(ctx, id); err != nil {
+		return err
+	}
+	return db.DeleteTenant(ctx, id)
+}
+
+// AuthenticateTenant resolves an API key to its tenant, or returns ErrInvalidAPIKey.
+func AuthenticateTenant(ctx context.Context, apiKey string) (*utils.Tenant, error) {
+	if apiKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	tenant, err := db.GetTenantByKeyHash(ctx, hashAPIKey(apiKey))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	created, err := CreateTenant(ctx, "  acme  ")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", created.Name)
+	assert.True(t, strings.HasPrefix(created.APIKey, utils.APIKeyPrefix))
+
+	tenant, err := AuthenticateTenant(ctx, created.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, tenant.ID)
+	assert.NotContains(t, tenant.KeyHash, created.APIKey)
+
+	rotated, err := RotateTenantKey(ctx, created.ID)
+	require.NoError(t, err)
+	_, err = AuthenticateTenant(ctx, created.APIKey)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = AuthenticateTenant(ctx, rotated.APIKey)
+	assert.NoError(t, err)
+
+	require.NoError(t, DeleteTenant(ctx, created.ID))
+	_, err = AuthenticateTenant(ctx, rotated.APIKey)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.ErrorIs(t, DeleteTenant(ctx, created.ID), db.ErrNotFound)
+
+	_, err = CreateTenant(ctx, " ")
+	assert.ErrorIs(t, err, ErrMissingTenantName)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	owner := WithTenant(context.Background(), "tenant-a")
+	other := WithTenant(context.Background(), "tenant-b")
+
+	config, err := createDashboardConfig(owner, utils.DashboardConfig{Country: "Norway", ISOCode: "TNA"})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", config.TenantID)
+
+	_, err = GetActiveDashboardConfig(other, config.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	_, err = PatchDashboardConfig(other, config.ID, map[string]interface{}{utils.KeyCountry: "X"}, "")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	_, err = UpdateDashboardConfig(other, config.ID, []byte(`{"country": "X"}`), "")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	assert.True(t, errors.Is(DeleteRegistrationByID(other, config.ID, ""), db.ErrNotFound))
+	_, err = GetDashboardHistory(other, config.ID)
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	_, err = GetDashboardRevision(other, config.ID, 1)
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+
+	listed, err := ListDashboardConfigs(other, false)
+	require.NoError(t, err)
+	assert.NotContains(t, trashedIDs(listed), config.ID)
+
+	// The owner still sees the untouched config
+	unchanged, err := GetActiveDashboardConfig(owner, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Norway", unchanged.Country)
+}
+
+func TestWebhookTenantIsolation(t *testing.T) {
+	var deliveries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&deliveries, 1)
+	}))
+	defer server.Close()
+
+	owner := WithTenant(context.Background(), "hook-tenant-a")
+	other := WithTenant(context.Background(), "hook-tenant-b")
+
+	id, err := RegisterWebhook(owner, utils.Webhook{URL: server.URL, Event: utils.EventInvoke, Country: "TNW"})
+	require.NoError(t, err)
+
+	_, err = GetWebhookByID(other, id)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	assert.ErrorIs(t, DeleteWebhook(other, id), db.ErrNotFound)
+
+	// Events of another tenant never reach the webhook
+	TriggerWebhooks(other, utils.EventInvoke, "TNW")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&deliveries))
+	TriggerWebhooks(owner, utils.EventInvoke, "TNW")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&deliveries))
+
+	assert.NoError(t, DeleteWebhook(owner, id))
+}
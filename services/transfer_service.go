@@ -0,0 +1,371 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ImportOptions controls how ImportDashboardConfigs applies rows.
+type ImportOptions struct {
+	Mode     string // utils.ImportModeCreate (default) or utils.ImportModeUpsert
+	DryRun   bool   // Validate and report without writing anything
+	Webhooks bool   // Fire REGISTER/CHANGE webhooks for imported rows
+}
+
+// importRow is one parsed row: either a config or the error that prevented decoding it.
+type importRow struct {
+	config utils.DashboardConfig
+	err    error
+}
+
+// ExportContentType returns the Content-Type for an export format, or an error if it is unknown.
+func ExportContentType(format string) (string, error) {
+	switch format {
+	case utils.FormatJSON:
+		return utils.ContentTypeJSON, nil
+	case utils.FormatNDJSON:
+		return utils.ContentTypeNDJSON, nil
+	case utils.FormatCSV:
+		return utils.ContentTypeCSV, nil
+	}
+	return "", fmt.Errorf(utils.ErrUnknownFormat, format)
+}
+
+// ExportDashboardConfigs streams every live registration to w in the given format,
+// reading them one page at a time so the full set is never held in memory.
+func ExportDashboardConfigs(ctx context.Context, format string, w io.Writer) error {
+	if _, err := ExportContentType(format); err != nil {
+		return err
+	}
+
+	switch format {
+	case utils.FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		return forEachDashboardConfig(ctx, func(config utils.DashboardConfig) error {
+			return encoder.Encode(config)
+		})
+
+	case utils.FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(utils.CSVColumns); err != nil {
+			return err
+		}
+		err := forEachDashboardConfig(ctx, func(config utils.DashboardConfig) error {
+			return writer.Write(dashboardConfigToCSV(config))
+		})
+		writer.Flush()
+		if err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+
+	// JSON: a single array, written element by element
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	err := forEachDashboardConfig(ctx, func(config utils.DashboardConfig) error {
+		raw, marshalErr := json.Marshal(config)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if !first {
+			raw = append([]byte(","), raw...)
+		}
+		first = false
+		_, writeErr := w.Write(raw)
+		return writeErr
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// forEachDashboardConfig calls fn for every live registration of the calling tenant in ID order, page by page.
+func forEachDashboardConfig(ctx context.Context, fn func(utils.DashboardConfig) error) error {
+	query := db.DashboardQuery{ListOptions: db.ListOptions{Limit: utils.MaxPageLimit}, TenantID: TenantFromContext(ctx)}
+	for {
+		page, err := db.QueryDashboardConfigs(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, config := range page.Items {
+			if err := fn(config); err != nil {
+				return err
+			}
+		}
+		if page.Next == nil {
+			return nil
+		}
+		query.After = page.Next
+	}
+}
+
+// dashboardConfigToCSV flattens a config into a record matching utils.CSVColumns.
+func dashboardConfigToCSV(config utils.DashboardConfig) []string {
+	features := config.Features
+	return []string{
+		config.ID, config.Country, config.ISOCode,
+		strconv.FormatBool(features.Temperature), strconv.FormatBool(features.Precipitation),
+		strconv.FormatBool(features.Capital), strconv.FormatBool(features.Coordinates),
+		strconv.FormatBool(features.Population), strconv.FormatBool(features.Area),
+		strings.Join(features.TargetCurrencies, utils.CSVListSeparator), config.LastChange,
+	}
+}
+
+// ImportDashboardConfigs validates and applies every row of an import body, reporting the outcome
+// of each row separately. An error is only returned if the body as a whole cannot be read.
+func ImportDashboardConfigs(ctx context.Context, format string, body io.Reader, opts ImportOptions) (*utils.ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = utils.ImportModeCreate
+	}
+	if opts.Mode != utils.ImportModeCreate && opts.Mode != utils.ImportModeUpsert {
+		return nil, fmt.Errorf(utils.ErrUnknownImportMode, opts.Mode)
+	}
+
+	rows, err := parseImport(format, body)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &utils.ImportReport{DryRun: opts.DryRun, Mode: opts.Mode, Total: len(rows), Rows: []utils.ImportRowResult{}}
+	client := httpclient.NewClient()
+	for i, row := range rows {
+		result := importDashboardConfig(ctx, client, row, opts)
+		result.Row = i + 1
+
+		switch result.Status {
+		case utils.ImportStatusCreate:
+			report.Created++
+		case utils.ImportStatusUpdate:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	log.Printf(utils.MsgImportCompleted, report.Created, report.Updated, report.Failed, report.DryRun)
+	return report, nil
+}
+
+// importDashboardConfig validates a single row and, unless this is a dry run, writes it.
+func importDashboardConfig(ctx context.Context, client *httpclient.Client, row importRow, opts ImportOptions) utils.ImportRowResult {
+	failed := func(err error) utils.ImportRowResult {
+		return utils.ImportRowResult{ID: row.config.ID, Status: utils.ImportStatusFailed, Error: err.Error()}
+	}
+	if row.err != nil {
+		return failed(row.err)
+	}
+
+	config := row.config
+	if config.Country == "" && config.ISOCode == "" {
+		return failed(errors.New(utils.ErrMissingCountryOrISOCode))
+	}
+	countryName, err := resolveCountryName(ctx, client, config.Country, config.ISOCode)
+	if err != nil {
+		return failed(err)
+	}
+	config.Country = countryName
+
+	// Create mode ignores incoming IDs, so every row becomes a new registration
+	if opts.Mode == utils.ImportModeCreate || config.ID == "" {
+		if opts.DryRun {
+			return utils.ImportRowResult{Status: utils.ImportStatusCreate}
+		}
+		created, createErr := createDashboardConfig(ctx, utils.DashboardConfig{
+			Country: config.Country, ISOCode: config.ISOCode, Features: config.Features,
+		})
+		if createErr != nil {
+			return failed(createErr)
+		}
+		if opts.Webhooks {
+			TriggerWebhooks(ctx, utils.EventRegister, created.ISOCode)
+		}
+		return utils.ImportRowResult{ID: created.ID, Status: utils.ImportStatusCreate}
+	}
+
+	// Upsert by ID: replace an existing registration or create one under the given ID
+	existing, getErr := db.GetDashboardConfigByID(ctx, config.ID)
+	if getErr != nil && !errors.Is(getErr, db.ErrNotFound) {
+		return failed(getErr)
+	}
+	exists := getErr == nil
+	if exists && existing.TenantID != TenantFromContext(ctx) {
+		return failed(fmt.Errorf(utils.ErrConfigNotFoundByID, db.ErrNotFound))
+	}
+	if exists && existing.DeletedAt != "" {
+		return failed(fmt.Errorf(utils.ErrImportRowInTrash, config.ID))
+	}
+
+	status, event := utils.ImportStatusCreate, utils.EventRegister
+	if exists {
+		status, event = utils.ImportStatusUpdate, utils.EventChange
+	}
+	if opts.DryRun {
+		return utils.ImportRowResult{ID: config.ID, Status: status}
+	}
+
+	written, writeErr := writeDashboardConfig(ctx, config.ID, "", event, exists, func(*utils.DashboardConfig) utils.DashboardConfig {
+		return utils.DashboardConfig{Country: config.Country, ISOCode: config.ISOCode, Features: config.Features}
+	})
+	if writeErr != nil {
+		return failed(writeErr)
+	}
+	if opts.Webhooks {
+		TriggerWebhooks(ctx, event, written.ISOCode)
+	}
+	return utils.ImportRowResult{ID: written.ID, Status: status}
+}
+
+// parseImport decodes an import body into rows. Rows that fail to decode carry their error;
+// only problems with the body as a whole (unknown format, unreadable input, bad CSV header) fail.
+func parseImport(format string, body io.Reader) ([]importRow, error) {
+	switch format {
+	case utils.FormatJSON:
+		return parseJSONImport(body)
+	case utils.FormatNDJSON:
+		return parseNDJSONImport(body)
+	case utils.FormatCSV:
+		return parseCSVImport(body)
+	}
+	return nil, fmt.Errorf(utils.ErrUnknownFormat, format)
+}
+
+// decodeImportRow unmarshals a single JSON object into a row.
+func decodeImportRow(raw []byte) importRow {
+	var row importRow
+	if err := json.Unmarshal(raw, &row.config); err != nil {
+		row.err = fmt.Errorf(utils.ErrInvalidImportRow, err)
+	}
+	return row
+}
+
+// parseJSONImport reads a JSON array of registration objects.
+func parseJSONImport(body io.Reader) ([]importRow, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&elements); err != nil {
+		return nil, fmt.Errorf(utils.ErrReadImport, err)
+	}
+
+	rows := make([]importRow, 0, len(elements))
+	for _, element := range elements {
+		rows = append(rows, decodeImportRow(element))
+	}
+	return rows, nil
+}
+
+// parseNDJSONImport reads one registration object per line, skipping blank lines.
+func parseNDJSONImport(body io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), utils.MaxImportBytes)
+
+	var rows []importRow
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		rows = append(rows, decodeImportRow([]byte(line)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrReadImport, err)
+	}
+	return rows, nil
+}
+
+// parseCSVImport reads a CSV file whose header names a subset of utils.CSVColumns.
+// Records with the wrong number of fields become failed rows.
+func parseCSVImport(body io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrReadImport, err)
+	}
+
+	known := map[string]bool{}
+	for _, column := range utils.CSVColumns {
+		known[column] = true
+	}
+	for _, column := range header {
+		if !known[column] {
+			return nil, fmt.Errorf(utils.ErrUnknownCSVColumn, column)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil && !errors.Is(readErr, csv.ErrFieldCount) {
+			return nil, fmt.Errorf(utils.ErrReadImport, readErr)
+		}
+		if readErr != nil {
+			rows = append(rows, importRow{err: fmt.Errorf(utils.ErrInvalidImportRow, readErr)})
+			continue
+		}
+		rows = append(rows, csvRecordToRow(header, record))
+	}
+	return rows, nil
+}
+
+// csvRecordToRow maps a CSV record onto a config using the header's column names.
+func csvRecordToRow(header, record []string) importRow {
+	var row importRow
+	features := &row.config.Features
+	flags := map[string]*bool{
+		utils.KeyTemperature:   &features.Temperature,
+		utils.KeyPrecipitation: &features.Precipitation,
+		utils.KeyCapital:       &features.Capital,
+		utils.KeyCoordinates:   &features.Coordinates,
+		utils.KeyPopulation:    &features.Population,
+		utils.KeyArea:          &features.Area,
+	}
+
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		switch column {
+		case utils.KeyID:
+			row.config.ID = value
+		case utils.KeyCountry:
+			row.config.Country = value
+		case utils.KeyISOCode:
+			row.config.ISOCode = value
+		case utils.KeyTargetCurrencies:
+			for _, currency := range strings.Split(value, utils.CSVListSeparator) {
+				if currency = strings.TrimSpace(currency); currency != "" {
+					features.TargetCurrencies = append(features.TargetCurrencies, currency)
+				}
+			}
+		default:
+			flag, isFlag := flags[column]
+			if !isFlag || value == "" {
+				continue // lastChange is informational and ignored on import
+			}
+			parsed, parseErr := strconv.ParseBool(value)
+			if parseErr != nil {
+				row.err = fmt.Errorf(utils.ErrInvalidCSVBool, column, value)
+				return row
+			}
+			*flag = parsed
+		}
+	}
+	return row
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	seeded, err := createDashboardConfig(ctx, utils.DashboardConfig{Country: "Exportia", ISOCode: "EXP",
+		Features: utils.FeatureConfig{Capital: true, TargetCurrencies: []string{"EUR", "USD"}}})
+	require.NoError(t, err)
+
+	for _, format := range []string{utils.FormatJSON, utils.FormatNDJSON, utils.FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var exported bytes.Buffer
+			require.NoError(t, ExportDashboardConfigs(ctx, format, &exported))
+			assert.Contains(t, exported.String(), seeded.ID)
+
+			// Upserting an export onto the same environment updates every row in place
+			report, err := ImportDashboardConfigs(ctx, format, bytes.NewReader(exported.Bytes()),
+				ImportOptions{Mode: utils.ImportModeUpsert})
+			require.NoError(t, err)
+			assert.Zero(t, report.Failed, report.Rows)
+			assert.Zero(t, report.Created)
+			assert.Equal(t, report.Total, report.Updated)
+
+			reimported, err := db.GetDashboardConfigByID(ctx, seeded.ID)
+			require.NoError(t, err)
+			assert.Equal(t, seeded.Features, reimported.Features)
+		})
+	}
+}
+
+func TestImportDashboardConfigs_RowErrorsAndDryRun(t *testing.T) {
+	ctx := context.Background()
+	body := strings.Join([]string{
+		`{"country": "Importia", "isoCode": "IMP", "features": {"area": true}}`,
+		`{"features": {"area": true}}`,
+		`not json`,
+		``,
+		`{"id": "import-upsert-id", "country": "Upsertia", "isoCode": "UPS"}`,
+	}, "\n")
+
+	dryRun, err := ImportDashboardConfigs(ctx, utils.FormatNDJSON, strings.NewReader(body),
+		ImportOptions{Mode: utils.ImportModeUpsert, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 4, dryRun.Total)
+	assert.Equal(t, 2, dryRun.Created)
+	assert.Equal(t, 2, dryRun.Failed)
+	assert.Equal(t, utils.ImportStatusFailed, dryRun.Rows[1].Status)
+	assert.Equal(t, utils.ErrMissingCountryOrISOCode, dryRun.Rows[1].Error)
+	assert.Equal(t, 3, dryRun.Rows[2].Row)
+
+	_, err = db.GetDashboardConfigByID(ctx, "import-upsert-id")
+	assert.ErrorIs(t, err, db.ErrNotFound, "dry run must not write")
+
+	applied, err := ImportDashboardConfigs(ctx, utils.FormatNDJSON, strings.NewReader(body),
+		ImportOptions{Mode: utils.ImportModeUpsert})
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied.Created)
+	assert.Equal(t, "import-upsert-id", applied.Rows[3].ID)
+
+	upserted, err := db.GetDashboardConfigByID(ctx, "import-upsert-id")
+	require.NoError(t, err)
+	assert.Equal(t, "Upsertia", upserted.Country)
+
+	// Create mode ignores IDs and always adds new registrations
+	created, err := ImportDashboardConfigs(ctx, utils.FormatNDJSON, strings.NewReader(body), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, utils.ImportModeCreate, created.Mode)
+	assert.NotEqual(t, "import-upsert-id", created.Rows[3].ID)
+}
+
+func TestImportDashboardConfigs_CSV(t *testing.T) {
+	ctx := context.Background()
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	_ = writer.WriteAll([][]string{
+		{utils.KeyCountry, utils.KeyISOCode, utils.KeyCapital, utils.KeyTargetCurrencies},
+		{"Csvland", "CSV", "true", "EUR; NOK"},
+		{"Badbool", "BBL", "maybe", ""},
+		{"Short"},
+	})
+
+	report, err := ImportDashboardConfigs(ctx, utils.FormatCSV, &body, ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 3)
+	assert.Equal(t, utils.ImportStatusCreate, report.Rows[0].Status)
+	assert.Equal(t, utils.ImportStatusFailed, report.Rows[1].Status)
+	assert.Equal(t, utils.ImportStatusFailed, report.Rows[2].Status)
+
+	config, err := db.GetDashboardConfigByID(ctx, report.Rows[0].ID)
+	require.NoError(t, err)
+	assert.True(t, config.Features.Capital)
+	assert.Equal(t, []string{"EUR", "NOK"}, config.Features.TargetCurrencies)
+
+	_, err = ImportDashboardConfigs(ctx, utils.FormatCSV, strings.NewReader("country,colour\n"), ImportOptions{})
+	assert.Error(t, err)
+	_, err = ImportDashboardConfigs(ctx, "xml", strings.NewReader(""), ImportOptions{})
+	assert.Error(t, err)
+	_, err = ImportDashboardConfigs(ctx, utils.FormatJSON, strings.NewReader("[]"), ImportOptions{Mode: "merge"})
+	assert.Error(t, err)
+}
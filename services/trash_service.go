@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// ErrNotInTrash is returned when restoring a registration that has not been soft-deleted.
+var ErrNotInTrash = errors.New(utils.ErrRegistrationNotDeleted)
+
+// checkTrashState rejects writes to soft-deleted configs, and restores of configs that are not deleted.
+func checkTrashState(current *utils.DashboardConfig, event string) error {
+	isDeleted := current.DeletedAt != ""
+	switch {
+	case event == utils.RevisionEventRestore && !isDeleted:
+		return ErrNotInTrash
+	case event != utils.RevisionEventRestore && isDeleted:
+		return fmt.Errorf(utils.ErrConfigNotFoundByID, db.ErrNotFound)
+	}
+	return nil
+}
+
+// GetActiveDashboardConfig retrieves a dashboard config of the calling tenant,
+// treating soft-deleted configs as missing.
+func GetActiveDashboardConfig(ctx context.Context, id string) (*utils.DashboardConfig, error) {
+	config, err := getOwnedDashboardConfig(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if config.DeletedAt != "" {
+		return nil, db.ErrNotFound
+	}
+	return config, nil
+}
+
+// ListDashboardConfigs returns the calling tenant's live dashboard configs, or only the soft-deleted
+// ones if deleted is true.
+func ListDashboardConfigs(ctx context.Context, deleted bool) ([]utils.DashboardConfig, error) {
+	page, err := db.QueryDashboardConfigs(ctx, db.DashboardQuery{Deleted: deleted, TenantID: TenantFromContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// RestoreRegistrationByID moves a soft-deleted config out of the trash and triggers a CHANGE webhook.
+// ifMatch is the request's If-Match header (may be empty).
+func RestoreRegistrationByID(ctx context.Context, id string, ifMatch string) (map[string]string, error) {
+	restored, err := writeDashboardConfig(ctx, id, ifMatch, utils.RevisionEventRestore, true, func(current *utils.DashboardConfig) utils.DashboardConfig {
+		untrashed := *current
+		untrashed.DeletedAt = ""
+		return untrashed
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	TriggerWebhooks(ctx, utils.EventChange, restored.ISOCode)
+
+	return map[string]string{
+		utils.KeyID:         id,
+		utils.KeyLastChange: restored.LastChange,
+		utils.KeyVersion:    strconv.FormatInt(restored.Version, 10),
+	}, nil
+}
+
+// PurgeDeletedRegistrations hard-deletes every config of every tenant soft-deleted more than retention ago,
+// along with its revision history. Configs restored or modified concurrently are skipped. Returns the
+// number of configs removed.
+func PurgeDeletedRegistrations(ctx context.Context, retention time.Duration) (int, error) {
+	page, err := db.QueryDashboardConfigs(ctx, db.DashboardQuery{Deleted: true, AllTenants: true})
+	if err != nil {
+		return 0, err
+	}
+	trashed := page.Items
+
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+	for _, config := range trashed {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return purged, ctxErr
+		}
+		deletedAt, parseErr := time.ParseInLocation(utils.TimestampLayout, config.DeletedAt, time.Local)
+		if parseErr != nil || deletedAt.After(cutoff) {
+			continue
+		}
+		if deleteErr := db.DeleteDashboardConfigIfVersion(ctx, config.ID, config.Version); deleteErr != nil {
+			log.Printf(utils.ErrPurgeTrashEntry, config.ID, deleteErr)
+			continue
+		}
+		if deleteErr := db.DeleteRevisions(ctx, config.ID); deleteErr != nil {
+			log.Printf(utils.ErrPurgeTrashRevisions, config.ID, deleteErr)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartTrashPurgeLoop launches a background loop that hard-deletes expired soft-deleted registrations.
+// It runs every interval defined by utils.TrashPurgeInterval, keeping them for utils.TrashRetention,
+// until ctx is cancelled, which also stops a purge that is under way.
+func StartTrashPurgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(utils.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		log.Println(utils.MsgTrashPurgeStart)
+
+		purged, err := PurgeDeletedRegistrations(ctx, utils.TrashRetention)
+		if err != nil {
+			log.Printf(utils.ErrPurgeTrash, err)
+		} else {
+			log.Printf(utils.MsgTrashPurged, purged, utils.TrashRetention)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println(utils.MsgTrashPurgeStop)
+			return
+		case <-ticker.C:
+		}
+	}
+}
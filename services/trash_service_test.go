@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	id, err := db.SaveDashboardConfig(ctx, utils.DashboardConfig{Country: "TrashLand", ISOCode: "TRS", Version: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, DeleteRegistrationByID(ctx, id, ""))
+
+	live, err := ListDashboardConfigs(ctx, false)
+	require.NoError(t, err)
+	for _, config := range live {
+		assert.NotEqual(t, id, config.ID)
+	}
+	trashed, err := ListDashboardConfigs(ctx, true)
+	require.NoError(t, err)
+	assert.Contains(t, trashedIDs(trashed), id)
+
+	// Trashed registrations cannot be modified or deleted again, only restored
+	_, err = PatchDashboardConfig(ctx, id, map[string]interface{}{utils.KeyCountry: "X"}, "")
+	assert.True(t, errors.Is(err, db.ErrNotFound))
+	assert.True(t, errors.Is(DeleteRegistrationByID(ctx, id, ""), db.ErrNotFound))
+
+	result, err := RestoreRegistrationByID(ctx, id, utils.VersionETag(2))
+	require.NoError(t, err)
+	assert.Equal(t, "3", result[utils.KeyVersion])
+
+	restored, err := GetActiveDashboardConfig(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, restored.DeletedAt)
+
+	_, err = RestoreRegistrationByID(ctx, id, "")
+	assert.ErrorIs(t, err, ErrNotInTrash)
+}
+
+func TestPurgeDeletedRegistrations(t *testing.T) {
+	ctx := context.Background()
+	expired := utils.DashboardConfig{ID: "trash-expired", Country: "Old", Version: 2,
+		DeletedAt: time.Now().Add(-48 * time.Hour).Format(utils.TimestampLayout)}
+	recent := utils.DashboardConfig{ID: "trash-recent", Country: "New", Version: 2,
+		DeletedAt: time.Now().Format(utils.TimestampLayout)}
+	require.NoError(t, db.UpdateDashboardConfig(ctx, expired))
+	require.NoError(t, db.UpdateDashboardConfig(ctx, recent))
+
+	purged, err := PurgeDeletedRegistrations(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = db.GetDashboardConfigByID(ctx, expired.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	_, err = db.GetDashboardConfigByID(ctx, recent.ID)
+	assert.NoError(t, err)
+}
+
+// trashedIDs collects the IDs of a list of configs.
+func trashedIDs(configs []utils.DashboardConfig) []string {
+	var ids []string
+	for _, config := range configs {
+		ids = append(ids, config.ID)
+	}
+	return ids
+}
+
+func TestStartTrashPurgeLoop_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		StartTrashPurgeLoop(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("trash purge loop did not stop after its context was cancelled")
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/httpclient"
+	"github.com/amundfpl/Assignment-2/utils"
+)
+
+// warmReport counts the outcome of one warm-up cycle, per cache entry.
+type warmReport struct {
+	Refreshed  int // Fetched upstream and cached
+	Fresh      int // Not expiring soon enough to refresh
+	Failed     int // Upstream call failed
+	OverBudget int // Due, but the cycle's budget of upstream calls was spent
+}
+
+// warmer runs the refreshes of one warm-up cycle, at most concurrency at a time and within a budget.
+type warmer struct {
+	ctx    context.Context
+	client *httpclient.Client
+	lead   time.Duration
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	budget int
+	report warmReport
+}
+
+// StartCacheWarmLoop launches a background loop that refreshes the country, weather and currency
+// cache entries used by registered dashboards shortly before they expire, so that viewers rarely
+// wait for an upstream API. It runs every utils.CacheWarmInterval, unless utils.CacheWarmBudget is 0,
+// until ctx is cancelled; a cycle in progress then abandons its upstream calls.
+func StartCacheWarmLoop(ctx context.Context) {
+	if utils.CacheWarmBudget <= 0 {
+		log.Println(utils.MsgCacheWarmDisabled)
+		return
+	}
+	ticker := time.NewTicker(utils.CacheWarmInterval)
+	defer ticker.Stop()
+
+	for {
+		log.Println(utils.MsgCacheWarmStart)
+		report, err := warmCaches(ctx, utils.CacheWarmLead, utils.CacheWarmConcurrency, utils.CacheWarmBudget)
+		if err != nil {
+			log.Printf(utils.ErrCacheWarmList, err)
+		} else {
+			log.Printf(utils.MsgCacheWarmDone, report.Refreshed, report.Fresh, report.Failed, report.OverBudget)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println(utils.MsgCacheWarmStop)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// warmCaches runs one warm-up cycle over every live registration. Cache entries that are missing or
+// expire within lead are fetched upstream, at most concurrency at a time and budget in total.
+// Countries are warmed first, since the weather and currency entries are derived from country data.
+// Currencies are warmed as one rate table per base currency, from which every dashboard's targets are derived.
+func warmCaches(ctx context.Context, lead time.Duration, concurrency, budget int) (warmReport, error) {
+	configs, err := db.GetAllDashboardConfigs(ctx)
+	if err != nil {
+		return warmReport{}, err
+	}
+	w := &warmer{ctx: ctx, client: httpclient.NewClient(), lead: lead, slots: make(chan struct{}, concurrency), budget: budget}
+
+	var countriesMu sync.Mutex
+	countries := map[string]utils.CountryInfoResponse{}
+	seen := map[string]bool{}
+	for _, config := range configs {
+		iso := cache.CountryCacheKey(config.ISOCode)
+		if seen[iso] || config.DeletedAt != "" || iso == "" {
+			continue
+		}
+		seen[iso] = true
+		w.run(func() {
+			if info, ok := warm(w, countrySource(w.client, iso, utils.CountryCacheTTL)); ok {
+				countriesMu.Lock()
+				countries[iso] = info
+				countriesMu.Unlock()
+			}
+		})
+	}
+	w.wg.Wait()
+
+	for _, config := range configs {
+		info := countries[cache.CountryCacheKey(config.ISOCode)]
+		if config.DeletedAt != "" {
+			continue
+		}
+
+		// Weather is looked up by the coordinates dashboards show, so only registrations that enable them
+		features := config.Features
+		if (features.Temperature || features.Precipitation) && features.Coordinates && len(info.Latlng) == 2 {
+			source := weatherSource(w.client, info.Latlng[0], info.Latlng[1], utils.WeatherCacheTTL)
+			if !seen[source.name] {
+				seen[source.name] = true
+				w.run(func() { warm(w, source) })
+			}
+		}
+
+		base := baseCurrency(info.Currencies)
+		if len(features.TargetCurrencies) > 0 && base != "" {
+			source := rateTableSource(w.client, base, utils.CurrencyCacheTTL)
+			if !seen[source.name] {
+				seen[source.name] = true
+				w.run(func() { warm(w, source) })
+			}
+		}
+	}
+	w.wg.Wait()
+
+	return w.report, nil
+}
+
+// run starts fn once one of the warmer's slots is free.
+func (w *warmer) run(fn func()) {
+	w.wg.Add(1)
+	w.slots <- struct{}{}
+	go func() {
+		defer func() {
+			<-w.slots
+			w.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// spend takes one upstream call from the budget, reporting false once it is used up.
+func (w *warmer) spend() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.budget <= 0 {
+		w.report.OverBudget++
+		return false
+	}
+	w.budget--
+	return true
+}
+
+// count records the outcome of one cache entry.
+func (w *warmer) count(outcome *int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	*outcome++
+}
+
+// warm refreshes the cache entry behind source if it is missing or expires within the warmer's lead,
+// and returns the entry's data. ok is false if there is no data at all.
+func warm[T any](w *warmer, source cachedSource[T]) (T, bool) {
+	// The warmer's own lookups are kept out of the cache statistics, which describe client traffic
+	data, freshness, lookupErr := source.lookup(cache.WithoutStats(w.ctx), source.maxAge-w.lead)
+	if lookupErr == nil && !freshness.Stale {
+		w.count(&w.report.Fresh)
+		return data, true
+	}
+	if !w.spend() {
+		return data, lookupErr == nil
+	}
+
+	fetched, fetchErr := source.fetchAndSave(w.ctx)
+	if fetchErr != nil {
+		log.Printf(utils.ErrCacheWarmFetch, source.name, fetchErr)
+		w.count(&w.report.Failed)
+		return data, lookupErr == nil
+	}
+	w.count(&w.report.Refreshed)
+	return fetched, true
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amundfpl/Assignment-2/cache"
+	"github.com/amundfpl/Assignment-2/db"
+	"github.com/amundfpl/Assignment-2/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmUpstreams serves canned country, weather and currency responses and counts the calls to each.
+type warmUpstreams struct {
+	countries, weather, currency atomic.Int32
+	running, maxRunning          atomic.Int32
+}
+
+// startWarmUpstreams points the upstream API URLs at stubs for the rest of the test.
+func startWarmUpstreams(t *testing.T) *warmUpstreams {
+	upstreams := &warmUpstreams{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		running := upstreams.running.Add(1)
+		defer upstreams.running.Add(-1)
+		for max := upstreams.maxRunning.Load(); running > max && !upstreams.maxRunning.CompareAndSwap(max, running); {
+			max = upstreams.maxRunning.Load()
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		switch {
+		case strings.Contains(r.URL.Path, "alpha"):
+			upstreams.countries.Add(1)
+			iso := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			_, _ = w.Write([]byte(`[{"capital": ["` + iso + `-city"], "latlng": [60, 10], "currencies": {"` + iso + `K": {"name": "Coin"}}}]`))
+		case strings.Contains(r.URL.Path, "forecast"):
+			upstreams.weather.Add(1)
+			_, _ = w.Write([]byte(`{"current": {"temperature_2m": 3, "precipitation": 0}}`))
+		default:
+			upstreams.currency.Add(1)
+			_, _ = w.Write([]byte(`{"rates": {"EUR": 0.1}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originals := []string{utils.RESTCountriesAPI, utils.OpenMeteoAPI, utils.CurrencyAPI}
+	utils.RESTCountriesAPI, utils.OpenMeteoAPI, utils.CurrencyAPI = server.URL, server.URL, server.URL
+	t.Cleanup(func() { utils.RESTCountriesAPI, utils.OpenMeteoAPI, utils.CurrencyAPI = originals[0], originals[1], originals[2] })
+	return upstreams
+}
+
+// seedWarmRegistrations stores registrations for three countries in a fresh memory store.
+func seedWarmRegistrations(t *testing.T) {
+	useBackupStore(t, db.NewMemoryStore())
+	cache.ResetLocalCache()
+	t.Cleanup(cache.ResetLocalCache)
+
+	ctx := context.Background()
+	all := utils.FeatureConfig{Temperature: true, Coordinates: true, TargetCurrencies: []string{"EUR"}}
+	for _, config := range []utils.DashboardConfig{
+		{Country: "Norway", ISOCode: "NO", Features: all},
+		{Country: "Norway", ISOCode: "no", Features: all}, // Same entries as the first
+		{Country: "Sweden", ISOCode: "SE", Features: utils.FeatureConfig{Capital: true}},
+		{Country: "Denmark", ISOCode: "DK", Features: all, DeletedAt: utils.CurrentTimestamp()},
+	} {
+		_, err := db.SaveDashboardConfig(ctx, config)
+		require.NoError(t, err)
+	}
+}
+
+func TestWarmCaches_RefreshesDistinctEntriesOnce(t *testing.T) {
+	upstreams := startWarmUpstreams(t)
+	seedWarmRegistrations(t)
+	ctx := context.Background()
+
+	report, err := warmCaches(ctx, time.Minute, 2, 100)
+	require.NoError(t, err)
+	assert.Equal(t, warmReport{Refreshed: 4}, report, "NO and SE, plus weather and currency for NO")
+	assert.Equal(t, int32(2), upstreams.countries.Load())
+	assert.Equal(t, int32(1), upstreams.weather.Load())
+	assert.Equal(t, int32(1), upstreams.currency.Load())
+	assert.LessOrEqual(t, upstreams.maxRunning.Load(), int32(2), "no more than the concurrency limit at once")
+
+	rates, err := cache.GetCachedCurrencyRates(ctx, cache.CurrencyCacheKey("NOK"), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, rates["EUR"])
+
+	report, err = warmCaches(ctx, time.Minute, 2, 100)
+	require.NoError(t, err)
+	assert.Equal(t, warmReport{Fresh: 4}, report, "nothing is fetched while the entries are fresh")
+	assert.Equal(t, int32(2), upstreams.countries.Load())
+}
+
+func TestWarmCaches_LeavesCacheStatsAlone(t *testing.T) {
+	startWarmUpstreams(t)
+	seedWarmRegistrations(t)
+	ctx := context.Background()
+
+	before := cache.Stats()
+	_, err := warmCaches(ctx, time.Minute, 2, 100)
+	require.NoError(t, err)
+	report, err := warmCaches(ctx, time.Minute, 2, 100)
+	require.NoError(t, err)
+	assert.Equal(t, warmReport{Fresh: 4}, report)
+	assert.Equal(t, before, cache.Stats(), "the warmer's misses and hits are not client lookups")
+}
+
+func TestWarmCaches_RefreshesEntriesAboutToExpire(t *testing.T) {
+	upstreams := startWarmUpstreams(t)
+	seedWarmRegistrations(t)
+	ctx := context.Background()
+	_, err := warmCaches(ctx, time.Minute, 2, 100)
+	require.NoError(t, err)
+
+	// The weather entry expires in ten minutes, inside a lead of fifteen
+	putAgedCacheEntry(t, utils.WeatherCacheCollection, cache.WeatherCacheKey(60, 10), utils.WeatherData{Temperature: 1},
+		utils.WeatherCacheTTL-10*time.Minute)
+	report, err := warmCaches(ctx, 15*time.Minute, 2, 100)
+	require.NoError(t, err)
+	assert.Equal(t, warmReport{Refreshed: 1, Fresh: 3}, report)
+	assert.Equal(t, int32(2), upstreams.weather.Load())
+}
+
+func TestWarmCaches_StopsAtBudget(t *testing.T) {
+	upstreams := startWarmUpstreams(t)
+	seedWarmRegistrations(t)
+
+	report, err := warmCaches(context.Background(), time.Minute, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, warmReport{Refreshed: 2, OverBudget: 2}, report)
+	assert.Equal(t, int32(2), upstreams.countries.Load()+upstreams.weather.Load()+upstreams.currency.Load())
+}
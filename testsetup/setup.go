@@ -3,6 +3,7 @@ package testsetup
 import (
 	"context"
 	"log"
+	"os"
 
 	firebase "firebase.google.com/go"
 	"google.golang.org/api/option"
@@ -11,6 +12,19 @@ import (
 	"github.com/amundfpl/Assignment-2/utils"
 )
 
+// InitTestStore prepares the storage backend for tests.
+// It connects to the Firestore test project when service account credentials are available,
+// and otherwise falls back to an in-memory store so the suite runs without Google credentials.
+func InitTestStore() {
+	credentialsPath := utils.DefaultCredentialsPath()
+	if _, statErr := os.Stat(credentialsPath); statErr != nil {
+		log.Printf(utils.MsgTestStoreFallback, credentialsPath)
+		db.UseStore(db.NewMemoryStore())
+		return
+	}
+	InitTestFirebase()
+}
+
 // InitTestFirebase sets up a Firebase app and Firestore client for integration testing.
 // It loads service account credentials and connects to a dedicated test project.
 func InitTestFirebase() {
@@ -9,8 +9,56 @@ const (
 	DashboardDashboardsRoute     = "/dashboard/v1/dashboards/"
 	DashboardNotificationsRoute  = "/dashboard/v1/notifications/"
 	DashboardStatusRoute         = "/dashboard/v1/status/"
+	DashboardTenantsRoute        = "/dashboard/v1/tenants/"
+	DashboardCacheRoute          = "/dashboard/v1/cache/"
 	RouteRoot                    = "/"
 
+	// Registration sub-resources
+	SegmentHistory  = "history"
+	SegmentRollback = "rollback"
+	SegmentRestore  = "restore"
+	SegmentExport   = "export"
+	SegmentImport   = "import"
+	SegmentRotate   = "rotate"
+	SegmentPurge    = "purge"
+
+	// Query parameters
+	QueryDeleted        = "deleted"
+	QueryLimit          = "limit"
+	QueryCursor         = "cursor"
+	QuerySort           = "sort"
+	QueryISOCode        = "isoCode"
+	QueryCountry        = "country"
+	QueryFeature        = "feature" // Repeatable; every listed feature must be enabled
+	QueryLastChangeFrom = "lastChangeFrom"
+	QueryLastChangeTo   = "lastChangeTo"
+	QueryEvent          = "event"
+	QueryFormat         = "format"
+	QueryDryRun         = "dryRun"
+	QueryMode           = "mode"
+	QueryWebhooks       = "webhooks"
+	QueryPrefix         = "prefix"
+
+	// Bulk import/export
+	FormatJSON         = "json"
+	FormatNDJSON       = "ndjson"
+	FormatCSV          = "csv"
+	ContentTypeNDJSON  = "application/x-ndjson"
+	ContentTypeCSV     = "text/csv"
+	HeaderContentDisp  = "Content-Disposition"
+	ExportFilenameFmt  = "attachment; filename=\"registrations.%s\""
+	ImportModeCreate   = "create" // Every row becomes a new registration with a generated ID
+	ImportModeUpsert   = "upsert" // Rows with an ID replace or create that registration
+	ImportStatusCreate = "created"
+	ImportStatusUpdate = "updated"
+	ImportStatusFailed = "failed"
+	MaxImportBytes     = 10 << 20
+	CSVListSeparator   = ";"
+
+	// Listing pagination
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
 	// Static assets
 	StaticDir       = "static"
 	StaticIndexFile = "index.html"
@@ -21,23 +69,126 @@ const (
 	CountryCacheCollection  = "country_cache"
 	WeatherCacheCollection  = "weather_cache"
 	CurrencyCacheCollection = "currency_cache"
+	RevisionCollection      = "revisions" // Sub-collection of each dashboard config
+	TenantCollection        = "tenants"
 
 	// Cache TTLs
-	CachePurgeInterval = 1 * time.Hour
-	CountryCacheTTL    = 24 * time.Hour
-	WeatherCacheTTL    = 2 * time.Hour
-	CurrencyCacheTTL   = 12 * time.Hour
+	CachePurgeInterval  = 1 * time.Hour
+	CachePurgeBatchSize = 500           // Entries read and deleted per round trip while purging
+	CacheStaleGrace     = 6 * time.Hour // Expired entries are kept this long to serve while refreshing or when upstream fails
+
+	// Cache TTL defaults, overridable per data type
+	DefaultCountryCacheTTL    = 24 * time.Hour
+	DefaultWeatherCacheTTL    = 2 * time.Hour
+	DefaultCurrencyCacheTTL   = 12 * time.Hour
+	FlagCountryCacheTTL       = "country-cache-ttl"
+	FlagCountryCacheTTLUsage  = "how long cached country data is fresh (e.g. 24h)"
+	EnvCountryCacheTTL        = "COUNTRY_CACHE_TTL"
+	FlagWeatherCacheTTL       = "weather-cache-ttl"
+	FlagWeatherCacheTTLUsage  = "how long cached weather data is fresh (e.g. 2h)"
+	EnvWeatherCacheTTL        = "WEATHER_CACHE_TTL"
+	FlagCurrencyCacheTTL      = "currency-cache-ttl"
+	FlagCurrencyCacheTTLUsage = "how long cached exchange rates are fresh (e.g. 12h)"
+	EnvCurrencyCacheTTL       = "CURRENCY_CACHE_TTL"
+
+	// Weather cache keys
+	DefaultWeatherGeohashPrecision = 5   // Cells of roughly 5 x 5 km
+	MaxWeatherGeohashPrecision     = 12  // Longest geohash a float64 coordinate can fill
+	DefaultWeatherReuseRadiusKm    = 5.0 // Cached weather of a cell centred this close is reused on a miss
+	FlagWeatherPrecision           = "weather-geohash-precision"
+	FlagWeatherPrecisionUsage      = "geohash length of weather cache keys, 1-12; longer keys make smaller cells"
+	EnvWeatherPrecision            = "WEATHER_GEOHASH_PRECISION"
+	FlagWeatherReuseRadius         = "weather-reuse-radius"
+	FlagWeatherReuseRadiusUsage    = "distance in km within which cached weather of a nearby cell is reused; 0 disables reuse"
+	EnvWeatherReuseRadius          = "WEATHER_REUSE_RADIUS"
+	GeohashAlphabet                = "0123456789bcdefghjkmnpqrstuvwxyz"
+	EarthRadiusKm                  = 6371.0
+
+	// Upstream HTTP retries
+	DefaultHTTPMaxAttempts   = 3
+	HTTPRetryBaseDelay       = 200 * time.Millisecond // Doubled for every retry after the first
+	HTTPRetryMaxDelay        = 5 * time.Second        // Longer Retry-After waits are not honoured; the call fails instead
+	HeaderRetryAfter         = "Retry-After"
+	FlagHTTPMaxAttempts      = "http-max-attempts"
+	FlagHTTPMaxAttemptsUsage = "attempts per upstream GET request, including the first; 1 disables retries"
+	EnvHTTPMaxAttempts       = "HTTP_MAX_ATTEMPTS"
+
+	// Upstream circuit breakers
+	CircuitBreakerWindow      = 20               // Most recent requests the failure rate is computed over
+	CircuitBreakerMinRequests = 5                // Fewer requests in the window never trip a breaker
+	CircuitBreakerFailureRate = 0.5              // Share of failed requests in the window that trips a breaker
+	CircuitBreakerCooldown    = 30 * time.Second // How long a tripped breaker fails fast before letting one trial request through
+	BreakerClosed             = "closed"
+	BreakerOpen               = "open"
+	BreakerHalfOpen           = "half-open"
+	DependencyCountriesAPI    = "countries_api"
+	DependencyMeteoAPI        = "meteo_api"
+	DependencyCurrencyAPI     = "currency_api"
+
+	// Upstream rate limits
+	DefaultHTTPRateLimit   = 10.0 // Requests per second to each upstream host
+	DefaultHTTPRateBurst   = 20   // Requests a host may receive at once after a quiet spell
+	FlagHTTPRateLimit      = "http-rate-limit"
+	FlagHTTPRateLimitUsage = "requests per second to each upstream host; 0 disables rate limiting"
+	EnvHTTPRateLimit       = "HTTP_RATE_LIMIT"
+	FlagHTTPRateBurst      = "http-rate-burst"
+	FlagHTTPRateBurstUsage = "requests each upstream host may receive at once before rate limiting starts"
+	EnvHTTPRateBurst       = "HTTP_RATE_BURST"
+
+	// Cache warming
+	CacheWarmInterval        = 5 * time.Minute
+	CacheWarmLead            = 15 * time.Minute // Entries expiring within this window are refreshed
+	CacheWarmConcurrency     = 4                // Upstream calls running at once
+	DefaultCacheWarmBudget   = 200              // Upstream calls per cycle
+	FlagCacheWarmBudget      = "cache-warm-budget"
+	FlagCacheWarmBudgetUsage = "maximum upstream calls per cache warm-up cycle; 0 disables warming"
+	EnvCacheWarmBudget       = "CACHE_WARM_BUDGET"
+
+	// In-process cache tier
+	DefaultCacheL1MaxEntries = 1000
+	FlagCacheSize            = "cache-size"
+	FlagCacheSizeUsage       = "maximum entries per collection in the in-process cache; 0 disables it"
+	EnvCacheSize             = "CACHE_SIZE"
+	FlagCacheL2              = "cache-l2"
+	FlagCacheL2Usage         = "also read and write cache entries in the storage backend, shared between instances"
+	EnvCacheL2               = "CACHE_L2"
+
+	// Shared cache backends
+	CacheBackendStore     = "store"
+	CacheBackendRedis     = "redis"
+	FlagCacheBackend      = "cache-backend"
+	FlagCacheBackendUsage = "shared cache tier: store (the storage backend) or redis (any Redis-protocol server)"
+	EnvCacheBackend       = "CACHE_BACKEND"
+	FlagRedisAddr         = "redis-addr"
+	FlagRedisAddrUsage    = "host:port of the Redis-protocol server used by --cache-backend=redis"
+	EnvRedisAddr          = "REDIS_ADDR"
+	EnvRedisPassword      = "REDIS_PASSWORD"
+	DefaultRedisAddr      = "localhost:6379"
+	RedisKeyPrefix        = "dashboard:"
+	RedisKeySeparator     = ":"
+	RedisScanCount        = 100
+	RedisMaxIdleConns     = 8
+	RedisDefaultTimeout   = 2 * time.Second
+
+	// Trash (soft-deleted registrations)
+	TrashPurgeInterval    = 1 * time.Hour
+	DefaultTrashRetention = 30 * 24 * time.Hour
 
 	// Cache formatting
-	WeatherCacheKeyFormat = "%.1f_%.1f"
-	CacheKeySeparator     = "_"
-	TimestampField        = "timestamp"
-	FieldData             = "data"
+	TimestampField = "timestamp"
+	FieldData      = "data"
+	FieldRevision  = "revision"
 
 	// Keys
 	KeyID               = "id"
 	KeyLastChange       = "lastChange"
+	KeyVersion          = "version"
+	KeySchemaVersion    = "schemaVersion"
+	KeyDeletedAt        = "deletedAt"
+	KeyTenantID         = "tenantId"
 	KeyCountry          = "country"
+	KeyURL              = "url"
+	KeyEvent            = "event"
 	KeyISOCode          = "isoCode"
 	KeyFeatures         = "features"
 	KeyTemperature      = "temperature"
@@ -47,10 +198,17 @@ const (
 	KeyPopulation       = "population"
 	KeyArea             = "area"
 	KeyTargetCurrencies = "targetCurrencies"
+	KeyMaxAge           = "maxAge"
+	KeyWeather          = "weather"
+	KeyCurrency         = "currency"
 	KeyError            = "error"
 
 	// Config
 	DefaultPort          = "8080"
+	ShutdownTimeout      = 15 * time.Second // How long in-flight requests get to finish on shutdown
+	RequestTimeout       = 30 * time.Second // Deadline of an API request, upstream calls and store reads included
+	BulkRequestTimeout   = 5 * time.Minute  // Deadline of a bulk export or import
+	WebhookTimeout       = 10 * time.Second // Deadline of one webhook delivery
 	EnvPort              = "PORT"
 	AddrPrefix           = ":"
 	TimestampLayout      = "20060102 15:04"
@@ -64,6 +222,68 @@ const (
 	CredentialsDir         = "credentials"
 	TestCredentialsFile    = "test-serviceAccountKey.json"
 
+	// Storage backends
+	StoreFirestore   = "firestore"
+	StoreMemory      = "memory"
+	StoreSQLite      = "sqlite"
+	StorePostgres    = "postgres"
+	DriverSQLite     = "sqlite"
+	DriverPostgres   = "pgx"
+	DefaultSQLiteDSN = "dashboard.db"
+	FlagStore        = "store"
+	FlagStoreUsage   = "storage backend to use (firestore, memory, sqlite or postgres)"
+	FlagDSN          = "dsn"
+	FlagDSNUsage     = "data source name for the sqlite or postgres backend"
+	EnvStore         = "STORE_BACKEND"
+	EnvDSN           = "DATABASE_URL"
+
+	// Tenancy and API keys
+	HeaderAPIKey        = "X-API-Key"
+	HeaderAuthorization = "Authorization"
+	BearerPrefix        = "Bearer "
+	APIKeyPrefix        = "dk_"
+	APIKeyBytes         = 32
+	FieldKeyHash        = "keyHash"
+	FlagAdminKey        = "admin-key"
+	FlagAdminKeyUsage   = "API key for the tenant admin API; enables per-tenant API key authentication"
+	EnvAdminKey         = "ADMIN_API_KEY"
+
+	// Document migrations
+	CommandMigrate       = "migrate"
+	FlagMigrateReport    = "report"
+	FlagMigrateReportUse = "only list outdated and undecodable documents; do not write anything"
+
+	// Backup archives
+	CommandBackup          = "backup"
+	CommandRestore         = "restore"
+	FlagBackupFile         = "file"
+	FlagBackupFileUsage    = "path of the backup archive (gzip-compressed NDJSON)"
+	FlagBackupCaches       = "caches"
+	FlagBackupCachesUsage  = "also back up the country, weather and currency caches"
+	FlagRestoreMode        = "mode"
+	FlagRestoreModeUsage   = "merge (overwrite documents with the same ID) or replace (delete everything first)"
+	FlagVerifyOnly         = "verify-only"
+	FlagVerifyOnlyUsage    = "only check the archive's checksum and structure; do not write anything"
+	RestoreModeMerge       = "merge"
+	RestoreModeReplace     = "replace"
+	BackupFormat           = "dashboard-backup"
+	BackupFormatVersion    = 1
+	BackupTempSuffix       = ".tmp"
+	BackupKindHeader       = "header"
+	BackupKindTenant       = "tenant"
+	BackupKindRegistration = "registration"
+	BackupKindRevision     = "revision"
+	BackupKindWebhook      = "webhook"
+	BackupKindCache        = "cache"
+	BackupKindTrailer      = "trailer"
+
+	// Trash retention configuration
+	FlagTrashRetention      = "trash-retention"
+	FlagTrashRetentionUsage = "how long soft-deleted registrations are kept before being purged (e.g. 720h)"
+	EnvTrashRetention       = "TRASH_RETENTION"
+	DocumentIDLength        = 20
+	DocumentIDAlphabet      = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
 	//Render environment variable
 	GOOGLE_APPLICATION_CREDENTIALS = "GOOGLE_APPLICATION_CREDENTIALS"
 
@@ -75,13 +295,24 @@ const (
 	CurrencyEURToNOKPath     = "/latest?from=EUR&to=NOK"
 
 	// API Formats
-	OpenMeteoWeatherURLFmt = "%s%s?latitude=%.4f&longitude=%.4f&current=temperature_2m,precipitation"
-	CurrencyAPIFmt         = "%s/%s"
+	OpenMeteoWeatherURLFmt  = "%s%s?latitude=%.4f&longitude=%.4f&current=temperature_2m,precipitation"
+	CurrencyAPIFmt          = "%s/%s"
+	CurrencyRateTableURLFmt = "%s/latest?from=%s"
+	CrossRateCurrency       = "EUR" // Its rate table is used to derive rates missing from another base's table
 
 	// Content Types
 	ContentTypeJSON   = "application/json"
 	HeaderContentType = "Content-Type"
 
+	// Conditional requests
+	HeaderETag            = "ETag"
+	HeaderIfMatch         = "If-Match"
+	HeaderIfNoneMatch     = "If-None-Match"
+	HeaderLastModified    = "Last-Modified"
+	HeaderIfModifiedSince = "If-Modified-Since"
+	ETagWildcard          = "*"
+	MaxWriteAttempts      = 3 // Retries of an unconditional write that lost a version race
+
 	// Operators
 	OperatorLessThan = "<"
 )
@@ -93,6 +324,65 @@ var (
 	OpenMeteoAPI     = "https://api.open-meteo.com"
 )
 
+// CSVColumns is the header written by the CSV export and accepted by the CSV import.
+var CSVColumns = []string{
+	KeyID, KeyCountry, KeyISOCode,
+	KeyTemperature, KeyPrecipitation, KeyCapital, KeyCoordinates, KeyPopulation, KeyArea,
+	KeyTargetCurrencies, KeyLastChange,
+}
+
+// CacheCollections lists every cache collection, for jobs that work across all of them.
+var CacheCollections = []string{CountryCacheCollection, WeatherCacheCollection, CurrencyCacheCollection}
+
+// AdminAPIKey guards the tenant admin API. When empty, authentication is disabled and every
+// request acts as the default (unnamed) tenant. Overridden at startup by the --admin-key flag.
+var AdminAPIKey = ""
+
+// CacheL1MaxEntries bounds the in-process cache per collection; least recently used entries are
+// evicted first. Overridden at startup by the --cache-size flag.
+var CacheL1MaxEntries = DefaultCacheL1MaxEntries
+
+// CacheL2Enabled makes the cache read through to, and write to, the storage backend.
+// Overridden at startup by the --cache-l2 flag.
+var CacheL2Enabled = true
+
+// CountryCacheTTL, WeatherCacheTTL and CurrencyCacheTTL are how long cached data of each type is fresh.
+// Overridden at startup by the --country-cache-ttl, --weather-cache-ttl and --currency-cache-ttl flags.
+// A dashboard can ask for fresher data with FeatureConfig.MaxAge.
+var (
+	CountryCacheTTL  = DefaultCountryCacheTTL
+	WeatherCacheTTL  = DefaultWeatherCacheTTL
+	CurrencyCacheTTL = DefaultCurrencyCacheTTL
+)
+
+// WeatherGeohashPrecision is the geohash length of weather cache keys, so coordinates in the same
+// cell share one entry. Overridden at startup by the --weather-geohash-precision flag.
+var WeatherGeohashPrecision = DefaultWeatherGeohashPrecision
+
+// WeatherReuseRadiusKm is how far away a cached weather cell may be centred and still answer a lookup
+// that misses its own cell; 0 disables reuse. Overridden at startup by the --weather-reuse-radius flag.
+var WeatherReuseRadiusKm = DefaultWeatherReuseRadiusKm
+
+// HTTPMaxAttempts is how often upstream GET requests are tried before their error is returned.
+// Overridden at startup by the --http-max-attempts flag.
+var HTTPMaxAttempts = DefaultHTTPMaxAttempts
+
+// HTTPRateLimit is how many requests per second each upstream host receives; 0 disables rate limiting.
+// Overridden at startup by the --http-rate-limit flag.
+var HTTPRateLimit = DefaultHTTPRateLimit
+
+// HTTPRateBurst is how many requests each upstream host may receive at once before they are queued.
+// Overridden at startup by the --http-rate-burst flag.
+var HTTPRateBurst = DefaultHTTPRateBurst
+
+// CacheWarmBudget caps the upstream calls of one cache warm-up cycle; 0 disables warming.
+// Overridden at startup by the --cache-warm-budget flag.
+var CacheWarmBudget = DefaultCacheWarmBudget
+
+// TrashRetention is how long soft-deleted registrations are kept before being purged.
+// Overridden at startup by the --trash-retention flag.
+var TrashRetention = DefaultTrashRetention
+
 // Allowed Events
 var AllowedEvents = map[string]bool{
 	"REGISTER": true,
@@ -113,6 +403,12 @@ const (
 	EventDelete   = "DELETE"
 )
 
+// Revision events that are not webhook events
+const (
+	RevisionEventRollback = "ROLLBACK"
+	RevisionEventRestore  = "RESTORE"
+)
+
 // Status constants
 const (
 	StatusVersion = "v1"
@@ -11,6 +11,7 @@ const (
 // --- Country / ISO Code / Config Errors ---
 const (
 	ErrMissingCountryOrISOCode        = "either 'country' or 'isoCode' must be provided"
+	ErrInvalidDuration                = "%s is not a valid duration; use a non-negative value such as \"15m\""
 	ErrInvalidISOCode                 = "no country found for ISO code: %s"
 	ErrCountryResponseParseFailed     = "failed to parse REST Countries API response: %v"
 	ErrInvalidCountryResp             = "invalid country response: %v"
@@ -19,10 +20,11 @@ const (
 	ErrFetchConfig                    = "failed to fetch dashboard config"
 	ErrFetchAllConfigs                = "failed to fetch all dashboard configurations"
 	ErrConfigNotFound                 = "dashboard config not found: %w"
-	ErrConfigNotFoundByID             = "dashboard config not found for ID: %v"
+	ErrConfigNotFoundByID             = "dashboard config not found for ID: %w"
 	MsgDashboardNotFound              = "Dashboard config not found"
 	ErrMsgMissingOrInvalidDashboardID = "Missing or invalid dashboard ID"
 	ErrMsgDashboardFetchFailed        = "Failed to retrieve populated dashboard: "
+	ErrMsgDashboardTimeout            = "Timed out retrieving populated dashboard: "
 )
 
 // --- HTTP / API Call Errors ---
@@ -32,7 +34,16 @@ const (
 	ErrHTTPPostFailed  = "HTTP POST failed: %w"
 	ErrHTTPPostStatus  = "HTTP POST returned status %d"
 	ErrHTTPReadBody    = "failed to read response body: %w"
+	ErrHTTPBuildFailed = "failed to build HTTP request: %v"
 	ErrHTTPPostMarshal = "JSON marshalling failed: %w"
+	MsgHTTPRetry       = "Retrying GET %s in %v (attempt %d of %d): %v"
+	ErrCircuitOpen     = "circuit breaker is open"
+	ErrCircuitOpenHost = "%s: %w"
+	MsgBreakerOpened   = "Circuit breaker for %s opened: %d of the last %d requests failed"
+	MsgBreakerReopened = "Circuit breaker for %s opened again: the trial request failed"
+	MsgBreakerClosed   = "Circuit breaker for %s closed: the trial request succeeded"
+	ErrRateLimited     = "rate limit would delay the request past its deadline"
+	ErrRateLimitedHost = "%s: %w"
 )
 
 // --- Weather & Currency ---
@@ -59,6 +70,14 @@ const (
 	ErrCacheMissCurrency    = "currency cache miss for key %s: %w"
 	ErrCacheDecodeCurrency  = "currency cache decode error for key %s: %w"
 	ErrCacheExpiredCurrency = "currency cache expired"
+	ErrCacheL1Miss          = "not in the in-process cache and the store tier is disabled"
+	ErrInvalidCacheSetting  = "Invalid %s value %q, using default: %v"
+	ErrUnknownCacheBackend  = "unknown cache backend: %s"
+	ErrCacheBackendInit     = "failed to initialize %s cache backend: %w"
+	ErrRESPReply            = "redis: %s"
+	ErrRESPProtocol         = "redis protocol error: unexpected reply %q"
+	MsgUsingCacheBackend    = "Using shared cache backend:"
+	ErrNearbyWeatherScan    = "Could not look for cached weather near %.4f,%.4f: %v"
 
 	ErrPurgeCountryCache  = "Country cache purge error: %v"
 	ErrPurgeWeatherCache  = "Weather cache purge error: %v"
@@ -77,6 +96,109 @@ const (
 	ErrFirestoreSaveFailed                 = "failed to save dashboard config: %v"
 	ErrFirestoreUpdateFailed               = "failed to update dashboard config: %v"
 	ErrFirestoreDeleteFailed               = "failed to delete dashboard config: %v"
+	ErrPreconditionFailed                  = "registration was modified (current ETag %s): %w"
+	ErrRevisionNotFound                    = "revision %d of dashboard %s: %w"
+	ErrSaveRevision                        = "Failed to record revision %d of dashboard %s: %v"
+)
+
+// --- Storage ---
+const (
+	ErrDocumentNotFound    = "document not found"
+	ErrVersionConflict     = "document version does not match"
+	ErrStoreNotInitialized = "storage backend is not initialized"
+	ErrUnknownStore        = "unknown storage backend %q"
+	ErrMissingDSN          = "the postgres backend requires a data source name"
+	ErrOpenSQLStore        = "failed to open SQL store: %w"
+	ErrSQLMigration        = "failed to apply SQL migration %d: %w"
+	MsgSQLMigrationApplied = "Applied SQL migration %d: %s"
+	MsgUsingStore          = "Using storage backend:"
+	MsgTestStoreFallback   = "Test credentials not found at %s — using in-memory store"
+)
+
+// --- Document migrations ---
+const (
+	ErrSchemaTooNew      = "document was written by a newer schema version"
+	ErrDocumentSchema    = "document schema version %d: %w"
+	ErrDocumentMigration = "document migration %d failed: %w"
+	ErrDecodeDocument    = "failed to decode document: %w"
+	ErrSkipUndecodable   = "Skipping dashboard config %s that failed to decode: %v"
+	ErrWriteBackUpgraded = "Failed to write back upgraded dashboard config %s: %v"
+	ErrMigrateDocuments  = "failed to migrate documents: %w"
+	ErrUnknownCommand    = "unknown command %q"
+	MsgMigrationFailures = "%d documents could not be migrated"
+)
+
+// --- Backups ---
+const (
+	ErrInvalidBackup        = "invalid backup archive"
+	ErrBackupRecord         = "line %d: %v: %w"
+	ErrBackupHeader         = "line 1 is not a %s header: %w"
+	ErrBackupFormatVersion  = "unsupported format version %d: %w"
+	ErrBackupSchemaTooNew   = "archive schema version %d is newer than this build's %d: %w"
+	ErrBackupRecordKind     = "line %d: unknown record kind %q: %w"
+	ErrBackupChecksum       = "checksum mismatch: trailer %s, computed %s: %w"
+	ErrBackupCount          = "%s count mismatch: trailer %d, archive %d: %w"
+	ErrBackupTruncated      = "archive has no trailer, it is truncated: %w"
+	ErrBackupTrailingData   = "line %d follows the trailer: %w"
+	ErrUnknownRestoreMode   = "unknown restore mode %q (expected merge or replace)"
+	ErrMissingBackupFile    = "the --file flag is required"
+	ErrWriteBackup          = "failed to write backup: %w"
+	ErrRestoreBackup        = "failed to restore backup: %w"
+	MsgRestoreVerifyFail    = "%d restored documents failed verification"
+	MsgRestoreMissing       = "missing from the store after restore"
+	MsgRestoreMismatch      = "stored document differs from the archive"
+	MsgRestoreCountMismatch = "store holds %d, archive %d"
+)
+
+// --- Tenants ---
+const (
+	ErrInvalidAPIKey     = "invalid or missing API key"
+	ErrMissingTenantName = "tenant name is required"
+	ErrGenerateAPIKey    = "failed to generate API key: %w"
+	MsgUnauthorized      = "Unauthorized: "
+	MsgTenantNotFound    = "Tenant not found: "
+	MsgTenantSaveFail    = "Failed to save tenant: "
+	MsgTenantFetchFail   = "Failed to retrieve tenants: "
+	MsgTenantDeleteFail  = "Failed to delete tenant: "
+	MsgAuthDisabled      = "No admin API key configured — tenant authentication is disabled"
+)
+
+// --- Listings ---
+const (
+	ErrInvalidListQuery  = "invalid listing query"
+	ErrInvalidCursor     = "malformed or mismatched cursor: %w"
+	ErrUnknownSortField  = "unknown sort field %q: %w"
+	ErrUnknownFeature    = "unknown feature %q: %w"
+	ErrInvalidLimit      = "limit must be between 1 and %d: %w"
+	ErrInvalidTimeFilter = "%s must be formatted as %q: %w"
+	MsgInvalidListQuery  = "Invalid listing parameters: "
+)
+
+// --- Import/Export ---
+const (
+	ErrUnknownFormat     = "unknown format %q (expected json, ndjson or csv)"
+	ErrUnknownImportMode = "unknown import mode %q (expected create or upsert)"
+	ErrUnknownCSVColumn  = "unknown CSV column %q"
+	ErrReadImport        = "failed to read import: %w"
+	ErrInvalidImportRow  = "invalid row: %v"
+	ErrInvalidCSVBool    = "column %s: %q is not a boolean"
+	ErrImportRowInTrash  = "registration %s is in the trash"
+	MsgExportFail        = "Failed to export registrations: "
+	MsgImportFail        = "Failed to import registrations: "
+	MsgImportCompleted   = "Import finished: %d created, %d updated, %d failed (dry run: %t)"
+)
+
+// --- Trash ---
+const (
+	ErrRegistrationNotDeleted = "registration is not in the trash"
+	ErrInvalidTrashRetention  = "Invalid %s value %q, using default: %v"
+	ErrPurgeTrash             = "Trash purge error: %v"
+	ErrPurgeTrashEntry        = "Failed to purge soft-deleted registration %s: %v"
+	ErrPurgeTrashRevisions    = "Failed to purge the history of registration %s: %v"
+	MsgTrashPurgeStart        = "Starting trash purge..."
+	MsgTrashPurged            = "Purged %d soft-deleted registrations older than %s"
+	MsgTrashPurgeStop         = "Trash purge loop stopped"
+	MsgRestoreFail            = "Failed to restore registration: "
 )
 
 // --- Webhooks ---
@@ -100,6 +222,11 @@ const (
 	MsgUpdateConfigFail      = "Failed to update config: "
 	MsgPatchConfigFail       = "Failed to patch config: "
 	MsgDeleteConfigFail      = "Failed to delete registration: "
+	MsgPreconditionFailed    = "Precondition failed: "
+	MsgHistoryFetchFail      = "Failed to retrieve revision history: "
+	MsgRevisionNotFound      = "Revision not found: "
+	MsgInvalidRevision       = "Invalid revision number: "
+	MsgRollbackFail          = "Failed to roll back registration: "
 	MsgDashboardSaved        = "Firestore write successful, new doc ID:"
 )
 
@@ -108,6 +235,34 @@ const (
 	MsgCachePurgeStart = "Starting cache purge..."
 	MsgCachePurgeDone  = "Cache purge completed. Waiting for next cycle..."
 	MsgPurgeSuccess    = "Purged %d documents from %s"
+	MsgCachePurgeStop  = "Cache purge loop stopped"
+	ErrPurgeEntry      = "Failed to purge %s from %s: %s"
+	ErrPurgeFailures   = "could not delete %d entries from %s"
+)
+
+// --- Cache Administration ---
+const (
+	ErrUnknownCacheCollection = "unknown cache collection"
+	MsgCacheEntryNotFound     = "Cache entry not found: "
+	MsgCacheAdminFail         = "Cache administration failed: "
+)
+
+// --- Cache Warming Logging ---
+const (
+	MsgCacheWarmStart    = "Starting cache warm-up..."
+	MsgCacheWarmDone     = "Cache warm-up completed: %d refreshed, %d fresh, %d failed, %d over budget"
+	MsgCacheWarmDisabled = "Cache warming disabled"
+	MsgCacheWarmStop     = "Cache warm-up loop stopped"
+	ErrCacheWarmList     = "Cache warm-up could not list registrations: %v"
+	ErrCacheWarmFetch    = "Cache warm-up of %s failed: %v"
+)
+
+// --- Stale Cache Logging ---
+const (
+	MsgServingStale      = "Serving cached %s after upstream failure: %v"
+	ErrBackgroundRefresh = "Background refresh of %s failed: %v"
+	ErrCacheSaveFailed   = "Caching %s failed: %v"
+	MsgNotModified       = "Cached %s not modified upstream, extending its lifetime"
 )
 
 // --- Webhook Logging ---
@@ -121,8 +276,11 @@ const (
 const (
 	MsgServerStart                = "Server running on port"
 	ErrMsgInitDB                  = "Could not initialize database: %v"
-	ErrMsgCloseFirestore          = "Error closing Firestore: %v"
+	ErrMsgCloseStore              = "Error closing storage backend: %v"
+	ErrMsgInitCache               = "Could not initialize cache backend: %v"
 	ErrMsgServerStart             = "Failed to start server: %v"
+	MsgServerShutdown             = "Shutting down server..."
+	ErrMsgServerShutdown          = "Error shutting down server: %v"
 	LogFallbackCredentialUsed     = "GO_FIREBASE_CREDENTIALS not set, using fallback: %s"
 	LogWriteErrorResponseFailed   = "WriteErrorResponse: failed to write response: %v"
 	LogWriteSuccessResponseFailed = "WriteSuccessResponse: failed to write response: %v"
@@ -1,5 +1,11 @@
 package utils
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 // RegistrationRequest represents the payload for creating a new dashboard registration.
 type RegistrationRequest struct {
 	Country  string        `json:"country"`
@@ -13,7 +19,147 @@ type DashboardConfig struct {
 	Country    string        `json:"country"`
 	ISOCode    string        `json:"isoCode"`
 	Features   FeatureConfig `json:"features"`
-	LastChange string        `json:"lastChange"` // Timestamp string representing last update
+	LastChange string        `json:"lastChange"`          // Timestamp string representing last update
+	Version    int64         `json:"version"`             // Incremented on every write; exposed as the ETag
+	DeletedAt  string        `json:"deletedAt,omitempty"` // Set when soft-deleted; formatted with TimestampLayout
+	TenantID   string        `json:"tenantId,omitempty"`  // Owning tenant; assigned by the service, never by clients
+	// Schema of the stored document; set by the store on write and upgraded on read
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+}
+
+// Tenant is an isolated customer of the service. Only a hash of its API key is stored.
+type Tenant struct {
+	ID           string `json:"id" firestore:"-"`
+	Name         string `json:"name" firestore:"name"`
+	KeyHash      string `json:"-" firestore:"keyHash"`
+	CreatedAt    string `json:"createdAt" firestore:"createdAt"`
+	KeyRotatedAt string `json:"keyRotatedAt,omitempty" firestore:"keyRotatedAt"`
+}
+
+// TenantKeyResponse is returned when a tenant is created or its key rotated.
+// The plaintext API key is only ever shown here.
+type TenantKeyResponse struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	APIKey string `json:"apiKey"`
+}
+
+// DashboardRevision is an immutable snapshot of a dashboard configuration taken after a write.
+// Revision numbers equal the configuration's version at the time of the snapshot.
+type DashboardRevision struct {
+	DashboardID string          `json:"dashboardId" firestore:"dashboardId"`
+	Revision    int64           `json:"revision" firestore:"revision"`
+	Event       string          `json:"event" firestore:"event"`         // REGISTER, CHANGE, PATCH, DELETE, ROLLBACK or RESTORE
+	Timestamp   string          `json:"timestamp" firestore:"timestamp"` // Formatted with TimestampLayout
+	Config      DashboardConfig `json:"config" firestore:"config"`
+	Diff        []FieldChange   `json:"diff" firestore:"diff"` // Changes relative to the previous revision
+}
+
+// FieldChange describes a single changed field between two revisions, using dotted paths
+// such as "features.capital".
+type FieldChange struct {
+	Field string      `json:"field" firestore:"field"`
+	Old   interface{} `json:"old" firestore:"old"`
+	New   interface{} `json:"new" firestore:"new"`
+}
+
+// ListResponse is the envelope returned by paginated listing endpoints.
+// Next is a link to the following page and is omitted on the last page.
+type ListResponse[T any] struct {
+	Items []T    `json:"items"`
+	Total int    `json:"total"`
+	Next  string `json:"next,omitempty"`
+}
+
+// ImportReport summarises a bulk import. In a dry run the counts describe what would have happened.
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Mode    string            `json:"mode"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult is the outcome of a single imported row. Row numbers start at 1 and
+// count data rows, excluding any CSV header.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MigrationReport summarises a bulk upgrade of stored documents to SchemaVersion.
+// In a dry run (report mode) nothing is written and Upgraded counts documents that would change.
+type MigrationReport struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	DryRun        bool              `json:"dryRun"`
+	Total         int               `json:"total"`
+	Current       int               `json:"current"`
+	Upgraded      int               `json:"upgraded"`
+	Failed        int               `json:"failed"`
+	Failures      []DocumentFailure `json:"failures"`
+}
+
+// DocumentFailure is a stored document that could not be decoded or upgraded.
+type DocumentFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// BackupRecord is one line of a backup archive: a header, a document of the given kind, or the trailer.
+type BackupRecord struct {
+	Kind       string          `json:"kind"`
+	Collection string          `json:"collection,omitempty"` // Cache collection, for cache records
+	Data       json.RawMessage `json:"data"`
+}
+
+// BackupHeader is the first record of a backup archive.
+type BackupHeader struct {
+	Format        string `json:"format"`
+	FormatVersion int    `json:"formatVersion"`
+	SchemaVersion int    `json:"schemaVersion"` // Dashboard document schema version of the registrations
+	CreatedAt     string `json:"createdAt"`     // RFC 3339, UTC
+	Caches        bool   `json:"caches"`
+}
+
+// BackupTrailer is the last record of a backup archive. SHA256 covers every preceding line.
+type BackupTrailer struct {
+	Counts map[string]int `json:"counts"`
+	SHA256 string         `json:"sha256"`
+}
+
+// BackupTenant is a tenant as archived, including the key hash that the API never exposes.
+type BackupTenant struct {
+	Tenant
+	KeyHash string `json:"keyHash"`
+}
+
+// CacheRecord is a raw cache entry as archived: its key, JSON data and original timestamp.
+type CacheRecord struct {
+	Key       string          `json:"key"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// BackupSummary describes a backup archive that was written or verified.
+type BackupSummary struct {
+	Header BackupHeader   `json:"header"`
+	Counts map[string]int `json:"counts"`
+	SHA256 string         `json:"sha256"`
+}
+
+// RestoreReport summarises a restore: what was deleted (replace mode), restored and verified.
+type RestoreReport struct {
+	Mode       string            `json:"mode"`
+	VerifyOnly bool              `json:"verifyOnly"`
+	Archive    BackupSummary     `json:"archive"`
+	Deleted    map[string]int    `json:"deleted"`
+	Restored   map[string]int    `json:"restored"`
+	Verified   int               `json:"verified"`
+	Failures   []DocumentFailure `json:"failures"`
 }
 
 // FeatureConfig represents the optional features that can be enabled in a dashboard.
@@ -25,6 +171,43 @@ type FeatureConfig struct {
 	Population       bool     `json:"population"`
 	Area             bool     `json:"area"`
 	TargetCurrencies []string `json:"targetCurrencies"` // Currency codes to compare against
+	// Optional per data type limits on how old the shown data may be, stricter than the cache TTLs
+	MaxAge *FeatureMaxAge `json:"maxAge,omitempty"`
+}
+
+// FeatureMaxAge caps the age of the country, weather and currency data behind a dashboard's features.
+// A zero value leaves the cache TTL of that data type in charge.
+type FeatureMaxAge struct {
+	Country  Duration `json:"country,omitempty"`  // Capital, coordinates, population and area
+	Weather  Duration `json:"weather,omitempty"`  // Temperature and precipitation
+	Currency Duration `json:"currency,omitempty"` // Exchange rates
+}
+
+// Duration is a time.Duration written to JSON as a string such as "15m". It also reads plain numbers
+// of nanoseconds, which is how stores that encode the struct directly keep it.
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a Go duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a Go duration string or a number of nanoseconds. Negative durations are rejected.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var parsed time.Duration
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		if parsed, err = time.ParseDuration(text); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, (*int64)(&parsed)); err != nil {
+		return fmt.Errorf(ErrInvalidDuration, string(data))
+	}
+	if parsed < 0 {
+		return fmt.Errorf(ErrInvalidDuration, string(data))
+	}
+	*d = Duration(parsed)
+	return nil
 }
 
 // RegistrationResponse represents the response returned after successfully registering a dashboard.
@@ -45,6 +228,15 @@ type DashboardResponse struct {
 	Temperature   float64            `json:"temperature,omitempty"`
 	Precipitation float64            `json:"precipitation,omitempty"`
 	ExchangeRates map[string]float64 `json:"exchangeRates,omitempty"`
+	Staleness     *Staleness         `json:"staleness,omitempty"`
+}
+
+// Staleness marks a response containing cached data older than its TTL, served while the data is
+// refreshed in the background or because the upstream API failed.
+type Staleness struct {
+	Stale      bool   `json:"stale"`
+	DataAsOf   string `json:"dataAsOf"`   // When the oldest stale data was fetched upstream; formatted with TimestampLayout
+	AgeSeconds int64  `json:"ageSeconds"` // Age of the oldest stale data
 }
 
 // CountryDetails is an internal model used to represent basic country information.
@@ -84,6 +276,15 @@ type CountryInfoResponse struct {
 	} `json:"flags"`
 	Languages  map[string]string          `json:"languages"`
 	Currencies map[string]CurrencyDetails `json:"currencies"`
+
+	Validators *Validators `json:"validators,omitempty"` // From the response headers, kept to revalidate the cached copy
+}
+
+// Validators are the HTTP cache validators an upstream response came with. A later request sending
+// them back is answered 304 Not Modified if the resource has not changed.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
 }
 
 // StatusResponse is the top-level structure for reporting service health in a list.
@@ -109,6 +310,32 @@ type StatusReport struct {
 	Webhooks        int    `json:"webhooks"`        // Number of registered webhooks
 	Version         string `json:"version"`         // API version
 	UptimeInSeconds int64  `json:"uptime"`          // Time since server started
+	CoalescedCalls  int64  `json:"coalesced_calls"` // Upstream calls saved by sharing one already in flight
+
+	CircuitBreakers []CircuitBreakerStatus `json:"circuit_breakers"` // One per upstream API
+	RateLimits      []RateLimitStatus      `json:"rate_limits"`      // One per upstream API
+}
+
+// CircuitBreakerStatus is the state of the circuit breaker guarding one upstream API.
+type CircuitBreakerStatus struct {
+	Dependency     string  `json:"dependency"`
+	Host           string  `json:"host"`
+	State          string  `json:"state"`              // closed, open or half-open
+	FailureRate    float64 `json:"failure_rate"`       // Share of failed requests among the most recent ones
+	RetryInSeconds int64   `json:"retry_in,omitempty"` // Until an open breaker lets a trial request through
+}
+
+// RateLimitStatus is the rate limit of one upstream API and how long requests have queued for it.
+type RateLimitStatus struct {
+	Dependency        string  `json:"dependency"`
+	Host              string  `json:"host"`
+	RequestsPerSecond float64 `json:"requests_per_second"` // 0 if the host is not rate limited
+	Burst             int     `json:"burst"`
+	Requests          int64   `json:"requests"`      // Let through since startup
+	Delayed           int64   `json:"delayed"`       // Of those, how many had to queue
+	Rejected          int64   `json:"rejected"`      // Failed because the queue would outlast their deadline
+	WaitTotalMs       int64   `json:"wait_total_ms"` // Summed queueing time
+	WaitMaxMs         int64   `json:"wait_max_ms"`   // Longest single queueing time
 }
 
 // Notification represents a generic notification message sent to the user or client.
@@ -139,12 +366,60 @@ type PopulatedDashboardResponse struct {
 	ISOCode       string            `json:"isoCode"`
 	Features      PopulatedFeatures `json:"features"`
 	LastRetrieval string            `json:"lastRetrieval"` // Timestamp of when the data was last fetched
+	Staleness     *Staleness        `json:"staleness,omitempty"`
 }
 
 // Webhook represents a registered webhook listener.
 type Webhook struct {
-	ID      string `json:"id" firestore:"-"`            // Local ID, not stored in Firestore
-	URL     string `json:"url" firestore:"url"`         // Target URL to POST to
-	Event   string `json:"event" firestore:"event"`     // Event type: REGISTER, CHANGE, DELETE, etc.
-	Country string `json:"country" firestore:"country"` // ISO code or empty for global
+	ID       string `json:"id" firestore:"-"`                        // Local ID, not stored in Firestore
+	URL      string `json:"url" firestore:"url"`                     // Target URL to POST to
+	Event    string `json:"event" firestore:"event"`                 // Event type: REGISTER, CHANGE, DELETE, etc.
+	Country  string `json:"country" firestore:"country"`             // ISO code or empty for global
+	TenantID string `json:"tenantId,omitempty" firestore:"tenantId"` // Owning tenant; assigned by the service
+}
+
+// CacheEntrySummary describes one cache entry in the cache admin API.
+type CacheEntrySummary struct {
+	Key        string    `json:"key"`
+	StoredAt   time.Time `json:"storedAt"`   // When the data was fetched upstream
+	AgeSeconds int64     `json:"ageSeconds"` // Time since StoredAt
+	TTLSeconds int64     `json:"ttlSeconds"` // Time left until the entry expires; negative once it is stale
+	RemovedAt  time.Time `json:"removedAt"`  // When the entry is purged, at the end of the stale grace window
+	Stale      bool      `json:"stale"`
+}
+
+// CacheEntryDetail is a single cache entry with its data, returned by the cache admin API.
+type CacheEntryDetail struct {
+	CacheEntrySummary
+	Data interface{} `json:"data"`
+}
+
+// CacheCollectionStats counts the lookups of one cache collection since startup.
+type CacheCollectionStats struct {
+	Collection string `json:"collection"`
+	Hits       int64  `json:"hits"`    // Served fresh data
+	Stale      int64  `json:"stale"`   // Served expired data within the stale grace window
+	Misses     int64  `json:"misses"`  // No entry at all
+	Expired    int64  `json:"expired"` // Entry too old to serve
+}
+
+// CachePurgeResult is the outcome of purging one cache collection.
+type CachePurgeResult struct {
+	Collection string              `json:"collection"`
+	Purged     int                 `json:"purged"`
+	Failures   []CachePurgeFailure `json:"failures,omitempty"` // Entries that could not be deleted
+	Error      string              `json:"error,omitempty"`    // Why the purge stopped early, or that some entries failed
+}
+
+// CachePurgeFailure is a cache entry a purge could not delete.
+type CachePurgeFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// CacheInvalidation reports how many entries an invalidation removed.
+type CacheInvalidation struct {
+	Collection string `json:"collection"`
+	Prefix     string `json:"prefix,omitempty"`
+	Removed    int    `json:"removed"`
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -48,6 +49,14 @@ func WriteIDResponse(w http.ResponseWriter, id string, statusCode int) {
 	}
 }
 
+// SetETagHeader sets the ETag response header for the given document version string.
+// Does nothing if the version is empty.
+func SetETagHeader(w http.ResponseWriter, version string) {
+	if version != "" {
+		w.Header().Set(HeaderETag, strconv.Quote(version))
+	}
+}
+
 // EnforceMethod ensures that the incoming HTTP request uses the expected method.
 // If not, it writes a "method not allowed" response and returns false.
 func EnforceMethod(w http.ResponseWriter, r *http.Request, expectedMethod string) bool {
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,3 +35,30 @@ func DefaultCredentialsPath() string {
 	log.Printf(LogFallbackCredentialUsed, defaultPath)
 	return defaultPath
 }
+
+// VersionETag formats a document version as a strong HTTP entity tag, e.g. "3".
+func VersionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// IfMatchSatisfied reports whether an If-Match header value permits a write against a
+// document with the given version. An empty header always matches, "*" matches any existing
+// document, and otherwise one of the listed entity tags must equal the current version.
+func IfMatchSatisfied(header string, version int64, exists bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return true
+	}
+	if !exists {
+		return false
+	}
+
+	current := VersionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag) // Weak tags (W/"...") never match: If-Match uses strong comparison
+		if tag == ETagWildcard || tag == current {
+			return true
+		}
+	}
+	return false
+}